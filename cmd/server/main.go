@@ -1,29 +1,96 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"io/fs"
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
+	"time"
 
+	"github.com/adsouza/llms.txt-generator/internal/adapters/cache"
 	"github.com/adsouza/llms.txt-generator/internal/adapters/crawler"
 	"github.com/adsouza/llms.txt-generator/internal/adapters/formatter"
+	"github.com/adsouza/llms.txt-generator/internal/adapters/history"
 	"github.com/adsouza/llms.txt-generator/internal/adapters/httphandler"
+	"github.com/adsouza/llms.txt-generator/internal/adapters/schedules"
+	"github.com/adsouza/llms.txt-generator/internal/adapters/webhook"
 	"github.com/adsouza/llms.txt-generator/internal/frameworks"
 	"github.com/adsouza/llms.txt-generator/internal/usecases"
 	"github.com/adsouza/llms.txt-generator/static"
 )
 
 func main() {
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	cfg, inv, err := frameworks.LoadConfig(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
 	}
+	if err != nil {
+		log.Fatalf("invalid configuration: %v", err)
+	}
+	if inv.PrintConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	crawl, err := newCrawler(cfg.Crawler)
+	if err != nil {
+		log.Fatal(err)
+	}
+	svc := &usecases.Service{
+		Crawler:       crawl,
+		Formatter:     formatter.LlmsTxt{},
+		FullFormatter: formatter.LlmsFullTxt{},
+	}
+	if cfg.Cache.TTL > 0 {
+		store := newCache(cfg.Cache)
+		crawl.Cache = store
+		svc.Cache, svc.CacheTTL = store, time.Duration(cfg.Cache.TTL)
+	}
+	if cfg.SectionsFile != "" {
+		if svc.SectionNames, err = frameworks.LoadSectionNames(cfg.SectionsFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+	differ := &usecases.DiffService{Parser: formatter.Parser{}, TextDiffer: formatter.UnifiedDiff{}}
+	handler := httphandler.New(svc, svc, cfg.MaxConcurrent)
+	handler.MaxQueued = cfg.MaxQueued
+	handler.QueueTimeout = time.Duration(cfg.QueueTimeout)
+	handler.JobTTL = time.Duration(cfg.JobTTL)
+	handler.MaxJobs = cfg.MaxJobs
+	handler.BatchConcurrency = cfg.BatchConcurrency
+	handler.Differ = differ
+	if cfg.Auth.Enabled() {
+		handler.Access = newAccess(cfg.Auth)
+		handler.Access.PagesPerJob = cfg.Crawler.MaxPages
+	}
+	notifier, err := newWebhookClient(cfg, crawl)
+	if err != nil {
+		log.Fatal(err)
+	}
+	handler.Callbacks = notifier
+	if cfg.Storage.Dir != "" {
+		store, err := history.Open(filepath.Join(cfg.Storage.Dir, "history"))
+		if err != nil {
+			log.Fatal(err)
+		}
+		store.MaxEntries = cfg.Storage.HistoryLimit
+		svc.History, handler.History = store, store
+		svc.Snapshots = cache.Dir{Path: filepath.Join(cfg.Storage.Dir, "snapshots")}
 
-	crawl := &crawler.HTTPCrawler{Client: &http.Client{}}
-	fmt := formatter.LlmsTxt{}
-	svc := &usecases.Service{Crawler: crawl, Formatter: fmt}
-	handler := httphandler.New(svc, svc, 5)
+		scheduler, err := newScheduler(cfg, svc, differ, notifier)
+		if err != nil {
+			log.Fatal(err)
+		}
+		scheduler.Slots = handler
+		handler.Schedules = scheduler
+		go scheduler.Run(context.Background(), time.Minute)
+	}
 
 	frontendFS, err := fs.Sub(static.Frontend, "build")
 	if err != nil {
@@ -31,6 +98,112 @@ func main() {
 	}
 
 	srv := frameworks.NewServer(handler, frontendFS)
-	log.Printf("Listening on :%s", port)
-	log.Fatal(http.ListenAndServe(":"+port, srv))
+	log.Printf("Listening on %s", cfg.Listen)
+	log.Fatal(http.ListenAndServe(cfg.Listen, srv))
+}
+
+// newScheduler regenerates the sites registered under cfg.Storage.Dir.
+func newScheduler(cfg frameworks.Config, gen usecases.Generator, differ usecases.Differ, notifier usecases.Notifier) (*usecases.Scheduler, error) {
+	store, err := schedules.Open(filepath.Join(cfg.Storage.Dir, "schedules"))
+	if err != nil {
+		return nil, err
+	}
+	return &usecases.Scheduler{Generator: gen, Differ: differ, Store: store, Notifier: notifier}, nil
+}
+
+// newAccess checks API keys and quotas as configured.
+func newAccess(cfg frameworks.AuthConfig) *httphandler.Access {
+	access := &httphandler.Access{
+		RequireKey: cfg.RequireKey,
+		Anonymous:  usecases.Limits(cfg.Anonymous),
+		ProxyHops:  cfg.ProxyHops,
+		Quotas:     &usecases.Quotas{},
+	}
+	for _, k := range cfg.Keys {
+		limits := cfg.KeyLimits
+		if k.Limits != nil {
+			limits = *k.Limits
+		}
+		access.Keys = append(access.Keys, httphandler.APIKey{Name: k.Name, Key: k.Key, Limits: usecases.Limits(limits)})
+	}
+	return access
+}
+
+// newWebhookClient delivers callbacks and webhooks through the crawler's
+// proxy and SSRF guard, but always verifies TLS certificates, whatever
+// crawler.insecure_tls says. Redirects are not followed, so a notification
+// only reaches the URL that was checked.
+func newWebhookClient(cfg frameworks.Config, crawl *crawler.HTTPCrawler) (*webhook.Client, error) {
+	transport, err := crawler.NewTransport(crawler.TransportConfig{Guard: crawl.Guard, ProxyURL: cfg.Crawler.Proxy})
+	if err != nil {
+		return nil, err
+	}
+	return &webhook.Client{
+		HTTP: &http.Client{
+			Transport: transport,
+			Timeout:   time.Duration(cfg.Webhooks.Timeout),
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		UserAgent:   cfg.Crawler.UserAgent,
+		Secret:      cfg.Webhooks.Secret,
+		MaxAttempts: cfg.Webhooks.MaxAttempts,
+		RetryDelay:  time.Duration(cfg.Webhooks.RetryDelay),
+		CheckHost:   crawl.Guard.CheckHost,
+	}, nil
+}
+
+func newCache(cfg frameworks.CacheConfig) usecases.Cache {
+	if cfg.Dir != "" {
+		return cache.Dir{Path: cfg.Dir, MaxBytes: cfg.MaxBytes}
+	}
+	return cache.NewLRU(cfg.MaxBytes)
+}
+
+func newCrawler(cfg frameworks.CrawlerConfig) (*crawler.HTTPCrawler, error) {
+	allow, err := crawler.ParseNetworks(cfg.AllowNetworks)
+	if err != nil {
+		return nil, err
+	}
+	guard := &crawler.Guard{Allow: allow}
+
+	transport, err := crawler.NewTransport(crawler.TransportConfig{
+		Guard:              guard,
+		ProxyURL:           cfg.Proxy,
+		CAFile:             cfg.CAFile,
+		InsecureSkipVerify: cfg.InsecureTLS,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	crawl := &crawler.HTTPCrawler{
+		Client:            &http.Client{Transport: transport},
+		UserAgent:         cfg.UserAgent,
+		ContactURL:        cfg.ContactURL,
+		MaxPages:          cfg.MaxPages,
+		MaxDepth:          cfg.MaxDepth,
+		RequestTimeout:    time.Duration(cfg.RequestTimeout),
+		DescriptionLength: cfg.DescriptionLength,
+		MaxAttempts:       cfg.MaxAttempts,
+		RetryDelay:        time.Duration(cfg.RetryDelay),
+		Limits: crawler.Limits{
+			PageBytes:    cfg.PageBytes,
+			DocBytes:     cfg.DocBytes,
+			SitemapBytes: cfg.SitemapBytes,
+			RobotsBytes:  cfg.RobotsBytes,
+			Redirects:    cfg.Redirects,
+		},
+		Guard: guard,
+	}
+	if cfg.PrerenderURL != "" {
+		crawl.Renderer = &crawler.PrerenderRenderer{
+			Endpoint:  cfg.PrerenderURL,
+			Token:     cfg.PrerenderToken,
+			UserAgent: crawl.UserAgentHeader(),
+			Guard:     guard,
+		}
+	}
+	return crawl, nil
 }
@@ -1,9 +1,85 @@
+const MAX_RECONNECTS = 5;
+const RECONNECT_DELAY_MS = 1000;
+
 export function generateLlmsTxtStream(url, callbacks) {
-  const { onDiscovered, onProgress, onDone, onError } = callbacks;
+  const { onQueued, onDiscovered, onProgress, onRetry, onPageError, onDone, onError } = callbacks;
 
   const controller = new AbortController();
+  let location = '';
+  let lastEventId = '';
+  let finished = false;
+
+  function dispatch(eventType, parsed) {
+    switch (eventType) {
+      case 'job':
+        location = parsed.events_url;
+        break;
+      case 'queued':
+        onQueued?.(parsed.position);
+        break;
+      case 'discovered':
+        onDiscovered(parsed.urls, parsed.total);
+        break;
+      case 'progress':
+        onProgress(parsed.url, parsed.done, parsed.total);
+        break;
+      case 'retry':
+        onRetry?.(parsed.url, parsed.attempt, parsed.status, parsed.reason);
+        break;
+      case 'page_error':
+        onPageError?.(parsed.url, parsed.status, parsed.reason, parsed.done, parsed.total);
+        break;
+      case 'done':
+        finished = true;
+        onDone(parsed.llms_txt, parsed.failures || []);
+        break;
+      case 'error':
+        finished = true;
+        onError(parsed.error);
+        break;
+    }
+  }
+
+  // read consumes an event stream until it ends, remembering the last event
+  // ID so that a dropped connection can resume where it left off.
+  async function read(resp) {
+    const reader = resp.body.getReader();
+    const decoder = new TextDecoder();
+    let buffer = '';
+
+    while (true) {
+      const { done, value } = await reader.read();
+      if (done) break;
+
+      buffer += decoder.decode(value, { stream: true });
+
+      const parts = buffer.split('\n\n');
+      buffer = parts.pop();
+
+      for (const part of parts) {
+        const lines = part.split('\n');
+        let eventType = '';
+        let data = '';
+
+        for (const line of lines) {
+          if (line.startsWith('id: ')) {
+            lastEventId = line.slice(4);
+          } else if (line.startsWith('event: ')) {
+            eventType = line.slice(7);
+          } else if (line.startsWith('data: ')) {
+            data = line.slice(6);
+          }
+        }
+
+        if (!eventType || !data) continue;
+
+        dispatch(eventType, JSON.parse(data));
+      }
+    }
+  }
 
   (async () => {
+    let reconnects = 0;
     try {
       const resp = await fetch('/api/generate-stream', {
         method: 'POST',
@@ -13,55 +89,35 @@ export function generateLlmsTxtStream(url, callbacks) {
       });
 
       if (!resp.ok) {
-        const text = await resp.text();
-        onError(text || 'Generation failed');
+        const problem = await resp.json().catch(() => null);
+        const detail = problem?.errors?.[0]?.message || problem?.detail;
+        onError(detail || 'Generation failed');
         return;
       }
 
-      const reader = resp.body.getReader();
-      const decoder = new TextDecoder();
-      let buffer = '';
-
+      let stream = resp;
       while (true) {
-        const { done, value } = await reader.read();
-        if (done) break;
-
-        buffer += decoder.decode(value, { stream: true });
-
-        const parts = buffer.split('\n\n');
-        buffer = parts.pop();
-
-        for (const part of parts) {
-          const lines = part.split('\n');
-          let eventType = '';
-          let data = '';
-
-          for (const line of lines) {
-            if (line.startsWith('event: ')) {
-              eventType = line.slice(7);
-            } else if (line.startsWith('data: ')) {
-              data = line.slice(6);
-            }
-          }
+        try {
+          await read(stream);
+        } catch (err) {
+          if (err.name === 'AbortError' || !location || reconnects >= MAX_RECONNECTS) throw err;
+        }
+        if (finished) return;
+        if (!location || reconnects >= MAX_RECONNECTS) {
+          onError('Connection lost');
+          return;
+        }
 
-          if (!eventType || !data) continue;
-
-          const parsed = JSON.parse(data);
-
-          switch (eventType) {
-            case 'discovered':
-              onDiscovered(parsed.URLs, parsed.Total);
-              break;
-            case 'progress':
-              onProgress(parsed.CurrentURL, parsed.Done, parsed.Total);
-              break;
-            case 'done':
-              onDone(parsed.Result);
-              break;
-            case 'error':
-              onError(parsed.Error);
-              break;
-          }
+        // The crawl carries on server-side; pick up the events we missed.
+        reconnects++;
+        await new Promise((resolve) => setTimeout(resolve, RECONNECT_DELAY_MS));
+        stream = await fetch(location, {
+          headers: lastEventId ? { 'Last-Event-ID': lastEventId } : {},
+          signal: controller.signal,
+        });
+        if (!stream.ok) {
+          onError('Connection lost');
+          return;
         }
       }
     } catch (err) {
@@ -71,5 +127,11 @@ export function generateLlmsTxtStream(url, callbacks) {
     }
   })();
 
-  return () => controller.abort();
+  return () => {
+    controller.abort();
+    // Stop the crawl too, since it no longer stops with the connection.
+    if (location && !finished) {
+      fetch(location.replace(/\/events$/, ''), { method: 'DELETE' }).catch(() => {});
+    }
+  };
 }
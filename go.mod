@@ -5,4 +5,5 @@ go 1.25.6
 require (
 	github.com/danielgtaylor/huma/v2 v2.37.2
 	golang.org/x/net v0.50.0
+	golang.org/x/text v0.34.0
 )
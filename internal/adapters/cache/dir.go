@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Dir is a cache that keeps each entry in a file under Path, so entries
+// survive restarts. Files are named after a hash of their key. The directory
+// can be cleared at any time, which only costs the work of regenerating its
+// entries.
+type Dir struct {
+	Path string
+
+	// MaxBytes, if positive, bounds the total size of the entries: each Put
+	// removes the least recently used beyond it, by modification time, which
+	// reads refresh.
+	MaxBytes int64
+}
+
+// Get implements usecases.Cache. Entries that cannot be read are misses.
+func (d Dir) Get(key string) ([]byte, bool) {
+	data, err := os.ReadFile(d.file(key))
+	if err != nil {
+		return nil, false
+	}
+	if d.MaxBytes > 0 {
+		now := time.Now()
+		_ = os.Chtimes(d.file(key), now, now)
+	}
+	return data, true
+}
+
+// Put implements usecases.Cache. The entry is written to a temporary file and
+// renamed into place, so concurrent readers never see a partial value.
+func (d Dir) Put(key string, value []byte) error {
+	if err := os.MkdirAll(d.Path, 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(d.Path, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(value); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), d.file(key)); err != nil {
+		return err
+	}
+	if d.MaxBytes > 0 {
+		d.evict()
+	}
+	return nil
+}
+
+// evict removes the least recently used entries until the rest fit in
+// MaxBytes. Entries removed concurrently, or that cannot be removed, are
+// skipped.
+func (d Dir) evict() {
+	entries, err := os.ReadDir(d.Path)
+	if err != nil {
+		return
+	}
+	var files []fs.FileInfo
+	var total int64
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".tmp-") {
+			continue
+		}
+		if info, err := e.Info(); err == nil && info.Mode().IsRegular() {
+			files = append(files, info)
+			total += info.Size()
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].ModTime().Before(files[j].ModTime()) })
+	for _, f := range files {
+		if total <= d.MaxBytes {
+			return
+		}
+		if err := os.Remove(filepath.Join(d.Path, f.Name())); err == nil || errors.Is(err, fs.ErrNotExist) {
+			total -= f.Size()
+		}
+	}
+}
+
+func (d Dir) file(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(d.Path, hex.EncodeToString(sum[:]))
+}
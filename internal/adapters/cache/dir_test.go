@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDir_PutGet(t *testing.T) {
+	d := Dir{Path: filepath.Join(t.TempDir(), "cache")}
+
+	if _, ok := d.Get("result:abc"); ok {
+		t.Error("Get() on an empty cache found an entry")
+	}
+	if err := d.Put("result:abc", []byte("one")); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	if err := d.Put("result:abc", []byte("two")); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	if v, ok := d.Get("result:abc"); !ok || string(v) != "two" {
+		t.Errorf("Get() = %q, %v; want two", v, ok)
+	}
+
+	// Entries survive a new Dir on the same path, and no temporary files are left.
+	if v, ok := (Dir{Path: d.Path}).Get("result:abc"); !ok || string(v) != "two" {
+		t.Errorf("reopened Get() = %q, %v", v, ok)
+	}
+	entries, _ := os.ReadDir(d.Path)
+	if len(entries) != 1 {
+		t.Errorf("cache directory has %d files, want 1", len(entries))
+	}
+}
+
+func TestDir_EvictsLeastRecentlyUsed(t *testing.T) {
+	d := Dir{Path: t.TempDir(), MaxBytes: 10}
+
+	// Give each entry a distinct modification time, oldest first.
+	age := func(key string, ago time.Duration) {
+		at := time.Now().Add(-ago)
+		if err := os.Chtimes(d.file(key), at, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, key := range []string{"a", "b"} {
+		if err := d.Put(key, []byte("12345")); err != nil {
+			t.Fatalf("Put() error: %v", err)
+		}
+		age(key, time.Duration(2-i)*time.Hour)
+	}
+	// Reading a refreshes it, leaving b the least recently used.
+	if _, ok := d.Get("a"); !ok {
+		t.Fatal("Get(a) missed")
+	}
+	if err := d.Put("c", []byte("12345")); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, ok := d.Get(key); ok != want {
+			t.Errorf("Get(%s) found = %v, want %v", key, ok, want)
+		}
+	}
+}
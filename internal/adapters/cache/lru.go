@@ -0,0 +1,70 @@
+// Package cache implements usecases.Cache in memory and on disk.
+package cache
+
+import (
+	"container/list"
+	"sync"
+)
+
+// LRU is an in-memory cache that evicts the least recently used entries once
+// their values exceed a total size. It is safe for concurrent use.
+type LRU struct {
+	maxBytes int64
+
+	mu    sync.Mutex
+	size  int64
+	order *list.List // most recently used at the front
+	items map[string]*list.Element
+}
+
+type lruEntry struct {
+	key   string
+	value []byte
+}
+
+// NewLRU returns an LRU that holds up to maxBytes of values.
+func NewLRU(maxBytes int64) *LRU {
+	return &LRU{
+		maxBytes: maxBytes,
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// Get implements usecases.Cache.
+func (c *LRU) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*lruEntry).value, true
+}
+
+// Put implements usecases.Cache. Values larger than the whole cache are not
+// stored.
+func (c *LRU) Put(key string, value []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+	if int64(len(value)) > c.maxBytes {
+		return nil
+	}
+	c.items[key] = c.order.PushFront(&lruEntry{key: key, value: value})
+	c.size += int64(len(value))
+	for c.size > c.maxBytes {
+		c.remove(c.order.Back())
+	}
+	return nil
+}
+
+// remove deletes an entry. The caller holds c.mu.
+func (c *LRU) remove(el *list.Element) {
+	entry := c.order.Remove(el).(*lruEntry)
+	delete(c.items, entry.key)
+	c.size -= int64(len(entry.value))
+}
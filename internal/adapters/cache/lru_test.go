@@ -0,0 +1,37 @@
+package cache
+
+import "testing"
+
+func TestLRU_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewLRU(10)
+	_ = c.Put("a", []byte("aaaa"))
+	_ = c.Put("b", []byte("bbbb"))
+	if _, ok := c.Get("a"); !ok { // a is now more recently used than b
+		t.Fatal("a missing")
+	}
+	_ = c.Put("c", []byte("cccc"))
+
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, ok := c.Get(key); ok != want {
+			t.Errorf("Get(%q) present = %v, want %v", key, ok, want)
+		}
+	}
+}
+
+func TestLRU_Replace(t *testing.T) {
+	c := NewLRU(10)
+	_ = c.Put("a", []byte("old"))
+	_ = c.Put("a", []byte("new value"))
+	if v, ok := c.Get("a"); !ok || string(v) != "new value" {
+		t.Errorf("Get(a) = %q, %v", v, ok)
+	}
+	if c.size != 9 {
+		t.Errorf("size = %d, want 9", c.size)
+	}
+
+	// A value larger than the cache is dropped, along with the old one.
+	_ = c.Put("a", []byte("far too large"))
+	if _, ok := c.Get("a"); ok || c.size != 0 {
+		t.Errorf("oversized value kept, size = %d", c.size)
+	}
+}
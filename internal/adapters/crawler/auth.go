@@ -0,0 +1,105 @@
+package crawler
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+)
+
+// authTransport adds a job's credentials to requests for the site being
+// crawled. It runs for every redirect hop, so credentials are never sent to
+// other hosts, and they are never set on the request the client copies
+// headers from.
+type authTransport struct {
+	base  http.RoundTripper
+	site  *url.URL
+	creds domain.Credentials
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !sameSite(t.site, req.URL) {
+		return t.base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	for name, value := range t.creds.Headers {
+		req.Header.Set(name, value)
+	}
+	if t.creds.Username != "" || t.creds.Password != "" {
+		req.SetBasicAuth(t.creds.Username, t.creds.Password)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// authenticate configures client to send creds to the site at siteURL. The
+// cookies are kept in a jar, which also holds cookies the site sets while
+// following a login redirect.
+func authenticate(client *http.Client, siteURL string, creds domain.Credentials) {
+	site, err := url.Parse(siteURL)
+	if err != nil {
+		return
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	client.Transport = &authTransport{base: base, site: site, creds: creds}
+
+	if len(creds.Cookies) > 0 {
+		jar, _ := cookiejar.New(nil)
+		cookies := make([]*http.Cookie, 0, len(creds.Cookies))
+		for name, value := range creds.Cookies {
+			cookies = append(cookies, &http.Cookie{Name: name, Value: value})
+		}
+		jar.SetCookies(site, cookies)
+		client.Jar = &siteJar{jar: jar, site: site}
+	}
+}
+
+// siteJar restricts a cookie jar to the site's own origin. Plain cookie jars
+// share cookies between all ports of a host.
+type siteJar struct {
+	jar  http.CookieJar
+	site *url.URL
+}
+
+func (j *siteJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	if sameSite(j.site, u) {
+		j.jar.SetCookies(u, cookies)
+	}
+}
+
+func (j *siteJar) Cookies(u *url.URL) []*http.Cookie {
+	if !sameSite(j.site, u) {
+		return nil
+	}
+	return j.jar.Cookies(u)
+}
+
+// sameSite reports whether target is on the site's own scheme, host and port,
+// also allowing an upgrade from http to https but never a downgrade.
+func sameSite(site, target *url.URL) bool {
+	if !strings.EqualFold(site.Hostname(), target.Hostname()) {
+		return false
+	}
+	if site.Scheme == target.Scheme {
+		return effectivePort(site) == effectivePort(target)
+	}
+	return site.Scheme == "http" && effectivePort(site) == "80" &&
+		target.Scheme == "https" && effectivePort(target) == "443"
+}
+
+func effectivePort(u *url.URL) string {
+	if p := u.Port(); p != "" {
+		return p
+	}
+	switch u.Scheme {
+	case "https":
+		return "443"
+	case "http":
+		return "80"
+	}
+	return ""
+}
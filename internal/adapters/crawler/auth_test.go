@@ -0,0 +1,119 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+	"github.com/adsouza/llms.txt-generator/internal/usecases"
+)
+
+func TestFetchPage_SendsCredentialsToSite(t *testing.T) {
+	// offsite records what a host other than the crawled site receives.
+	var offsite http.Header
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offsite = r.Header.Clone()
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("<html><head><title>Elsewhere</title></head></html>"))
+	}))
+	defer other.Close()
+
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		cookie, _ := r.Cookie("session")
+		if user != "ci" || pass != "hunter2" || r.Header.Get("X-Api-Key") != "k3y" || cookie == nil || cookie.Value != "abc" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path == "/leave" {
+			http.Redirect(w, r, other.URL+"/landing", http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("<html><head><title>Staging Docs</title></head></html>"))
+	}))
+	defer site.Close()
+
+	creds := domain.Credentials{
+		Headers:  map[string]string{"X-Api-Key": "k3y"},
+		Cookies:  map[string]string{"session": "abc"},
+		Username: "ci",
+		Password: "hunter2",
+	}
+	ctx := usecases.WithCredentials(context.Background(), site.URL, creds)
+	c := &HTTPCrawler{Client: site.Client()}
+
+	page, err := c.FetchPage(ctx, site.URL+"/docs")
+	if err != nil {
+		t.Fatalf("FetchPage() error: %v", err)
+	}
+	if page.Title != "Staging Docs" {
+		t.Errorf("title = %q, want %q", page.Title, "Staging Docs")
+	}
+
+	if _, err := c.FetchPage(context.Background(), site.URL+"/docs"); err == nil {
+		t.Error("FetchPage() without credentials succeeded, want 401")
+	}
+
+	if _, err := c.FetchPage(ctx, site.URL+"/leave"); err != nil {
+		t.Fatalf("FetchPage() redirect error: %v", err)
+	}
+	for _, name := range []string{"Authorization", "X-Api-Key", "Cookie"} {
+		if v := offsite.Get(name); v != "" {
+			t.Errorf("off-site redirect target received %s: %q", name, v)
+		}
+	}
+
+	if _, err := c.FetchPage(ctx, other.URL+"/listed-in-sitemap"); err != nil {
+		t.Fatalf("FetchPage() off-site error: %v", err)
+	}
+	if v := offsite.Get("Authorization"); v != "" {
+		t.Errorf("off-site page received Authorization: %q", v)
+	}
+}
+
+func TestSameSite(t *testing.T) {
+	tests := []struct {
+		site, target string
+		want         bool
+	}{
+		{"https://example.com", "https://example.com/docs", true},
+		{"https://example.com", "https://EXAMPLE.com:443/docs", true},
+		{"http://example.com", "https://example.com/docs", true},
+		{"https://example.com", "http://example.com/docs", false},
+		{"https://example.com", "https://docs.example.com/", false},
+		{"https://example.com", "https://example.com:8443/", false},
+		{"https://example.com", "https://example.com.evil.net/", false},
+	}
+	for _, tt := range tests {
+		site, _ := url.Parse(tt.site)
+		target, _ := url.Parse(tt.target)
+		if got := sameSite(site, target); got != tt.want {
+			t.Errorf("sameSite(%q, %q) = %v, want %v", tt.site, tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestLoadHTML_DoesNotRenderAuthenticatedPages(t *testing.T) {
+	site := newAppServer(t, appShell)
+	prerender, calls := newPrerenderServer(t, func(w http.ResponseWriter, pageURL string) {
+		_, _ = w.Write([]byte("<html><head><title>Login</title></head></html>"))
+	})
+
+	c := &HTTPCrawler{
+		Client:   site.Client(),
+		Renderer: &PrerenderRenderer{Endpoint: prerender.URL + "/", Token: "secret", Client: prerender.Client()},
+	}
+	ctx := usecases.WithCredentials(context.Background(), site.URL, domain.Credentials{Headers: map[string]string{"Authorization": "Bearer t"}})
+	page, err := c.FetchPage(ctx, site.URL+"/")
+	if err != nil {
+		t.Fatalf("FetchPage() error: %v", err)
+	}
+	if calls.Load() != 0 || !strings.Contains(page.Title, "React App") {
+		t.Errorf("title = %q after %d prerender calls, want raw page and no calls", page.Title, calls.Load())
+	}
+}
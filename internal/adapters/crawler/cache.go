@@ -0,0 +1,124 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/adsouza/llms.txt-generator/internal/usecases"
+)
+
+// cachedPage is a response body stored with the validators needed to
+// revalidate it with a conditional GET.
+type cachedPage struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	ContentType  string `json:"content_type"`
+	Body         []byte `json:"body"`
+}
+
+// pageCache returns the cache for responses fetched with ctx, or nil if they
+// must not be cached because they were fetched with credentials.
+func (c *HTTPCrawler) pageCache(ctx context.Context) usecases.Cache {
+	if _, _, authenticated := usecases.SiteCredentials(ctx); authenticated {
+		return nil
+	}
+	return c.Cache
+}
+
+func pageKey(rawURL string) string { return "page:" + rawURL }
+
+// loadPage returns the cached response for rawURL, if any.
+func loadPage(cache usecases.Cache, rawURL string) (cachedPage, bool) {
+	if cache == nil {
+		return cachedPage{}, false
+	}
+	data, ok := cache.Get(pageKey(rawURL))
+	if !ok {
+		return cachedPage{}, false
+	}
+	var page cachedPage
+	if err := json.Unmarshal(data, &page); err != nil {
+		return cachedPage{}, false
+	}
+	return page, true
+}
+
+// setValidators makes req conditional on a stored response still being
+// current, reporting whether it has any validators to check.
+func setValidators(req *http.Request, etag, lastModified string) bool {
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+	return etag != "" || lastModified != ""
+}
+
+// contentHasher returns a writer that hashes a response body as it is read,
+// and a function returning the hex SHA-256 of what it has been given.
+func contentHasher() (io.Writer, func() string) {
+	h := sha256.New()
+	return h, func() string { return hex.EncodeToString(h.Sum(nil)) }
+}
+
+// recordingReader passes a response body through, storing it in the cache
+// once it has been read to the end. Bodies that are only partly read, or that
+// exceed the size limit, are not stored.
+type recordingReader struct {
+	r     io.Reader
+	buf   bytes.Buffer
+	store func(body []byte)
+}
+
+func (r *recordingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.buf.Write(p[:n])
+	if err == io.EOF && r.store != nil {
+		r.store(r.buf.Bytes())
+		r.store = nil
+	}
+	return n, err
+}
+
+// storable reports whether the Cache-Control header allows a response to be
+// kept: not when it has a no-store directive, nor a private one, since the
+// cache is shared by every client.
+func storable(header http.Header) bool {
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			name, _, _ := strings.Cut(directive, "=")
+			switch strings.ToLower(strings.TrimSpace(name)) {
+			case "no-store", "private":
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// recordResponse wraps body so that it is cached under rawURL with the
+// validators of resp, if resp has any.
+func recordResponse(cache usecases.Cache, rawURL string, resp *http.Response, body io.Reader) io.Reader {
+	page := cachedPage{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		ContentType:  resp.Header.Get("Content-Type"),
+	}
+	if cache == nil || (page.ETag == "" && page.LastModified == "") || !storable(resp.Header) {
+		return body
+	}
+	return &recordingReader{r: body, store: func(data []byte) {
+		page.Body = data
+		if encoded, err := json.Marshal(page); err == nil {
+			// A page that cannot be stored is simply downloaded again next time.
+			_ = cache.Put(pageKey(rawURL), encoded)
+		}
+	}}
+}
@@ -0,0 +1,204 @@
+package crawler
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+	"github.com/adsouza/llms.txt-generator/internal/usecases"
+)
+
+// mapCache is an in-memory usecases.Cache without eviction.
+type mapCache struct {
+	mu      sync.Mutex
+	entries map[string][]byte
+}
+
+func (m *mapCache) Get(key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.entries[key]
+	return v, ok
+}
+
+func (m *mapCache) Put(key string, value []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.entries == nil {
+		m.entries = make(map[string][]byte)
+	}
+	m.entries[key] = value
+	return nil
+}
+
+// contentHash returns the hash fetchPage records for body.
+func contentHash(body string) string {
+	w, hash := contentHasher()
+	_, _ = io.WriteString(w, body)
+	return hash()
+}
+
+// newRevalidatingServer serves a page with the given validator headers,
+// answering matching conditional requests with 304. It counts full responses.
+func newRevalidatingServer(t *testing.T, etag, lastModified string, downloads *int) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if (etag != "" && r.Header.Get("If-None-Match") == etag) ||
+			(lastModified != "" && r.Header.Get("If-Modified-Since") == lastModified) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		*downloads++
+		if etag != "" {
+			w.Header().Set("ETag", etag)
+		}
+		if lastModified != "" {
+			w.Header().Set("Last-Modified", lastModified)
+		}
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<html><head><title>Cached Page</title><meta name="description" content="Served once."></head></html>`))
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestFetchPage_ConditionalGet(t *testing.T) {
+	tests := []struct {
+		name          string
+		etag          string
+		lastModified  string
+		wantDownloads int
+	}{
+		{"etag", `"v1"`, "", 1},
+		{"last modified", "", "Mon, 05 Oct 2026 10:00:00 GMT", 1},
+		{"no validators", "", "", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var downloads int
+			ts := newRevalidatingServer(t, tt.etag, tt.lastModified, &downloads)
+			c := &HTTPCrawler{Client: ts.Client(), Cache: &mapCache{}}
+
+			for range 3 {
+				page, err := c.FetchPage(context.Background(), ts.URL+"/docs")
+				if err != nil {
+					t.Fatalf("FetchPage() error: %v", err)
+				}
+				if page.Title != "Cached Page" || page.Description != "Served once." {
+					t.Errorf("page = %+v", page)
+				}
+			}
+			if downloads != tt.wantDownloads {
+				t.Errorf("downloads = %d, want %d", downloads, tt.wantDownloads)
+			}
+		})
+	}
+}
+
+func TestFetchPage_AuthenticatedPagesNotCached(t *testing.T) {
+	var downloads int
+	ts := newRevalidatingServer(t, `"v1"`, "", &downloads)
+	cache := &mapCache{}
+	c := &HTTPCrawler{Client: ts.Client(), Cache: cache}
+
+	ctx := usecases.WithCredentials(context.Background(), ts.URL, domain.Credentials{Headers: map[string]string{"Authorization": "Bearer t0ken"}})
+	for range 2 {
+		if _, err := c.FetchPage(ctx, ts.URL+"/private"); err != nil {
+			t.Fatalf("FetchPage() error: %v", err)
+		}
+	}
+	if downloads != 2 || len(cache.entries) != 0 {
+		t.Errorf("downloads = %d, cache entries = %d; want 2 downloads and nothing cached", downloads, len(cache.entries))
+	}
+}
+
+func TestStorable(t *testing.T) {
+	tests := []struct {
+		cacheControl []string
+		want         bool
+	}{
+		{nil, true},
+		{[]string{"max-age=3600, must-revalidate"}, true},
+		{[]string{"no-store"}, false},
+		{[]string{"max-age=0, No-Store"}, false},
+		{[]string{"private"}, false},
+		{[]string{"private=\"Set-Cookie\", max-age=60"}, false},
+		{[]string{"public", "private"}, false},
+		{[]string{"no-cache"}, true},
+	}
+	for _, tt := range tests {
+		header := http.Header{"Cache-Control": tt.cacheControl}
+		if got := storable(header); got != tt.want {
+			t.Errorf("storable(%q) = %v, want %v", tt.cacheControl, got, tt.want)
+		}
+	}
+}
+
+func TestFetchPage_ReusesUnchangedPreviousPage(t *testing.T) {
+	const body = `<html><head><title>Current Title</title></head></html>`
+	tests := []struct {
+		name          string
+		etag          string // served by the site
+		previous      domain.Page
+		wantTitle     string
+		wantDownloads int
+	}{
+		{
+			name:          "not modified",
+			etag:          `"v1"`,
+			previous:      domain.Page{Title: "From Snapshot", ETag: `"v1"`},
+			wantTitle:     "From Snapshot",
+			wantDownloads: 0,
+		},
+		{
+			name:          "same content",
+			previous:      domain.Page{Title: "From Snapshot", ContentHash: contentHash(body)},
+			wantTitle:     "From Snapshot",
+			wantDownloads: 1,
+		},
+		{
+			name:          "changed",
+			etag:          `"v2"`,
+			previous:      domain.Page{Title: "From Snapshot", ETag: `"v1"`, ContentHash: contentHash("old")},
+			wantTitle:     "Current Title",
+			wantDownloads: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var downloads int
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.etag != "" && r.Header.Get("If-None-Match") == tt.etag {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				downloads++
+				if tt.etag != "" {
+					w.Header().Set("ETag", tt.etag)
+				}
+				w.Header().Set("Content-Type", "text/html")
+				_, _ = w.Write([]byte(body))
+			}))
+			defer ts.Close()
+			c := &HTTPCrawler{Client: ts.Client()}
+
+			previous := tt.previous
+			previous.URL = ts.URL + "/docs"
+			ctx := usecases.WithPrevious(context.Background(), &domain.Snapshot{Pages: []domain.Page{previous}})
+			page, err := c.FetchPage(ctx, previous.URL)
+			if err != nil {
+				t.Fatalf("FetchPage() error: %v", err)
+			}
+			if page.Title != tt.wantTitle || downloads != tt.wantDownloads {
+				t.Errorf("title = %q after %d downloads, want %q after %d", page.Title, downloads, tt.wantTitle, tt.wantDownloads)
+			}
+			if page.ContentHash == "" && tt.wantDownloads > 0 {
+				t.Error("page has no content hash")
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package crawler
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+
+	"golang.org/x/net/html/charset"
+)
+
+const utf8BOM = "\xef\xbb\xbf"
+
+// decodeHTML returns a UTF-8 reader for an HTML or plain-text body, detecting
+// its encoding from a byte order mark, the Content-Type header or a
+// <meta charset> declaration, in that order.
+func decodeHTML(r io.Reader, contentType string) (io.Reader, error) {
+	return charset.NewReader(r, contentType)
+}
+
+// unmarshalXML decodes an XML document into v. The encoding comes from a byte
+// order mark or the Content-Type header when present, and otherwise from the
+// document's XML declaration.
+func unmarshalXML(data []byte, contentType string, v any) error {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.CharsetReader = charset.NewReaderLabel
+
+	if e, name, certain := charset.DetermineEncoding(data, contentType); certain {
+		// The document is transcoded up front, so the encoding named in its
+		// XML declaration must not be applied a second time.
+		if name != "utf-8" {
+			decoded, err := io.ReadAll(e.NewDecoder().Reader(bytes.NewReader(data)))
+			if err != nil {
+				return err
+			}
+			data = decoded
+		}
+		data = bytes.TrimPrefix(data, []byte(utf8BOM))
+		dec = xml.NewDecoder(bytes.NewReader(data))
+		dec.CharsetReader = func(_ string, r io.Reader) (io.Reader, error) { return r, nil }
+	}
+	return dec.Decode(v)
+}
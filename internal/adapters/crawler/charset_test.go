@@ -0,0 +1,119 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func encode(t *testing.T, e encoding.Encoding, s string) []byte {
+	t.Helper()
+	b, err := e.NewEncoder().Bytes([]byte(s))
+	if err != nil {
+		t.Fatalf("encode %q: %v", s, err)
+	}
+	return b
+}
+
+func TestFetchPage_DecodesCharsets(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		wantTitle   string
+		wantDesc    string
+	}{
+		{
+			name:        "Shift_JIS from header",
+			contentType: "text/html; charset=Shift_JIS",
+			body:        encode(t, japanese.ShiftJIS, `<html><head><title>日本語のページ</title><meta name="description" content="説明文"></head></html>`),
+			wantTitle:   "日本語のページ",
+			wantDesc:    "説明文",
+		},
+		{
+			name:        "windows-1252 from meta charset",
+			contentType: "text/html",
+			body:        encode(t, charmap.Windows1252, `<html><head><meta charset="windows-1252"><title>Café “Crème”</title></head></html>`),
+			wantTitle:   "Café “Crème”",
+		},
+		{
+			name:        "ISO-8859-2 from http-equiv",
+			contentType: "text/html",
+			body:        encode(t, charmap.ISO8859_2, `<html><head><meta http-equiv="Content-Type" content="text/html; charset=iso-8859-2"><title>Zażółć gęślą jaźń</title></head></html>`),
+			wantTitle:   "Zażółć gęślą jaźń",
+		},
+		{
+			name:        "UTF-16 with BOM",
+			contentType: "text/html",
+			body:        encode(t, unicode.UTF16(unicode.LittleEndian, unicode.UseBOM), `<html><head><title>Grüße</title></head></html>`),
+			wantTitle:   "Grüße",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				_, _ = w.Write(tt.body)
+			}))
+			defer ts.Close()
+
+			c := &HTTPCrawler{Client: ts.Client()}
+			page, err := c.FetchPage(context.Background(), ts.URL+"/")
+			if err != nil {
+				t.Fatalf("FetchPage() error: %v", err)
+			}
+			if page.Title != tt.wantTitle {
+				t.Errorf("title = %q, want %q", page.Title, tt.wantTitle)
+			}
+			if page.Description != tt.wantDesc {
+				t.Errorf("description = %q, want %q", page.Description, tt.wantDesc)
+			}
+		})
+	}
+}
+
+func TestUnmarshalXML_Charsets(t *testing.T) {
+	const doc = `<urlset><url><loc>https://example.com/café</loc></url></urlset>`
+	tests := []struct {
+		name        string
+		contentType string
+		data        []byte
+	}{
+		{"UTF-8 default", "application/xml", []byte(`<?xml version="1.0"?>` + doc)},
+		{"declaration", "application/xml", encode(t, charmap.ISO8859_1, `<?xml version="1.0" encoding="ISO-8859-1"?>`+doc)},
+		{"header", "application/xml; charset=iso-8859-1", encode(t, charmap.ISO8859_1, `<?xml version="1.0"?>`+doc)},
+		{"header overrides declaration", "text/xml; charset=iso-8859-1", encode(t, charmap.ISO8859_1, `<?xml version="1.0" encoding="ISO-8859-1"?>`+doc)},
+		{"UTF-8 BOM", "application/xml", []byte(utf8BOM + `<?xml version="1.0" encoding="UTF-8"?>` + doc)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var urlset sitemapURLSet
+			if err := unmarshalXML(tt.data, tt.contentType, &urlset); err != nil {
+				t.Fatalf("unmarshalXML() error: %v", err)
+			}
+			if len(urlset.URLs) != 1 || urlset.URLs[0].Loc != "https://example.com/café" {
+				t.Errorf("urls = %+v, want the café URL", urlset.URLs)
+			}
+		})
+	}
+}
+
+func TestFetchRobots_DecodesCharset(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=iso-8859-1")
+		_, _ = w.Write(encode(t, charmap.ISO8859_1, "User-agent: *\nDisallow: /privé\n"))
+	}))
+	defer ts.Close()
+
+	c := &HTTPCrawler{Client: ts.Client()}
+	robots := c.fetchRobots(context.Background(), ts.URL)
+	if len(robots.disallowed) != 1 || robots.disallowed[0] != "/privé" {
+		t.Errorf("disallowed = %q, want [/privé]", robots.disallowed)
+	}
+}
@@ -0,0 +1,420 @@
+package crawler
+
+import (
+	"bytes"
+	"cmp"
+	"compress/zlib"
+	"io"
+	"mime"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+)
+
+// documentKind is the type of document a response holds, which decides how
+// its metadata is extracted.
+type documentKind int
+
+const (
+	kindUnsupported documentKind = iota
+	kindHTML
+	kindPDF
+	kindText
+	kindMarkdown
+)
+
+// maxInflatedBytes bounds the decompressed PDF streams searched for metadata.
+const maxInflatedBytes = 10 << 20
+
+// classify determines a document's kind from its Content-Type, falling back
+// to the URL's extension for generic types that servers often send for
+// Markdown and PDF files.
+func classify(contentType, pageURL string) documentKind {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/html", "application/xhtml+xml":
+		return kindHTML
+	case "application/pdf", "application/x-pdf":
+		return kindPDF
+	case "text/markdown", "text/x-markdown":
+		return kindMarkdown
+	}
+
+	var ext string
+	if u, err := url.Parse(pageURL); err == nil {
+		ext = strings.ToLower(path.Ext(u.Path))
+	}
+	switch {
+	case ext == ".md" || ext == ".markdown":
+		if mediaType == "" || mediaType == "text/plain" || mediaType == "application/octet-stream" {
+			return kindMarkdown
+		}
+	case ext == ".pdf":
+		if mediaType == "" || mediaType == "application/octet-stream" {
+			return kindPDF
+		}
+	case mediaType == "":
+		return kindHTML
+	}
+	if mediaType == "text/plain" {
+		return kindText
+	}
+	return kindUnsupported
+}
+
+// parseText extracts a title, description and content from a plain-text or
+// Markdown document. The title is the first heading (Markdown) or first line
+// (plain text), and the description is the first paragraph of prose.
+func (c *HTTPCrawler) parseText(pageURL string, r io.Reader, contentType string, markdown bool) (domain.Page, error) {
+	decoded, err := decodeHTML(r, contentType)
+	if err != nil {
+		return domain.Page{}, err
+	}
+	data, err := io.ReadAll(decoded)
+	if err != nil {
+		return domain.Page{}, err
+	}
+	text := strings.TrimPrefix(strings.ReplaceAll(string(data), "\r\n", "\n"), utf8BOM)
+
+	var title string
+	var paragraphs, current []string
+	prose := true // whether the current block is prose rather than a list, table, etc.
+	flush := func() {
+		if len(current) > 0 && prose {
+			paragraphs = append(paragraphs, strings.Join(current, " "))
+		}
+		current, prose = nil, true
+	}
+	inFence := false
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if markdown {
+			if strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~") {
+				flush()
+				inFence = !inFence
+				continue
+			}
+			if inFence {
+				continue
+			}
+			switch {
+			case strings.HasPrefix(line, "#"):
+				flush()
+				if heading := strings.TrimSpace(strings.Trim(line, "#")); title == "" {
+					title = heading
+				}
+				continue
+			case isSetextUnderline(line) && len(current) == 1 && prose:
+				if title == "" {
+					title = current[0]
+				}
+				current = nil
+				continue
+			case line != "" && isMarkdownBlock(line):
+				prose = false
+			}
+		} else if title == "" && line != "" {
+			title = line
+			continue
+		}
+		if line == "" {
+			flush()
+			continue
+		}
+		current = append(current, line)
+	}
+	flush()
+
+	page := domain.Page{URL: pageURL, Title: title, Content: strings.TrimSpace(text)}
+	if page.Title == "" {
+		page.Title = fileTitle(pageURL)
+	}
+	if len(paragraphs) > 0 {
+		desc := paragraphs[0]
+		if markdown {
+			desc = stripMarkdown(desc)
+		}
+		page.Description = truncateAtSentence(desc, c.descriptionLength())
+	}
+	return page, nil
+}
+
+func isSetextUnderline(line string) bool {
+	return len(line) >= 2 && (strings.Trim(line, "=") == "" || strings.Trim(line, "-") == "")
+}
+
+// isMarkdownBlock reports whether a line starts a block that is not prose.
+func isMarkdownBlock(line string) bool {
+	for _, prefix := range []string{">", "- ", "* ", "+ ", "|", "<", "![", "---", "***", "==="} {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	markdownLink     = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+	markdownEmphasis = strings.NewReplacer("**", "", "__", "", "`", "")
+)
+
+// stripMarkdown removes inline link and emphasis syntax from a paragraph.
+func stripMarkdown(s string) string {
+	return markdownEmphasis.Replace(markdownLink.ReplaceAllString(s, "$1"))
+}
+
+// fileTitle derives a title from the last path segment of a URL.
+func fileTitle(pageURL string) string {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return pageURL
+	}
+	name := path.Base(u.Path)
+	name = strings.TrimSuffix(name, path.Ext(name))
+	if name == "" || name == "." || name == "/" {
+		return u.Hostname()
+	}
+	if unescaped, err := url.PathUnescape(name); err == nil {
+		name = unescaped
+	}
+	return strings.Join(strings.FieldsFunc(name, func(r rune) bool { return r == '-' || r == '_' }), " ")
+}
+
+// parsePDF extracts the title and subject from a PDF's document information
+// dictionary or XMP metadata, searching compressed object streams if needed.
+// Text content is not extracted, so PDFs do not contribute to llms-full.
+func (c *HTTPCrawler) parsePDF(pageURL string, r io.Reader) (domain.Page, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return domain.Page{}, err
+	}
+
+	title, subject := pdfMetadata(data)
+	if title == "" || subject == "" {
+		inflated := inflatePDFStreams(data)
+		t, s := pdfMetadata(inflated)
+		title = cmp.Or(title, t)
+		subject = cmp.Or(subject, s)
+	}
+	if title == "" {
+		title = fileTitle(pageURL)
+	}
+	return domain.Page{
+		URL:         pageURL,
+		Title:       title,
+		Description: truncateAtSentence(subject, c.descriptionLength()),
+	}, nil
+}
+
+var (
+	xmpTitle       = regexp.MustCompile(`(?s)<dc:title>.*?<rdf:li[^>]*>(.*?)</rdf:li>`)
+	xmpDescription = regexp.MustCompile(`(?s)<dc:description>.*?<rdf:li[^>]*>(.*?)</rdf:li>`)
+	xmlEntities    = strings.NewReplacer("&lt;", "<", "&gt;", ">", "&quot;", `"`, "&apos;", "'", "&amp;", "&")
+	pdfStream      = regexp.MustCompile(`\bstream\r?\n`)
+)
+
+// pdfMetadata looks for /Title and /Subject entries, then XMP dc:title and
+// dc:description elements.
+func pdfMetadata(data []byte) (title, subject string) {
+	title = pdfInfoString(data, "/Title")
+	subject = pdfInfoString(data, "/Subject")
+	if title == "" {
+		if m := xmpTitle.FindSubmatch(data); m != nil {
+			title = strings.TrimSpace(xmlEntities.Replace(string(m[1])))
+		}
+	}
+	if subject == "" {
+		if m := xmpDescription.FindSubmatch(data); m != nil {
+			subject = strings.TrimSpace(xmlEntities.Replace(string(m[1])))
+		}
+	}
+	return title, subject
+}
+
+// pdfInfoString returns the string value of the first occurrence of key,
+// following an indirect reference such as "/Title 12 0 R" if necessary.
+func pdfInfoString(data []byte, key string) string {
+	for off := 0; ; {
+		i := bytes.Index(data[off:], []byte(key))
+		if i < 0 {
+			return ""
+		}
+		off += i + len(key)
+		// Skip keys that merely share a prefix, such as /TitleFont.
+		if off < len(data) && isPDFNameChar(data[off]) {
+			continue
+		}
+		rest := bytes.TrimLeft(data[off:], " \t\r\n")
+		if ref := pdfReference.FindSubmatch(rest); ref != nil {
+			if obj := pdfObject(data, ref[1], ref[2]); obj != nil {
+				rest = obj
+			}
+		}
+		if s, ok := pdfString(rest); ok {
+			return strings.TrimSpace(s)
+		}
+	}
+}
+
+var pdfReference = regexp.MustCompile(`^(\d+)\s+(\d+)\s+R\b`)
+
+// pdfObject returns the data following the header of the object numbered num
+// and gen, such as "12 0 obj", or nil if there is none.
+func pdfObject(data, num, gen []byte) []byte {
+	for off := 0; ; {
+		i := bytes.Index(data[off:], num)
+		if i < 0 {
+			return nil
+		}
+		start := off + i
+		off = start + len(num)
+		// Skip numbers that merely end in num, such as 112 for 12.
+		if start > 0 && !isPDFSpace(data[start-1]) {
+			continue
+		}
+		if m := pdfObjectHeader.FindSubmatchIndex(data[off:]); m != nil && bytes.Equal(data[off+m[2]:off+m[3]], gen) {
+			return data[off+m[1]:]
+		}
+	}
+}
+
+var pdfObjectHeader = regexp.MustCompile(`^\s+(\d+)\s+obj\s*`)
+
+func isPDFSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\r' || b == '\n' || b == '\f'
+}
+
+func isPDFNameChar(b byte) bool {
+	return b > ' ' && !bytes.ContainsRune([]byte("()<>[]{}/%"), rune(b))
+}
+
+// pdfString decodes a literal "(...)" or hex "<...>" string at the start of data.
+func pdfString(data []byte) (string, bool) {
+	if len(data) == 0 {
+		return "", false
+	}
+	var raw []byte
+	switch data[0] {
+	case '(':
+		depth := 0
+	literal:
+		for i := 0; i < len(data); i++ {
+			b := data[i]
+			switch b {
+			case '\\':
+				i++
+				if i >= len(data) {
+					break literal
+				}
+				switch e := data[i]; e {
+				case 'n':
+					raw = append(raw, '\n')
+				case 'r':
+					raw = append(raw, '\r')
+				case 't':
+					raw = append(raw, '\t')
+				case 'b':
+					raw = append(raw, '\b')
+				case 'f':
+					raw = append(raw, '\f')
+				case '\r', '\n':
+					// Line continuation.
+				default:
+					if e >= '0' && e <= '7' {
+						j := i
+						for j < len(data) && j < i+3 && data[j] >= '0' && data[j] <= '7' {
+							j++
+						}
+						n, _ := strconv.ParseUint(string(data[i:j]), 8, 8)
+						raw = append(raw, byte(n))
+						i = j - 1
+					} else {
+						raw = append(raw, e)
+					}
+				}
+			case '(':
+				if depth > 0 {
+					raw = append(raw, b)
+				}
+				depth++
+			case ')':
+				depth--
+				if depth == 0 {
+					return decodePDFText(raw), true
+				}
+				raw = append(raw, b)
+			default:
+				raw = append(raw, b)
+			}
+		}
+		return "", false
+	case '<':
+		end := bytes.IndexByte(data, '>')
+		if end < 0 || (len(data) > 1 && data[1] == '<') {
+			return "", false
+		}
+		hex := bytes.Map(func(r rune) rune {
+			if strings.ContainsRune(" \t\r\n", r) {
+				return -1
+			}
+			return r
+		}, data[1:end])
+		if len(hex)%2 == 1 {
+			hex = append(hex, '0')
+		}
+		for i := 0; i+1 < len(hex); i += 2 {
+			n, err := strconv.ParseUint(string(hex[i:i+2]), 16, 8)
+			if err != nil {
+				return "", false
+			}
+			raw = append(raw, byte(n))
+		}
+		return decodePDFText(raw), true
+	}
+	return "", false
+}
+
+// decodePDFText decodes a PDF text string, which is UTF-16BE when it starts
+// with a byte order mark and PDFDocEncoding (close to Latin-1) otherwise.
+func decodePDFText(raw []byte) string {
+	if len(raw) >= 2 && raw[0] == 0xFE && raw[1] == 0xFF {
+		units := make([]uint16, 0, len(raw)/2)
+		for i := 2; i+1 < len(raw); i += 2 {
+			units = append(units, uint16(raw[i])<<8|uint16(raw[i+1]))
+		}
+		return string(utf16.Decode(units))
+	}
+	if bytes.HasPrefix(raw, []byte(utf8BOM)) {
+		return string(raw[len(utf8BOM):])
+	}
+	runes := make([]rune, len(raw))
+	for i, b := range raw {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
+
+// inflatePDFStreams decompresses the zlib-compressed streams of a PDF, where
+// PDF 1.5+ files keep their document information in object streams. Streams
+// using other filters fail zlib's header check and are skipped.
+func inflatePDFStreams(data []byte) []byte {
+	var out bytes.Buffer
+	for _, loc := range pdfStream.FindAllIndex(data, -1) {
+		zr, err := zlib.NewReader(bytes.NewReader(data[loc[1]:]))
+		if err != nil {
+			continue
+		}
+		_, _ = io.Copy(&out, io.LimitReader(zr, int64(maxInflatedBytes-out.Len())))
+		_ = zr.Close()
+		out.WriteByte('\n')
+		if out.Len() >= maxInflatedBytes {
+			break
+		}
+	}
+	return out.Bytes()
+}
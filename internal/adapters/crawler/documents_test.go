@@ -0,0 +1,158 @@
+package crawler
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		contentType, url string
+		want             documentKind
+	}{
+		{"text/html; charset=utf-8", "https://example.com/", kindHTML},
+		{"", "https://example.com/page", kindHTML},
+		{"application/pdf", "https://example.com/download?id=1", kindPDF},
+		{"application/octet-stream", "https://example.com/whitepaper.PDF", kindPDF},
+		{"", "https://example.com/whitepaper.pdf", kindPDF},
+		{"text/markdown", "https://example.com/readme", kindMarkdown},
+		{"text/plain", "https://example.com/README.md", kindMarkdown},
+		{"text/plain; charset=utf-8", "https://example.com/notes.txt", kindText},
+		{"image/png", "https://example.com/logo.png", kindUnsupported},
+		{"application/octet-stream", "https://example.com/setup.exe", kindUnsupported},
+	}
+	for _, tt := range tests {
+		if got := classify(tt.contentType, tt.url); got != tt.want {
+			t.Errorf("classify(%q, %q) = %v, want %v", tt.contentType, tt.url, got, tt.want)
+		}
+	}
+}
+
+func serveDocument(t *testing.T, contentType string, body []byte) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestFetchPage_Markdown(t *testing.T) {
+	doc := "---\ntitle: ignored\n---\n\n# Widget API\n\n![logo](logo.png)\n\n" +
+		"The **Widget API** lets you [manage widgets](https://example.com/w) programmatically.\n\n" +
+		"```go\nfmt.Println(\"not a description\")\n```\n\n- a list item\n"
+	ts := serveDocument(t, "text/plain; charset=utf-8", []byte(doc))
+
+	c := &HTTPCrawler{Client: ts.Client()}
+	page, err := c.FetchPage(context.Background(), ts.URL+"/docs/api.md")
+	if err != nil {
+		t.Fatalf("FetchPage() error: %v", err)
+	}
+	if page.Title != "Widget API" {
+		t.Errorf("title = %q, want %q", page.Title, "Widget API")
+	}
+	if want := "The Widget API lets you manage widgets programmatically."; page.Description != want {
+		t.Errorf("description = %q, want %q", page.Description, want)
+	}
+	if page.Content == "" {
+		t.Error("expected Markdown content for llms-full")
+	}
+}
+
+func TestFetchPage_PlainText(t *testing.T) {
+	ts := serveDocument(t, "text/plain", []byte("Release Notes\n\nVersion 2 adds streaming output and faster crawling.\n\nMore details.\n"))
+
+	c := &HTTPCrawler{Client: ts.Client()}
+	page, err := c.FetchPage(context.Background(), ts.URL+"/notes.txt")
+	if err != nil {
+		t.Fatalf("FetchPage() error: %v", err)
+	}
+	if page.Title != "Release Notes" {
+		t.Errorf("title = %q, want %q", page.Title, "Release Notes")
+	}
+	if want := "Version 2 adds streaming output and faster crawling."; page.Description != want {
+		t.Errorf("description = %q, want %q", page.Description, want)
+	}
+}
+
+func TestFetchPage_PDF(t *testing.T) {
+	compressed := func(s string) []byte {
+		var b bytes.Buffer
+		zw := zlib.NewWriter(&b)
+		_, _ = zw.Write([]byte(s))
+		_ = zw.Close()
+		return b.Bytes()
+	}
+
+	tests := []struct {
+		name      string
+		pdf       []byte
+		wantTitle string
+		wantDesc  string
+	}{
+		{
+			name:      "info dictionary",
+			pdf:       []byte("%PDF-1.4\n1 0 obj\n<< /Title (Widget Datasheet \\(2024\\)) /Subject (Specs for the W-100 widget.) /TitleFont /F1 >>\nendobj\ntrailer << /Info 1 0 R >>\n%%EOF"),
+			wantTitle: "Widget Datasheet (2024)",
+			wantDesc:  "Specs for the W-100 widget.",
+		},
+		{
+			name:      "UTF-16 hex string via indirect reference",
+			pdf:       []byte("%PDF-1.4\n5 0 obj\n<FEFF00C9007400750064006500>\nendobj\n1 0 obj\n<< /Title 5 0 R >>\nendobj\n%%EOF"),
+			wantTitle: "Étude",
+		},
+		{
+			name:      "indirect reference past a longer object number",
+			pdf:       []byte("%PDF-1.4\n15 0 obj\n(Wrong Object)\nendobj\n5 1 obj\n(Wrong Generation)\nendobj\n5 0 obj\n(Right Object)\nendobj\n1 0 obj\n<< /Title 5 0 R >>\nendobj\n%%EOF"),
+			wantTitle: "Right Object",
+		},
+		{
+			name: "compressed object stream",
+			pdf: append(append([]byte("%PDF-1.5\n7 0 obj\n<< /Type /ObjStm /Filter /FlateDecode >>\nstream\n"),
+				compressed("<< /Title (Compressed Whitepaper) /Subject (Hidden in an object stream.) >>")...),
+				[]byte("\nendstream\nendobj\n%%EOF")...),
+			wantTitle: "Compressed Whitepaper",
+			wantDesc:  "Hidden in an object stream.",
+		},
+		{
+			name: "XMP metadata",
+			pdf: []byte(`%PDF-1.6
+<x:xmpmeta><rdf:RDF><rdf:Description>
+<dc:title><rdf:Alt><rdf:li xml:lang="x-default">Spec &amp; Guide</rdf:li></rdf:Alt></dc:title>
+<dc:description><rdf:Alt><rdf:li xml:lang="x-default">The complete spec.</rdf:li></rdf:Alt></dc:description>
+</rdf:Description></rdf:RDF></x:xmpmeta>
+%%EOF`),
+			wantTitle: "Spec & Guide",
+			wantDesc:  "The complete spec.",
+		},
+		{
+			name:      "no metadata",
+			pdf:       []byte("%PDF-1.4\n%%EOF"),
+			wantTitle: "product sheet",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := serveDocument(t, "application/pdf", tt.pdf)
+			c := &HTTPCrawler{Client: ts.Client()}
+			page, err := c.FetchPage(context.Background(), ts.URL+"/files/product-sheet.pdf")
+			if err != nil {
+				t.Fatalf("FetchPage() error: %v", err)
+			}
+			if page.Title != tt.wantTitle {
+				t.Errorf("title = %q, want %q", page.Title, tt.wantTitle)
+			}
+			if page.Description != tt.wantDesc {
+				t.Errorf("description = %q, want %q", page.Description, tt.wantDesc)
+			}
+			if page.Content != "" {
+				t.Errorf("content = %q, want none for PDFs", page.Content)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package crawler
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	defaultDescriptionLength = 200
+	minParagraphLength       = 40
+)
+
+// skippedTags hold navigation, chrome and non-visible content that never
+// yields a useful page description.
+var skippedTags = map[string]bool{
+	"nav":      true,
+	"header":   true,
+	"footer":   true,
+	"aside":    true,
+	"script":   true,
+	"style":    true,
+	"noscript": true,
+	"template": true,
+	"form":     true,
+	"button":   true,
+	"svg":      true,
+	"dialog":   true,
+}
+
+// noiseMarkers identify cookie banners, accessibility links and similar
+// boilerplate by a token of their id or class attribute, which either is a
+// marker or starts with one followed by "-" or "_", as in "cookie-banner" or
+// "skip-link". Parts of other words, as in "hero-banner" or "skipper", do not
+// count.
+var noiseMarkers = []string{
+	"cookie", "cookies", "cookieconsent", "consent", "gdpr",
+	"skip-link", "skip-to", "skiplink", "sr-only", "visually-hidden", "screen-reader",
+}
+
+// noisePhrases identify boilerplate paragraphs by their text.
+var noisePhrases = []string{
+	"skip to content", "skip to main content", "skip to navigation",
+	"we use cookies", "uses cookies", "accept cookies", "cookie policy",
+	"enable javascript", "javascript is disabled", "javascript is required",
+}
+
+// paragraphExtractor finds the first meaningful paragraph of a page's main
+// content while an HTML document is being tokenized.
+type paragraphExtractor struct {
+	skipTag     string // tag whose subtree is being skipped, if any
+	skipDepth   int    // nesting depth of skipTag within the skipped subtree
+	inParagraph bool
+	text        strings.Builder
+	found       string
+}
+
+// start processes a start tag with its id and class attribute values.
+func (e *paragraphExtractor) start(tag, id, class string) {
+	if e.found != "" {
+		return
+	}
+	if e.skipTag != "" {
+		if tag == e.skipTag {
+			e.skipDepth++
+		}
+		return
+	}
+	if skippedTags[tag] || isNoise(id) || isNoise(class) {
+		if isVoidElement(tag) {
+			return
+		}
+		e.skipTag = tag
+		e.skipDepth = 1
+		return
+	}
+	if tag == "p" {
+		// An unclosed paragraph is implicitly closed by the next one.
+		e.end("p")
+		if e.found != "" {
+			return
+		}
+		e.inParagraph = true
+		e.text.Reset()
+	}
+}
+
+// end processes an end tag.
+func (e *paragraphExtractor) end(tag string) {
+	if e.found != "" {
+		return
+	}
+	if e.skipTag != "" {
+		if tag == e.skipTag {
+			e.skipDepth--
+			if e.skipDepth == 0 {
+				e.skipTag = ""
+			}
+		}
+		return
+	}
+	if tag == "p" && e.inParagraph {
+		e.inParagraph = false
+		if p := strings.Join(strings.Fields(e.text.String()), " "); isMeaningful(p) {
+			e.found = p
+		}
+	}
+}
+
+// addText processes a text token.
+func (e *paragraphExtractor) addText(text string) {
+	if e.found != "" || e.skipTag != "" || !e.inParagraph {
+		return
+	}
+	e.text.WriteString(text)
+}
+
+// paragraph returns the first meaningful paragraph, truncated to maxLen
+// characters, once the document has been read. A paragraph still open at the
+// end of the document counts.
+func (e *paragraphExtractor) paragraph(maxLen int) string {
+	e.end("p")
+	return truncateAtSentence(e.found, maxLen)
+}
+
+func isNoise(attr string) bool {
+	for _, token := range strings.Fields(strings.ToLower(attr)) {
+		for _, m := range noiseMarkers {
+			rest, ok := strings.CutPrefix(token, m)
+			if ok && (rest == "" || rest[0] == '-' || rest[0] == '_') {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isMeaningful(p string) bool {
+	if utf8.RuneCountInString(p) < minParagraphLength || !strings.Contains(p, " ") {
+		return false
+	}
+	lower := strings.ToLower(p)
+	for _, phrase := range noisePhrases {
+		if strings.Contains(lower, phrase) {
+			return false
+		}
+	}
+	return true
+}
+
+func isVoidElement(tag string) bool {
+	switch tag {
+	case "area", "base", "br", "col", "embed", "hr", "img", "input", "link", "meta", "source", "track", "wbr":
+		return true
+	}
+	return false
+}
+
+// truncateAtSentence shortens s to at most maxLen characters, preferring to
+// cut after the last complete sentence and otherwise at a word boundary,
+// where the "…" it appends counts towards maxLen.
+func truncateAtSentence(s string, maxLen int) string {
+	if maxLen <= 0 || utf8.RuneCountInString(s) <= maxLen {
+		return s
+	}
+	runes := []rune(s)
+	cut := string(runes[:maxLen])
+
+	end := -1
+	for i, r := range cut {
+		if r != '.' && r != '!' && r != '?' {
+			continue
+		}
+		next := i + utf8.RuneLen(r)
+		if next == len(cut) || cut[next] == ' ' {
+			end = next
+		}
+	}
+	if end > len(cut)/3 {
+		return cut[:end]
+	}
+
+	cut = string(runes[:maxLen-1])
+	if i := strings.LastIndexByte(cut, ' '); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,;:-") + "…"
+}
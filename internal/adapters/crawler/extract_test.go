@@ -0,0 +1,120 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestFetchPage_FallbackDescription(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/test", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `<html><head><title>Widgets</title></head><body>
+<a class="skip-link" href="#main">Skip to content</a>
+<div id="cookie-banner"><p>We use cookies to improve your experience on this website. Accept?</p></div>
+<nav><p>Home | Products | Pricing | About us | Contact our sales team today</p></nav>
+<main>
+<p>Short intro.</p>
+<p>Widgets are small <strong>reusable components</strong> that make building dashboards fast and simple.</p>
+<p>A second paragraph that should not be used for the description at all.</p>
+</main>
+</body></html>`)
+	})
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	c := &HTTPCrawler{Client: ts.Client()}
+	page, err := c.FetchPage(context.Background(), ts.URL+"/test")
+	if err != nil {
+		t.Fatalf("FetchPage() error: %v", err)
+	}
+	want := "Widgets are small reusable components that make building dashboards fast and simple."
+	if page.Description != want {
+		t.Errorf("description = %q, want %q", page.Description, want)
+	}
+}
+
+func TestFetchPage_FallbackDescriptionKeepsContent(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"words containing markers", `<div class="hero-banner"><p>Widgets are small reusable components that make dashboards fast.</p></div>`,
+			"Widgets are small reusable components that make dashboards fast."},
+		{"id containing a marker", `<section id="skipper-guide"><p>Skippers plan every voyage with charts, tides and the weather.</p></section>`,
+			"Skippers plan every voyage with charts, tides and the weather."},
+		{"noise among classes", `<div class="modal cookie-notice"><p>Some text about cookies that is long enough to be kept.</p></div><p>The real description of this page is in this paragraph.</p>`,
+			"The real description of this page is in this paragraph."},
+		{"unclosed last paragraph", `<main><p>This paragraph is never closed before the document ends here.`,
+			"This paragraph is never closed before the document ends here."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = fmt.Fprint(w, `<html><head><title>T</title></head><body>`+tt.body)
+			}))
+			defer ts.Close()
+
+			c := &HTTPCrawler{Client: ts.Client()}
+			page, err := c.FetchPage(context.Background(), ts.URL+"/test")
+			if err != nil {
+				t.Fatalf("FetchPage() error: %v", err)
+			}
+			if page.Description != tt.want {
+				t.Errorf("description = %q, want %q", page.Description, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchPage_MetaDescriptionWins(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/test", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `<html><head><title>T</title><meta name="description" content="From meta"></head>
+<body><p>This paragraph is long enough to be a description but meta should win.</p></body></html>`)
+	})
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	c := &HTTPCrawler{Client: ts.Client()}
+	page, err := c.FetchPage(context.Background(), ts.URL+"/test")
+	if err != nil {
+		t.Fatalf("FetchPage() error: %v", err)
+	}
+	if page.Description != "From meta" {
+		t.Errorf("description = %q, want %q", page.Description, "From meta")
+	}
+}
+
+func TestTruncateAtSentence(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     string
+		maxLen int
+		want   string
+	}{
+		{"short", "Fits easily.", 50, "Fits easily."},
+		{"sentence boundary", "First sentence is here. Second sentence runs much longer than allowed.", 40, "First sentence is here."},
+		{"word boundary", "One very long sentence without any early stop at all", 20, "One very long…"},
+		{"abbreviation mid-word", "Version 1.5 brings many improvements to everything", 30, "Version 1.5 brings many…"},
+		{"ellipsis within the limit", "Exactly twenty chars plus more", 20, "Exactly twenty…"},
+		{"no space", "Supercalifragilistic", 10, "Supercali…"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateAtSentence(tt.in, tt.maxLen)
+			if got != tt.want {
+				t.Errorf("truncateAtSentence() = %q, want %q", got, tt.want)
+			}
+			if utf8.RuneCountInString(got) > tt.maxLen {
+				t.Errorf("result %q longer than %d", got, tt.maxLen)
+			}
+		})
+	}
+}
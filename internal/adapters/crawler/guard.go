@@ -0,0 +1,122 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+)
+
+// blockedNetworks are special-purpose ranges that are not blocked by the
+// netip.Addr predicates used in Guard.Check.
+var blockedNetworks = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),         // "this" network
+	netip.MustParsePrefix("100.64.0.0/10"),     // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),      // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"),     // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),       // reserved, including broadcast
+	netip.MustParsePrefix("fd00:ec2::254/128"), // AWS IPv6 metadata endpoint
+}
+
+// Guard blocks connections to private, loopback, link-local and cloud
+// metadata addresses so that user-supplied URLs cannot reach internal
+// services. The check runs on every dial, after DNS resolution, so it also
+// covers redirects, sitemap entries and DNS rebinding.
+type Guard struct {
+	// Allow lists networks that may be contacted even though they would
+	// otherwise be blocked, for deployments that crawl internal sites.
+	Allow []netip.Prefix
+}
+
+// Check returns an error wrapping domain.ErrBlockedAddress if ip may not be contacted.
+func (g *Guard) Check(ip netip.Addr) error {
+	ip = ip.Unmap()
+	for _, p := range g.Allow {
+		if p.Contains(ip) {
+			return nil
+		}
+	}
+	blocked := !ip.IsValid() || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified()
+	for _, p := range blockedNetworks {
+		blocked = blocked || p.Contains(ip)
+	}
+	if blocked {
+		return fmt.Errorf("%w: %s", domain.ErrBlockedAddress, ip)
+	}
+	return nil
+}
+
+// CheckHost resolves host and checks every address it maps to.
+func (g *Guard) CheckHost(ctx context.Context, host string) error {
+	if ip, err := netip.ParseAddr(strings.Trim(host, "[]")); err == nil {
+		return g.Check(ip)
+	}
+	ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return err
+	}
+	for _, ip := range ips {
+		if err := g.Check(ip); err != nil {
+			return fmt.Errorf("%s: %w", host, err)
+		}
+	}
+	return nil
+}
+
+// control is a net.Dialer Control function that vets the resolved address
+// immediately before each connection is made.
+func (g *Guard) control(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrBlockedAddress, address)
+	}
+	return g.Check(addrPort.Addr())
+}
+
+// Transport returns an http.Transport whose connections are vetted by the
+// guard. It ignores proxy environment variables, since a proxy would
+// otherwise make the guard check the proxy's address instead of the target's.
+func (g *Guard) Transport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   g.control,
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = nil
+	t.DialContext = dialer.DialContext
+	return t
+}
+
+// ParseNetworks parses a comma-separated list of CIDR prefixes or single IP
+// addresses, such as "10.0.0.0/8,192.168.1.5".
+func ParseNetworks(list string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, field := range strings.Split(list, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if !strings.Contains(field, "/") {
+			ip, err := netip.ParseAddr(field)
+			if err != nil {
+				return nil, fmt.Errorf("invalid network %q: %w", field, err)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(ip, ip.BitLen()))
+			continue
+		}
+		p, err := netip.ParsePrefix(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network %q: %w", field, err)
+		}
+		prefixes = append(prefixes, p.Masked())
+	}
+	return prefixes, nil
+}
@@ -0,0 +1,109 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+)
+
+func TestGuard_Check(t *testing.T) {
+	tests := []struct {
+		ip      string
+		blocked bool
+	}{
+		{"93.184.216.34", false},
+		{"2606:2800:220:1:248:1893:25c8:1946", false},
+		{"127.0.0.1", true},
+		{"::1", true},
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"192.168.1.1", true},
+		{"169.254.169.254", true},
+		{"100.64.0.1", true},
+		{"0.0.0.0", true},
+		{"fe80::1", true},
+		{"fd00:ec2::254", true},
+		{"::ffff:127.0.0.1", true},
+		{"224.0.0.1", true},
+	}
+	g := &Guard{}
+	for _, tt := range tests {
+		err := g.Check(netip.MustParseAddr(tt.ip))
+		if blocked := errors.Is(err, domain.ErrBlockedAddress); blocked != tt.blocked {
+			t.Errorf("Check(%s) blocked = %v, want %v", tt.ip, blocked, tt.blocked)
+		}
+	}
+}
+
+func TestGuard_AllowList(t *testing.T) {
+	allow, err := ParseNetworks("10.0.0.0/8, 192.168.1.5")
+	if err != nil {
+		t.Fatalf("ParseNetworks() error: %v", err)
+	}
+	g := &Guard{Allow: allow}
+	if err := g.Check(netip.MustParseAddr("10.20.30.40")); err != nil {
+		t.Errorf("allowed network blocked: %v", err)
+	}
+	if err := g.Check(netip.MustParseAddr("192.168.1.5")); err != nil {
+		t.Errorf("allowed address blocked: %v", err)
+	}
+	if err := g.Check(netip.MustParseAddr("192.168.1.6")); err == nil {
+		t.Error("address outside allow list was not blocked")
+	}
+}
+
+func TestParseNetworks_Invalid(t *testing.T) {
+	if _, err := ParseNetworks("10.0.0.0/8,not-a-network"); err == nil {
+		t.Error("expected error for invalid network, got nil")
+	}
+}
+
+func TestGuard_BlocksConnections(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `<html><head><title>Internal</title></head></html>`)
+	}))
+	defer ts.Close()
+
+	g := &Guard{}
+	c := &HTTPCrawler{Client: &http.Client{Transport: g.Transport()}, RetryDelay: time.Millisecond}
+	_, err := c.FetchPage(context.Background(), ts.URL+"/")
+	if !errors.Is(err, domain.ErrBlockedAddress) {
+		t.Errorf("FetchPage() error = %v, want ErrBlockedAddress", err)
+	}
+}
+
+func TestGuard_BlocksRedirects(t *testing.T) {
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `<html><head><title>Internal</title></head></html>`)
+	}))
+	defer internal.Close()
+	// Only 127.0.0.1 is allowed, so the redirect to the same server via
+	// 127.0.0.2 must be refused when the redirect is followed.
+	public := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, strings.Replace(internal.URL, "127.0.0.1", "127.0.0.2", 1)+"/", http.StatusFound)
+	}))
+	defer public.Close()
+
+	g := &Guard{Allow: []netip.Prefix{netip.MustParsePrefix("127.0.0.1/32")}}
+	c := &HTTPCrawler{Client: &http.Client{Transport: g.Transport()}, RetryDelay: time.Millisecond}
+	_, err := c.FetchPage(context.Background(), public.URL+"/")
+	if !errors.Is(err, domain.ErrBlockedAddress) {
+		t.Errorf("FetchPage() error = %v, want ErrBlockedAddress", err)
+	}
+}
+
+func TestDiscover_BlockedHost(t *testing.T) {
+	c := &HTTPCrawler{Guard: &Guard{}}
+	_, err := c.Discover(context.Background(), "http://169.254.169.254/latest/meta-data/")
+	if !errors.Is(err, domain.ErrBlockedAddress) {
+		t.Errorf("Discover() error = %v, want ErrBlockedAddress", err)
+	}
+}
@@ -2,31 +2,92 @@ package crawler
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/net/html"
 
 	"github.com/adsouza/llms.txt-generator/internal/domain"
+	"github.com/adsouza/llms.txt-generator/internal/usecases"
 )
 
 const (
-	maxPages       = 100
-	maxDepth       = 3
-	requestDelay   = 150 * time.Millisecond
-	requestTimeout = 10 * time.Second
-	userAgent      = "llms-txt-generator/1.0"
+	defaultMaxPages       = 100
+	defaultMaxDepth       = 3
+	requestDelay          = 150 * time.Millisecond
+	defaultRequestTimeout = 10 * time.Second
+	userAgent             = "llms-txt-generator/1.0"
 )
 
 // HTTPCrawler implements usecases.Crawler by fetching pages over HTTP.
 type HTTPCrawler struct {
 	Client *http.Client
+
+	// UserAgent identifies the crawler to sites. Its product token, the part
+	// before the first "/", selects the crawler's group in robots.txt.
+	// Defaults to "llms-txt-generator/1.0".
+	UserAgent string
+
+	// ContactURL, if set, is appended to the User-Agent as "(+URL)" so that
+	// site operators can find out about the crawler.
+	ContactURL string
+
+	// MaxPages caps the number of pages discovered on a site. Defaults to 100.
+	MaxPages int
+
+	// MaxDepth is how many links deep link crawling goes when a site has no
+	// sitemap. Defaults to 3.
+	MaxDepth int
+
+	// RequestTimeout bounds each request, including reading its body.
+	// Defaults to 10s.
+	RequestTimeout time.Duration
+
+	// DescriptionLength caps descriptions taken from a page's first paragraph
+	// when it has no meta description. Defaults to 200 characters.
+	DescriptionLength int
+
+	// MaxAttempts is the number of times a request is tried before giving up
+	// on transient failures such as 429, 502, 503 or connection resets.
+	// Defaults to 3.
+	MaxAttempts int
+
+	// RetryDelay is the initial backoff between attempts, doubled for each
+	// retry up to 10s. Defaults to 500ms.
+	RetryDelay time.Duration
+
+	// Limits caps response sizes and redirect chains.
+	Limits Limits
+
+	// Guard, if set, rejects sites whose host resolves to a blocked address
+	// before crawling starts. Client should use Guard.Transport so that every
+	// connection, including redirects, is checked as well.
+	Guard *Guard
+
+	// Renderer, if set, renders pages that look like client-side JavaScript
+	// apps so that their titles, descriptions and links can be extracted.
+	// Pages fall back to the raw HTML when rendering fails, and authenticated
+	// crawls are never rendered since the renderer cannot log in.
+	Renderer Renderer
+
+	// Cache, if set, stores response bodies that have an ETag or
+	// Last-Modified header, so that later crawls revalidate them with
+	// conditional GETs and only download pages that changed. Responses
+	// fetched with credentials are never cached.
+	Cache usecases.Cache
+
+	mu       sync.Mutex
+	limiters map[string]*hostLimiter // per-host request pacing
 }
 
 type robotsResult struct {
@@ -64,6 +125,11 @@ func (c *HTTPCrawler) Discover(ctx context.Context, siteURL string) ([]string, e
 	if parsed.Host == "" {
 		return nil, fmt.Errorf("invalid URL: missing host")
 	}
+	if c.Guard != nil {
+		if err := c.Guard.CheckHost(ctx, parsed.Hostname()); err != nil {
+			return nil, err
+		}
+	}
 
 	baseURL := fmt.Sprintf("%s://%s", parsed.Scheme, parsed.Host)
 	robots := c.fetchRobots(ctx, baseURL)
@@ -76,7 +142,7 @@ func (c *HTTPCrawler) Discover(ctx context.Context, siteURL string) ([]string, e
 	// Filter disallowed URLs and cap.
 	var filtered []string
 	for _, u := range urls {
-		if len(filtered) >= maxPages {
+		if len(filtered) >= c.maxPages() {
 			break
 		}
 		if !c.isDisallowed(u, robots) {
@@ -86,63 +152,117 @@ func (c *HTTPCrawler) Discover(ctx context.Context, siteURL string) ([]string, e
 	return filtered, nil
 }
 
-// FetchPage retrieves a single page and extracts its title and meta description.
+// FetchPage retrieves a single page and extracts its title and meta description,
+// falling back to the first meaningful paragraph when no description is set.
+// It also records the page's declared language and its hreflang alternates.
 func (c *HTTPCrawler) FetchPage(ctx context.Context, pageURL string) (domain.Page, error) {
 	return c.fetchPage(ctx, pageURL)
 }
 
-func (c *HTTPCrawler) Crawl(ctx context.Context, siteURL string) ([]domain.Page, error) {
+// Crawl discovers and fetches every page on a site, returning the pages that
+// were fetched and a record of those that could not be.
+func (c *HTTPCrawler) Crawl(ctx context.Context, siteURL string) ([]domain.Page, []domain.PageError, error) {
 	urls, err := c.Discover(ctx, siteURL)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	var pages []domain.Page
+	var failures []domain.PageError
 	for _, u := range urls {
 		page, err := c.fetchPage(ctx, u)
 		if err != nil {
+			failures = append(failures, *asPageError(u, err))
 			continue
 		}
 		pages = append(pages, page)
-		sleep(ctx, requestDelay)
 	}
-	return pages, nil
+	return pages, failures, nil
 }
 
 func (c *HTTPCrawler) fetchRobots(ctx context.Context, baseURL string) robotsResult {
 	var result robotsResult
-	body, err := c.get(ctx, baseURL+"/robots.txt")
+	body, err := c.get(ctx, baseURL+"/robots.txt", c.Limits.robotsBytes())
 	if err != nil {
 		return result
 	}
 	defer func() { _ = body.Close() }()
 
-	scanner := bufio.NewScanner(body)
-	inWildcard := false
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		lower := strings.ToLower(line)
+	text, err := decodeHTML(body, body.contentType)
+	if err != nil {
+		return result
+	}
 
-		if strings.HasPrefix(lower, "user-agent:") {
-			agent := strings.TrimSpace(line[len("user-agent:"):])
-			inWildcard = agent == "*"
+	// Rules apply to the groups of consecutive User-agent lines before them.
+	// The crawler obeys the groups naming its product token, or else the
+	// "*" groups.
+	token := robotsToken(c.UserAgentHeader())
+	var own, wildcard, agents []string
+	named, inRules := false, false
+	scanner := bufio.NewScanner(text)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
 			continue
 		}
-		if strings.HasPrefix(lower, "disallow:") && inWildcard {
-			path := strings.TrimSpace(line[len("disallow:"):])
-			if path != "" {
-				result.disallowed = append(result.disallowed, path)
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if inRules {
+				agents, inRules = nil, false
 			}
-			continue
-		}
-		if strings.HasPrefix(lower, "sitemap:") {
-			result.sitemapURL = strings.TrimSpace(line[len("sitemap:"):])
-			continue
+			agent := robotsToken(value)
+			named = named || agent == token
+			agents = append(agents, agent)
+		case "disallow":
+			inRules = true
+			if value == "" {
+				continue
+			}
+			if slices.Contains(agents, token) {
+				own = append(own, value)
+			}
+			if slices.Contains(agents, "*") {
+				wildcard = append(wildcard, value)
+			}
+		case "allow":
+			inRules = true
+		case "sitemap":
+			result.sitemapURL = value
 		}
 	}
+
+	result.disallowed = wildcard
+	if named {
+		result.disallowed = own
+	}
 	return result
 }
 
+// robotsToken returns the lowercased product token of a User-Agent string,
+// such as "llms-txt-generator" for "llms-txt-generator/1.0 (+https://...)".
+func robotsToken(ua string) string {
+	token, _, _ := strings.Cut(strings.TrimSpace(ua), " ")
+	token, _, _ = strings.Cut(token, "/")
+	return strings.ToLower(token)
+}
+
+// UserAgentHeader returns the User-Agent the crawler sends: UserAgent, or its
+// default, followed by ContactURL when set.
+func (c *HTTPCrawler) UserAgentHeader() string {
+	ua := c.UserAgent
+	if ua == "" {
+		ua = userAgent
+	}
+	if c.ContactURL != "" {
+		ua += " (+" + c.ContactURL + ")"
+	}
+	return ua
+}
+
 func (c *HTTPCrawler) isDisallowed(rawURL string, robots robotsResult) bool {
 	parsed, err := url.Parse(rawURL)
 	if err != nil {
@@ -167,14 +287,14 @@ func (c *HTTPCrawler) discoverViaSitemap(ctx context.Context, baseURL string, ro
 	}
 
 	urls := c.parseSitemap(ctx, sitemapURL)
-	if len(urls) > maxPages {
-		urls = urls[:maxPages]
+	if len(urls) > c.maxPages() {
+		urls = urls[:c.maxPages()]
 	}
 	return urls
 }
 
 func (c *HTTPCrawler) parseSitemap(ctx context.Context, sitemapURL string) []string {
-	body, err := c.get(ctx, sitemapURL)
+	body, err := c.get(ctx, sitemapURL, c.Limits.sitemapBytes())
 	if err != nil {
 		return nil
 	}
@@ -186,7 +306,7 @@ func (c *HTTPCrawler) parseSitemap(ctx context.Context, sitemapURL string) []str
 	}
 
 	var urlset sitemapURLSet
-	if err := xml.Unmarshal(data, &urlset); err == nil && len(urlset.URLs) > 0 {
+	if err := unmarshalXML(data, body.contentType, &urlset); err == nil && len(urlset.URLs) > 0 {
 		var urls []string
 		for _, u := range urlset.URLs {
 			urls = append(urls, u.Loc)
@@ -195,12 +315,12 @@ func (c *HTTPCrawler) parseSitemap(ctx context.Context, sitemapURL string) []str
 	}
 
 	var index sitemapIndex
-	if err := xml.Unmarshal(data, &index); err == nil && len(index.Sitemaps) > 0 {
+	if err := unmarshalXML(data, body.contentType, &index); err == nil && len(index.Sitemaps) > 0 {
 		var urls []string
 		for _, sm := range index.Sitemaps {
 			urls = append(urls, c.parseSitemap(ctx, sm.Loc)...)
-			if len(urls) >= maxPages {
-				return urls[:maxPages]
+			if len(urls) >= c.maxPages() {
+				return urls[:c.maxPages()]
 			}
 		}
 		return urls
@@ -219,6 +339,7 @@ func (c *HTTPCrawler) discoverViaBFS(ctx context.Context, baseURL, host string,
 	queue := []entry{{url: baseURL + "/", depth: 0}}
 	var discovered []string
 
+	maxPages := c.maxPages()
 	for len(queue) > 0 && len(discovered) < maxPages {
 		current := queue[0]
 		queue = queue[1:]
@@ -229,12 +350,11 @@ func (c *HTTPCrawler) discoverViaBFS(ctx context.Context, baseURL, host string,
 
 		discovered = append(discovered, current.url)
 
-		if current.depth >= maxDepth {
+		if current.depth >= c.maxDepth() {
 			continue
 		}
 
 		links := c.extractLinks(ctx, current.url, host)
-		sleep(ctx, requestDelay)
 
 		for _, link := range links {
 			if visited[link] || len(discovered)+len(queue) >= maxPages {
@@ -248,16 +368,27 @@ func (c *HTTPCrawler) discoverViaBFS(ctx context.Context, baseURL, host string,
 }
 
 func (c *HTTPCrawler) extractLinks(ctx context.Context, pageURL, host string) []string {
-	body, err := c.get(ctx, pageURL)
+	body, err := c.get(ctx, pageURL, c.Limits.pageBytes())
 	if err != nil {
 		return nil
 	}
 	defer func() { _ = body.Close() }()
+	if !isHTML(body.contentType) {
+		return nil
+	}
+	r, contentType, err := c.loadHTML(ctx, pageURL, body, body.contentType, nil)
+	if err != nil {
+		return nil
+	}
+	doc, err := decodeHTML(r, contentType)
+	if err != nil {
+		return nil
+	}
 
 	base, _ := url.Parse(pageURL)
 	var links []string
 
-	tokenizer := html.NewTokenizer(body)
+	tokenizer := html.NewTokenizer(doc)
 	for {
 		tt := tokenizer.Next()
 		if tt == html.ErrorToken {
@@ -292,92 +423,323 @@ func (c *HTTPCrawler) extractLinks(ctx context.Context, pageURL, host string) []
 }
 
 func (c *HTTPCrawler) fetchPage(ctx context.Context, pageURL string) (domain.Page, error) {
-	body, err := c.get(ctx, pageURL)
+	body, err := c.get(ctx, pageURL, c.Limits.documentBytes())
 	if err != nil {
 		return domain.Page{}, err
 	}
 	defer func() { _ = body.Close() }()
+	previous, havePrevious := usecases.PreviousPage(ctx, pageURL)
+	if body.notModified {
+		return previous, nil
+	}
+
+	kind := classify(body.contentType, pageURL)
+	if kind == kindUnsupported {
+		return domain.Page{}, &domain.PageError{URL: pageURL, Status: http.StatusOK, Reason: "unsupported content type " + body.contentType}
+	}
+	maxBytes := c.Limits.pageBytes()
+	if kind == kindPDF {
+		maxBytes = c.Limits.documentBytes()
+	}
+	if body.contentLength > maxBytes {
+		return domain.Page{}, tooLarge(pageURL, maxBytes)
+	}
+	// The body is hashed as it is parsed, so that a page whose content is
+	// unchanged since the previous crawl is not rendered again and keeps what
+	// was extracted from it then.
+	hasher, hash := contentHasher()
+	r := io.TeeReader(&limitedReader{r: body, remaining: maxBytes}, hasher)
+	unchanged := func() bool { return havePrevious && previous.ContentHash == hash() }
+
+	var page domain.Page
+	switch kind {
+	case kindPDF:
+		page, err = c.parsePDF(pageURL, r)
+	case kindText, kindMarkdown:
+		page, err = c.parseText(pageURL, r, body.contentType, kind == kindMarkdown)
+	default:
+		var doc io.Reader
+		var contentType string
+		if doc, contentType, err = c.loadHTML(ctx, pageURL, r, body.contentType, unchanged); err == nil {
+			page, err = c.parseHTML(pageURL, doc, contentType)
+		}
+	}
+	if err == nil {
+		// Hash whatever the parser left unread.
+		_, err = io.Copy(io.Discard, r)
+	}
+	if errors.Is(err, errBodyTooLarge) {
+		return domain.Page{}, tooLarge(pageURL, maxBytes)
+	}
+	if err != nil {
+		return domain.Page{}, err
+	}
+	page.ETag, page.LastModified = body.etag, body.lastModified
+	if unchanged() {
+		previous.ETag, previous.LastModified = body.etag, body.lastModified
+		return previous, nil
+	}
+	page.ContentHash = hash()
+	return page, nil
+}
+
+// parseHTML extracts metadata from an HTML document.
+func (c *HTTPCrawler) parseHTML(pageURL string, r io.Reader, contentType string) (domain.Page, error) {
+	doc, err := decodeHTML(r, contentType)
+	if err != nil {
+		return domain.Page{}, asPageError(pageURL, err)
+	}
 
 	page := domain.Page{URL: pageURL}
+	base, _ := url.Parse(pageURL)
+	var para paragraphExtractor
 
-	tokenizer := html.NewTokenizer(body)
+	tokenizer := html.NewTokenizer(doc)
 	var inTitle bool
 	for {
 		tt := tokenizer.Next()
 		switch tt {
 		case html.ErrorToken:
+			if err := tokenizer.Err(); err != io.EOF {
+				return domain.Page{}, err
+			}
+			if page.Description == "" {
+				page.Description = para.paragraph(c.descriptionLength())
+			}
 			return page, nil
-		case html.StartTagToken:
+		case html.StartTagToken, html.SelfClosingTagToken:
 			tn, hasAttr := tokenizer.TagName()
 			tag := string(tn)
-			if tag == "title" {
+			if tag == "title" && tt == html.StartTagToken {
 				inTitle = true
 			}
-			if tag == "meta" && hasAttr {
-				var name, content string
-				for {
-					key, val, more := tokenizer.TagAttr()
-					switch string(key) {
-					case "name":
-						name = string(val)
-					case "content":
-						content = string(val)
-					}
-					if !more {
-						break
-					}
+			var name, content, id, class, lang, rel, hreflang, href string
+			for hasAttr {
+				key, val, more := tokenizer.TagAttr()
+				switch string(key) {
+				case "name":
+					name = string(val)
+				case "content":
+					content = string(val)
+				case "id":
+					id = string(val)
+				case "class":
+					class = string(val)
+				case "lang":
+					lang = string(val)
+				case "rel":
+					rel = string(val)
+				case "hreflang":
+					hreflang = string(val)
+				case "href":
+					href = string(val)
 				}
-				if strings.EqualFold(name, "description") {
-					page.Description = content
+				hasAttr = more
+			}
+			switch {
+			case tag == "meta" && strings.EqualFold(name, "description"):
+				page.Description = content
+			case tag == "html" && lang != "":
+				page.Lang = strings.TrimSpace(lang)
+			case tag == "link" && hreflang != "" && strings.EqualFold(rel, "alternate"):
+				if resolved := resolveURL(base, href); resolved != "" {
+					if page.Alternates == nil {
+						page.Alternates = make(map[string]string)
+					}
+					page.Alternates[strings.TrimSpace(hreflang)] = resolved
 				}
 			}
+			if tt == html.StartTagToken {
+				para.start(tag, id, class)
+			}
 		case html.TextToken:
 			if inTitle {
 				page.Title = strings.TrimSpace(string(tokenizer.Text()))
 				inTitle = false
+				continue
 			}
+			para.addText(string(tokenizer.Text()))
 		case html.EndTagToken:
 			tn, _ := tokenizer.TagName()
 			if string(tn) == "title" {
 				inTitle = false
 			}
+			para.end(string(tn))
+		}
+	}
+}
+
+func (c *HTTPCrawler) maxPages() int {
+	if c.MaxPages > 0 {
+		return c.MaxPages
+	}
+	return defaultMaxPages
+}
+
+func (c *HTTPCrawler) maxDepth() int {
+	if c.MaxDepth > 0 {
+		return c.MaxDepth
+	}
+	return defaultMaxDepth
+}
+
+func (c *HTTPCrawler) requestTimeout() time.Duration {
+	if c.RequestTimeout > 0 {
+		return c.RequestTimeout
+	}
+	return defaultRequestTimeout
+}
+
+func (c *HTTPCrawler) descriptionLength() int {
+	if c.DescriptionLength > 0 {
+		return c.DescriptionLength
+	}
+	return defaultDescriptionLength
+}
+
+// response is the body of a successful request. Reads fail with
+// errBodyTooLarge past the size limit, and closing it releases the request.
+type response struct {
+	io.ReadCloser
+	contentType   string
+	contentLength int64 // -1 if unknown
+	etag          string
+	lastModified  string
+
+	// notModified is set when the page is unchanged since the previous
+	// crawl, which has its content. The body is then empty.
+	notModified bool
+}
+
+// get fetches rawURL, retrying transient failures with capped exponential
+// backoff. Each retry is reported as a "retry" progress event. Responses
+// larger than maxBytes are rejected.
+func (c *HTTPCrawler) get(ctx context.Context, rawURL string, maxBytes int64) (*response, error) {
+	for attempt := 1; ; attempt++ {
+		body, retryAfter, err := c.getOnce(ctx, rawURL, maxBytes)
+		if err == nil {
+			return body, nil
+		}
+		if attempt >= c.maxAttempts() || ctx.Err() != nil || !isRetryable(err) || retryAfter > maxRetryAfter {
+			return nil, err
 		}
+
+		pe := asPageError(rawURL, err)
+		usecases.ReportProgress(ctx, domain.ProgressEvent{
+			Type:       "retry",
+			CurrentURL: rawURL,
+			Attempt:    attempt,
+			Status:     pe.Status,
+			Error:      pe.Reason,
+		})
+		sleep(ctx, max(c.backoff(attempt), retryAfter))
 	}
 }
 
-func (c *HTTPCrawler) get(ctx context.Context, rawURL string) (io.ReadCloser, error) {
-	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+// getOnce makes a single rate-limited request. For throttled responses it also
+// returns the delay requested by the server's Retry-After header.
+func (c *HTTPCrawler) getOnce(ctx context.Context, rawURL string, maxBytes int64) (*response, time.Duration, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
 	if err != nil {
-		cancel()
-		return nil, err
+		return nil, 0, err
+	}
+	req.Header.Set("User-Agent", c.UserAgentHeader())
+	// Requests are conditional on a cached body, or else on the page as it
+	// was in the previous crawl, if there is one.
+	cache := c.pageCache(ctx)
+	cached, haveCached := loadPage(cache, rawURL)
+	previous, havePrevious := usecases.PreviousPage(ctx, rawURL)
+	if haveCached {
+		haveCached = setValidators(req, cached.ETag, cached.LastModified)
+	} else if havePrevious {
+		havePrevious = setValidators(req, previous.ETag, previous.LastModified)
 	}
-	req.Header.Set("User-Agent", userAgent)
 
-	client := c.Client
-	if client == nil {
-		client = http.DefaultClient
+	limiter := c.limiter(req.URL.Host)
+	limiter.wait(ctx)
+	// The timeout starts once the limiter lets the request go, and the host
+	// gets a pause after the response, however long it took.
+	ctx, cancel := context.WithTimeout(ctx, c.requestTimeout())
+	req = req.WithContext(ctx)
+	finish := func() {
+		cancel()
+		limiter.done()
 	}
-	resp, err := client.Do(req)
+
+	resp, err := c.client(ctx).Do(req)
 	if err != nil {
-		cancel()
-		return nil, err
+		finish()
+		return nil, 0, err
+	}
+	if resp.StatusCode == http.StatusNotModified && (haveCached || havePrevious) {
+		_ = resp.Body.Close()
+		finish()
+		limiter.relax()
+		if !haveCached {
+			return &response{ReadCloser: http.NoBody, notModified: true, etag: previous.ETag, lastModified: previous.LastModified}, 0, nil
+		}
+		if int64(len(cached.Body)) > maxBytes {
+			return nil, 0, tooLarge(rawURL, maxBytes)
+		}
+		return &response{
+			ReadCloser:    io.NopCloser(bytes.NewReader(cached.Body)),
+			contentType:   cached.ContentType,
+			contentLength: int64(len(cached.Body)),
+			etag:          cached.ETag,
+			lastModified:  cached.LastModified,
+		}, 0, nil
 	}
 	if resp.StatusCode != http.StatusOK {
+		var retryAfter time.Duration
+		if isThrottled(resp.StatusCode) {
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+			limiter.throttle(retryAfter)
+		}
 		_ = resp.Body.Close()
-		cancel()
-		return nil, fmt.Errorf("HTTP %d for %s", resp.StatusCode, rawURL)
+		finish()
+		return nil, retryAfter, &domain.PageError{URL: rawURL, Status: resp.StatusCode, Reason: http.StatusText(resp.StatusCode)}
+	}
+	limiter.relax()
+	if resp.ContentLength > maxBytes {
+		_ = resp.Body.Close()
+		finish()
+		return nil, 0, tooLarge(rawURL, maxBytes)
+	}
+	return &response{
+		ReadCloser: &cancelOnCloseReader{
+			Reader: recordResponse(cache, rawURL, resp, &limitedReader{r: resp.Body, remaining: maxBytes}),
+			body:   resp.Body,
+			cancel: finish,
+		},
+		contentType:   resp.Header.Get("Content-Type"),
+		contentLength: resp.ContentLength,
+		etag:          resp.Header.Get("ETag"),
+		lastModified:  resp.Header.Get("Last-Modified"),
+	}, 0, nil
+}
+
+// asPageError converts a request error into a PageError, reporting transport
+// failures by their underlying cause rather than the wrapping *url.Error.
+func asPageError(rawURL string, err error) *domain.PageError {
+	var pe *domain.PageError
+	if errors.As(err, &pe) {
+		return pe
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
 	}
-	return &cancelOnCloseReader{ReadCloser: resp.Body, cancel: cancel}, nil
+	return &domain.PageError{URL: rawURL, Reason: err.Error()}
 }
 
 type cancelOnCloseReader struct {
-	io.ReadCloser
-	cancel context.CancelFunc
+	io.Reader
+	body   io.Closer
+	cancel func()
 }
 
 func (r *cancelOnCloseReader) Close() error {
-	err := r.ReadCloser.Close()
+	err := r.body.Close()
 	r.cancel()
 	return err
 }
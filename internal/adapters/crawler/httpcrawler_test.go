@@ -55,7 +55,7 @@ func TestCrawl_WithSitemap(t *testing.T) {
 	defer ts.Close()
 
 	c := &HTTPCrawler{Client: ts.Client()}
-	pages, err := c.Crawl(context.Background(), ts.URL)
+	pages, _, err := c.Crawl(context.Background(), ts.URL)
 	if err != nil {
 		t.Fatalf("Crawl() error: %v", err)
 	}
@@ -96,7 +96,7 @@ func TestCrawl_BFSFallback(t *testing.T) {
 	defer ts.Close()
 
 	c := &HTTPCrawler{Client: ts.Client()}
-	pages, err := c.Crawl(context.Background(), ts.URL)
+	pages, _, err := c.Crawl(context.Background(), ts.URL)
 	if err != nil {
 		t.Fatalf("Crawl() error: %v", err)
 	}
@@ -142,7 +142,7 @@ func TestCrawl_RobotsDisallow(t *testing.T) {
 	defer ts.Close()
 
 	c := &HTTPCrawler{Client: ts.Client()}
-	pages, err := c.Crawl(context.Background(), ts.URL)
+	pages, _, err := c.Crawl(context.Background(), ts.URL)
 	if err != nil {
 		t.Fatalf("Crawl() error: %v", err)
 	}
@@ -157,9 +157,59 @@ func TestCrawl_RobotsDisallow(t *testing.T) {
 	}
 }
 
+func TestDiscover_RobotsGroupForUserAgent(t *testing.T) {
+	robots := `# Crawlers in general
+User-agent: *
+Disallow: /
+
+User-agent: Googlebot
+User-agent: DocsBot/2.0
+Disallow: /internal # staff only
+Allow: /internal/public
+`
+	var gotUA string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		gotUA = r.UserAgent()
+		_, _ = fmt.Fprint(w, robots)
+	})
+	mux.HandleFunc("/sitemap.xml", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		_, _ = fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>BASEURL/</loc></url>
+  <url><loc>BASEURL/internal/wiki</loc></url>
+</urlset>`)
+	})
+	ts := newTestSite(mux)
+	defer ts.Close()
+
+	tests := []struct {
+		userAgent string
+		want      []string
+	}{
+		{"", nil}, // the default token only matches "*", which disallows everything
+		{"docsbot/3.1", []string{ts.URL + "/"}},
+		{"DocsBot", []string{ts.URL + "/"}},
+	}
+	for _, tt := range tests {
+		c := &HTTPCrawler{Client: ts.Client(), UserAgent: tt.userAgent, ContactURL: "https://example.com/bot"}
+		urls, err := c.Discover(context.Background(), ts.URL)
+		if err != nil {
+			t.Fatalf("Discover() error: %v", err)
+		}
+		if fmt.Sprint(urls) != fmt.Sprint(tt.want) {
+			t.Errorf("UserAgent %q: Discover() = %v, want %v", tt.userAgent, urls, tt.want)
+		}
+		if !strings.HasSuffix(gotUA, " (+https://example.com/bot)") {
+			t.Errorf("User-Agent = %q, want contact URL", gotUA)
+		}
+	}
+}
+
 func TestCrawl_InvalidURL(t *testing.T) {
 	c := &HTTPCrawler{}
-	_, err := c.Crawl(context.Background(), "ftp://example.com")
+	_, _, err := c.Crawl(context.Background(), "ftp://example.com")
 	if err == nil {
 		t.Fatal("expected error for ftp scheme, got nil")
 	}
@@ -219,3 +269,71 @@ func TestFetchPage_ExtractsMetadata(t *testing.T) {
 		t.Errorf("description = %q, want %q", page.Description, "A test page")
 	}
 }
+
+func TestCrawl_ReportsFailures(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("/sitemap.xml", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		_, _ = fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>BASEURL/</loc></url>
+  <url><loc>BASEURL/gone</loc></url>
+</urlset>`)
+	})
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `<html><head><title>Home</title></head></html>`)
+	})
+
+	ts := newTestSite(mux)
+	defer ts.Close()
+
+	c := &HTTPCrawler{Client: ts.Client()}
+	pages, failures, err := c.Crawl(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatalf("Crawl() error: %v", err)
+	}
+	if len(pages) != 1 {
+		t.Errorf("got %d pages, want 1", len(pages))
+	}
+	if len(failures) != 1 {
+		t.Fatalf("got %d failures, want 1", len(failures))
+	}
+	if failures[0].URL != ts.URL+"/gone" || failures[0].Status != http.StatusNotFound {
+		t.Errorf("failure = %+v, want 404 for /gone", failures[0])
+	}
+}
+
+func TestFetchPage_ExtractsLanguage(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/fr/docs", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `<html lang="fr-FR"><head><title>Docs</title>
+<link rel="alternate" hreflang="en" href="/docs">
+<link rel="alternate" hreflang="fr-FR" href="https://example.com/fr/docs">
+<link rel="stylesheet" href="/style.css">
+</head></html>`)
+	})
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	c := &HTTPCrawler{Client: ts.Client()}
+	page, err := c.FetchPage(context.Background(), ts.URL+"/fr/docs")
+	if err != nil {
+		t.Fatalf("FetchPage() error: %v", err)
+	}
+	if page.Lang != "fr-FR" {
+		t.Errorf("lang = %q, want %q", page.Lang, "fr-FR")
+	}
+	if len(page.Alternates) != 2 {
+		t.Fatalf("alternates = %v, want 2", page.Alternates)
+	}
+	if page.Alternates["en"] != ts.URL+"/docs" {
+		t.Errorf("en alternate = %q, want %q", page.Alternates["en"], ts.URL+"/docs")
+	}
+}
@@ -0,0 +1,138 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+	"github.com/adsouza/llms.txt-generator/internal/usecases"
+)
+
+const (
+	defaultMaxPageBytes    = 5 << 20
+	defaultMaxDocBytes     = 20 << 20
+	defaultMaxSitemapBytes = 50 << 20 // the sitemaps.org limit for an uncompressed sitemap
+	defaultMaxRobotsBytes  = 500 << 10
+	defaultMaxRedirects    = 5
+)
+
+var (
+	errBodyTooLarge     = errors.New("response body too large")
+	errTooManyRedirects = errors.New("too many redirects")
+)
+
+// Limits caps what the crawler downloads. Zero fields use the defaults.
+type Limits struct {
+	PageBytes    int64 // per HTML, text or Markdown page; defaults to 5 MiB
+	DocBytes     int64 // per PDF document; defaults to 20 MiB
+	SitemapBytes int64 // per sitemap file; defaults to 50 MiB
+	RobotsBytes  int64 // for robots.txt; defaults to 500 KiB
+	Redirects    int   // redirect hops followed per request; defaults to 5
+}
+
+func (l Limits) pageBytes() int64 {
+	if l.PageBytes > 0 {
+		return l.PageBytes
+	}
+	return defaultMaxPageBytes
+}
+
+func (l Limits) documentBytes() int64 {
+	if l.DocBytes > 0 {
+		return max(l.DocBytes, l.pageBytes())
+	}
+	return max(defaultMaxDocBytes, l.pageBytes())
+}
+
+func (l Limits) sitemapBytes() int64 {
+	if l.SitemapBytes > 0 {
+		return l.SitemapBytes
+	}
+	return defaultMaxSitemapBytes
+}
+
+func (l Limits) robotsBytes() int64 {
+	if l.RobotsBytes > 0 {
+		return l.RobotsBytes
+	}
+	return defaultMaxRobotsBytes
+}
+
+func (l Limits) redirects() int {
+	if l.Redirects > 0 {
+		return l.Redirects
+	}
+	return defaultMaxRedirects
+}
+
+// client returns a copy of the configured client that stops following
+// redirects after Limits.Redirects hops and sends any credentials carried by
+// ctx to the site being crawled.
+func (c *HTTPCrawler) client(ctx context.Context) *http.Client {
+	base := c.Client
+	if base == nil {
+		base = http.DefaultClient
+	}
+	client := *base
+	maxRedirects := c.Limits.redirects()
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) > maxRedirects {
+			return fmt.Errorf("%w: stopped after %d", errTooManyRedirects, maxRedirects)
+		}
+		if base.CheckRedirect != nil {
+			return base.CheckRedirect(req, via)
+		}
+		return nil
+	}
+	if creds, siteURL, ok := usecases.SiteCredentials(ctx); ok {
+		authenticate(&client, siteURL, creds)
+	}
+	return &client
+}
+
+func tooLarge(rawURL string, maxBytes int64) *domain.PageError {
+	return &domain.PageError{
+		URL:    rawURL,
+		Status: http.StatusOK,
+		Reason: fmt.Sprintf("response exceeds the %d byte limit", maxBytes),
+	}
+}
+
+// limitedReader reads at most n bytes from r and fails with errBodyTooLarge
+// if r holds more, unlike io.LimitReader which silently truncates.
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		var probe [1]byte
+		if _, err := io.ReadFull(l.r, probe[:]); err == nil {
+			return 0, errBodyTooLarge
+		} else if err != io.EOF && err != io.ErrUnexpectedEOF {
+			return 0, err
+		}
+		return 0, io.EOF
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
+
+// isHTML reports whether a Content-Type header denotes an HTML document.
+// A missing header is treated as HTML, which is what such servers usually send.
+func isHTML(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "text/html" || mediaType == "application/xhtml+xml")
+}
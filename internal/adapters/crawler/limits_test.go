@@ -0,0 +1,103 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFetchPage_SkipsNonHTML(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		_, _ = fmt.Fprint(w, "binary data")
+	}))
+	defer ts.Close()
+
+	c := &HTTPCrawler{Client: ts.Client()}
+	_, err := c.FetchPage(context.Background(), ts.URL+"/download.bin")
+	if err == nil || !strings.Contains(err.Error(), "unsupported content type") {
+		t.Errorf("FetchPage() error = %v, want unsupported content type", err)
+	}
+}
+
+func TestFetchPage_RejectsOversizedResponse(t *testing.T) {
+	page := "<html><head><title>Big</title></head>" + strings.Repeat("x", 4096) + "</html>"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		if r.URL.Path == "/declared" {
+			w.Header().Set("Content-Length", fmt.Sprint(len(page)))
+		}
+		_, _ = fmt.Fprint(w, page)
+	}))
+	defer ts.Close()
+
+	c := &HTTPCrawler{Client: ts.Client(), Limits: Limits{PageBytes: 1024}}
+	for _, path := range []string{"/declared", "/chunked"} {
+		_, err := c.FetchPage(context.Background(), ts.URL+path)
+		if err == nil || !strings.Contains(err.Error(), "byte limit") {
+			t.Errorf("FetchPage(%s) error = %v, want size limit error", path, err)
+		}
+	}
+}
+
+func TestDiscover_OversizedSitemapFallsBackToBFS(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("/sitemap.xml", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		var b strings.Builder
+		b.WriteString(`<?xml version="1.0" encoding="UTF-8"?><urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+		for i := range 100 {
+			fmt.Fprintf(&b, "<url><loc>BASEURL/page%d</loc></url>", i)
+		}
+		b.WriteString("</urlset>")
+		_, _ = fmt.Fprint(w, b.String())
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `<html><head><title>Home</title></head></html>`)
+	})
+
+	ts := newTestSite(mux)
+	defer ts.Close()
+
+	c := &HTTPCrawler{Client: ts.Client(), Limits: Limits{SitemapBytes: 512}}
+	urls, err := c.Discover(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatalf("Discover() error: %v", err)
+	}
+	if len(urls) != 1 || urls[0] != ts.URL+"/" {
+		t.Errorf("urls = %v, want only the BFS root", urls)
+	}
+}
+
+func TestFetchPage_RedirectLimit(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, r.URL.Path+"x", http.StatusFound)
+	}))
+	defer ts.Close()
+
+	c := &HTTPCrawler{Client: ts.Client(), Limits: Limits{Redirects: 2}}
+	_, err := c.FetchPage(context.Background(), ts.URL+"/loop")
+	if !errors.Is(err, errTooManyRedirects) {
+		t.Errorf("FetchPage() error = %v, want errTooManyRedirects", err)
+	}
+}
+
+func TestLimitedReader(t *testing.T) {
+	r := &limitedReader{r: strings.NewReader("12345"), remaining: 5}
+	if data, err := io.ReadAll(r); err != nil || string(data) != "12345" {
+		t.Errorf("exact size: got %q, %v", data, err)
+	}
+
+	r = &limitedReader{r: strings.NewReader("123456"), remaining: 5}
+	if _, err := io.ReadAll(r); !errors.Is(err, errBodyTooLarge) {
+		t.Errorf("oversized: err = %v, want errBodyTooLarge", err)
+	}
+}
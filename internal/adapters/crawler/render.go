@@ -0,0 +1,192 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+
+	"github.com/adsouza/llms.txt-generator/internal/usecases"
+)
+
+const (
+	// minVisibleText is the amount of body text, in characters, below which
+	// a page that loads scripts is assumed to be rendered client-side.
+	minVisibleText = 100
+
+	renderTimeout       = 30 * time.Second
+	renderedContentType = "text/html; charset=utf-8"
+)
+
+// Renderer returns the HTML of a page after its scripts have run, for sites
+// that build their content client-side.
+type Renderer interface {
+	Render(ctx context.Context, pageURL string) (io.ReadCloser, error)
+}
+
+// PrerenderRenderer renders pages through a Rendertron- or Prerender-compatible
+// service, which takes the page URL appended to its endpoint and responds with
+// the rendered HTML.
+type PrerenderRenderer struct {
+	// Endpoint is the prefix the page URL is appended to, for example
+	// "http://localhost:3000/" for Prerender or "https://rendertron.example/render/"
+	// for Rendertron.
+	Endpoint string
+
+	// Token, if set, is sent as the X-Prerender-Token header.
+	Token string
+
+	// UserAgent is sent to the service, which should pass it on to the page,
+	// so that sites see the same crawler as for plain fetches. It should be
+	// the crawler's HTTPCrawler.UserAgentHeader. Defaults to
+	// "llms-txt-generator/1.0".
+	UserAgent string
+
+	// Client makes requests to the service. The service is configured by the
+	// operator and usually runs on an internal network, so it should not go
+	// through the crawler's Guard. Defaults to http.DefaultClient.
+	Client *http.Client
+
+	// Guard vets the page's host before it is sent to the service, which
+	// resolves it again and runs the page's scripts, so the service must also
+	// be set up to block private networks. Defaults to a Guard that allows
+	// nothing extra.
+	Guard *Guard
+}
+
+// Render implements Renderer. It refuses pages whose host does not resolve
+// to public addresses only.
+func (r *PrerenderRenderer) Render(ctx context.Context, pageURL string) (io.ReadCloser, error) {
+	u, err := url.Parse(pageURL)
+	if err != nil || u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid page URL %q", pageURL)
+	}
+	guard := r.Guard
+	if guard == nil {
+		guard = &Guard{}
+	}
+	if err := guard.CheckHost(ctx, u.Hostname()); err != nil {
+		return nil, fmt.Errorf("not prerendering %s: %w", u.Hostname(), err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, renderTimeout)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.Endpoint+pageURL, nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	ua := r.UserAgent
+	if ua == "" {
+		ua = userAgent
+	}
+	req.Header.Set("User-Agent", ua)
+	if r.Token != "" {
+		req.Header.Set("X-Prerender-Token", r.Token)
+	}
+
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		cancel()
+		return nil, fmt.Errorf("prerender service returned %s", resp.Status)
+	}
+	return &cancelOnCloseReader{Reader: resp.Body, body: resp.Body, cancel: cancel}, nil
+}
+
+// loadHTML reads an HTML response. When a Renderer is configured and the page
+// looks like an unrendered JavaScript app, the rendered HTML is returned
+// instead; if rendering fails the raw HTML is used. Pages fetched with
+// credentials are not rendered, so the credentials stay with the site, nor
+// are those that unchanged, if set, reports as unchanged once read.
+func (c *HTTPCrawler) loadHTML(ctx context.Context, pageURL string, r io.Reader, contentType string, unchanged func() bool) (io.Reader, string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, _, authenticated := usecases.SiteCredentials(ctx); authenticated || c.Renderer == nil || !needsRendering(data, contentType) ||
+		unchanged != nil && unchanged() {
+		return bytes.NewReader(data), contentType, nil
+	}
+	if rendered, err := c.render(ctx, pageURL); err == nil {
+		return bytes.NewReader(rendered), renderedContentType, nil
+	}
+	return bytes.NewReader(data), contentType, nil
+}
+
+// render fetches a page through the Renderer, paced like any other request to
+// the page's host since the renderer will load it and its assets.
+func (c *HTTPCrawler) render(ctx context.Context, pageURL string) ([]byte, error) {
+	if host := hostOf(pageURL); host != "" {
+		c.limiter(host).wait(ctx)
+	}
+	body, err := c.Renderer.Render(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = body.Close() }()
+	return io.ReadAll(&limitedReader{r: body, remaining: c.Limits.pageBytes()})
+}
+
+// needsRendering reports whether an HTML document loads scripts but has
+// almost no visible text, like the empty <div id="app"> shell of a
+// single-page app.
+func needsRendering(data []byte, contentType string) bool {
+	doc, err := decodeHTML(bytes.NewReader(data), contentType)
+	if err != nil {
+		return false
+	}
+	var text strings.Builder
+	hasScript := false
+	skip := "" // tag whose non-visible contents are being skipped
+	tokenizer := html.NewTokenizer(doc)
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			visible := strings.Join(strings.Fields(text.String()), " ")
+			return hasScript && utf8.RuneCountInString(visible) < minVisibleText
+		case html.StartTagToken:
+			tn, _ := tokenizer.TagName()
+			switch tag := string(tn); tag {
+			case "script":
+				hasScript = true
+				fallthrough
+			case "style", "noscript", "template", "title":
+				if skip == "" {
+					skip = tag
+				}
+			}
+		case html.EndTagToken:
+			if tn, _ := tokenizer.TagName(); string(tn) == skip {
+				skip = ""
+			}
+		case html.TextToken:
+			if skip == "" {
+				text.Write(tokenizer.Text())
+				text.WriteByte(' ')
+			}
+		}
+	}
+}
+
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
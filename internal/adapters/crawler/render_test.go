@@ -0,0 +1,213 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+)
+
+const appShell = `<!DOCTYPE html><html><head><title>React App</title>
+<script type="module" src="/assets/index.js"></script></head>
+<body><noscript>You need to enable JavaScript to run this app.</noscript><div id="app"></div></body></html>`
+
+func TestNeedsRendering(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want bool
+	}{
+		{"app shell", appShell, true},
+		{"server rendered", `<html><head><script src="/a.js"></script></head><body><p>` + strings.Repeat("Plenty of server-rendered text. ", 10) + `</p></body></html>`, false},
+		{"short page without scripts", `<html><head><title>Hi</title></head><body><p>Hello.</p></body></html>`, false},
+		{"inline script text is not content", `<html><body><div id="root"></div><script>` + strings.Repeat("window.x = 1;", 50) + `</script></body></html>`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := needsRendering([]byte(tt.html), "text/html"); got != tt.want {
+				t.Errorf("needsRendering() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// newPrerenderServer stands in for a Prerender service, rendering each
+// requested URL with the given function.
+func newPrerenderServer(t *testing.T, render func(w http.ResponseWriter, pageURL string)) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.Header.Get("X-Prerender-Token") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		render(w, strings.TrimPrefix(r.URL.RequestURI(), "/"))
+	}))
+	t.Cleanup(ts.Close)
+	return ts, &calls
+}
+
+// newTestRenderer renders through prerender, allowing the loopback test
+// servers past its Guard.
+func newTestRenderer(prerender *httptest.Server) *PrerenderRenderer {
+	return &PrerenderRenderer{
+		Endpoint: prerender.URL + "/",
+		Token:    "secret",
+		Client:   prerender.Client(),
+		Guard:    &Guard{Allow: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")}},
+	}
+}
+
+func newAppServer(t *testing.T, body string) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" || r.URL.Path == "/sitemap.xml" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = fmt.Fprint(w, body)
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestFetchPage_RendersAppShell(t *testing.T) {
+	site := newAppServer(t, appShell)
+	prerender, _ := newPrerenderServer(t, func(w http.ResponseWriter, pageURL string) {
+		_, _ = fmt.Fprintf(w, `<html><head><title>Widgets</title><meta name="description" content="Rendered %s"></head><body></body></html>`, pageURL)
+	})
+
+	c := &HTTPCrawler{
+		Client:   site.Client(),
+		Renderer: newTestRenderer(prerender),
+	}
+	page, err := c.FetchPage(context.Background(), site.URL+"/widgets")
+	if err != nil {
+		t.Fatalf("FetchPage() error: %v", err)
+	}
+	if page.Title != "Widgets" {
+		t.Errorf("title = %q, want %q", page.Title, "Widgets")
+	}
+	if want := "Rendered " + site.URL + "/widgets"; page.Description != want {
+		t.Errorf("description = %q, want %q", page.Description, want)
+	}
+}
+
+func TestFetchPage_FallsBackWhenRenderingFails(t *testing.T) {
+	site := newAppServer(t, appShell)
+	prerender, calls := newPrerenderServer(t, func(w http.ResponseWriter, pageURL string) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	c := &HTTPCrawler{
+		Client:   site.Client(),
+		Renderer: newTestRenderer(prerender),
+	}
+	page, err := c.FetchPage(context.Background(), site.URL+"/")
+	if err != nil {
+		t.Fatalf("FetchPage() error: %v", err)
+	}
+	if page.Title != "React App" {
+		t.Errorf("title = %q, want raw title %q", page.Title, "React App")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("prerender calls = %d, want 1", calls.Load())
+	}
+}
+
+func TestFetchPage_SkipsRenderingForServerRenderedPages(t *testing.T) {
+	site := newAppServer(t, `<html><head><title>Docs</title><script src="/a.js"></script></head><body><main><p>`+
+		strings.Repeat("This page is rendered on the server. ", 5)+`</p></main></body></html>`)
+	prerender, calls := newPrerenderServer(t, func(w http.ResponseWriter, pageURL string) {
+		_, _ = fmt.Fprint(w, `<html><head><title>Wrong</title></head></html>`)
+	})
+
+	c := &HTTPCrawler{
+		Client:   site.Client(),
+		Renderer: newTestRenderer(prerender),
+	}
+	page, err := c.FetchPage(context.Background(), site.URL+"/docs")
+	if err != nil {
+		t.Fatalf("FetchPage() error: %v", err)
+	}
+	if page.Title != "Docs" {
+		t.Errorf("title = %q, want %q", page.Title, "Docs")
+	}
+	if calls.Load() != 0 {
+		t.Errorf("prerender calls = %d, want 0", calls.Load())
+	}
+}
+
+func TestDiscover_FollowsRenderedLinks(t *testing.T) {
+	site := newAppServer(t, appShell)
+	prerender, _ := newPrerenderServer(t, func(w http.ResponseWriter, pageURL string) {
+		if strings.HasSuffix(pageURL, "/") {
+			_, _ = fmt.Fprint(w, `<html><body><a href="/pricing">Pricing</a><a href="/docs">Docs</a></body></html>`)
+			return
+		}
+		_, _ = fmt.Fprint(w, `<html><body><p>Leaf</p></body></html>`)
+	})
+
+	c := &HTTPCrawler{
+		Client:   site.Client(),
+		Renderer: newTestRenderer(prerender),
+	}
+	urls, err := c.Discover(context.Background(), site.URL)
+	if err != nil {
+		t.Fatalf("Discover() error: %v", err)
+	}
+	want := []string{site.URL + "/", site.URL + "/pricing", site.URL + "/docs"}
+	if strings.Join(urls, " ") != strings.Join(want, " ") {
+		t.Errorf("Discover() = %v, want %v", urls, want)
+	}
+}
+
+func TestPrerenderRenderer_SendsUserAgent(t *testing.T) {
+	c := &HTTPCrawler{UserAgent: "examplebot/2.0", ContactURL: "https://example.com/bot"}
+	var got string
+	prerender := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("User-Agent")
+		_, _ = fmt.Fprint(w, `<html></html>`)
+	}))
+	defer prerender.Close()
+	r := newTestRenderer(prerender)
+	r.UserAgent = c.UserAgentHeader()
+
+	body, err := r.Render(context.Background(), "http://127.0.0.1/app")
+	if err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+	_ = body.Close()
+	if want := "examplebot/2.0 (+https://example.com/bot)"; got != want {
+		t.Errorf("User-Agent = %q, want %q", got, want)
+	}
+}
+
+func TestPrerenderRenderer_RefusesInternalHosts(t *testing.T) {
+	prerender, calls := newPrerenderServer(t, func(w http.ResponseWriter, pageURL string) {
+		_, _ = fmt.Fprint(w, `<html></html>`)
+	})
+	r := &PrerenderRenderer{Endpoint: prerender.URL + "/", Token: "secret", Client: prerender.Client()}
+
+	for _, pageURL := range []string{"http://127.0.0.1:8080/", "http://localhost/admin", "http://10.0.0.5/", "http://[::1]/", "http://169.254.169.254/latest/meta-data/"} {
+		body, err := r.Render(context.Background(), pageURL)
+		if err == nil {
+			_ = body.Close()
+		}
+		if !errors.Is(err, domain.ErrBlockedAddress) {
+			t.Errorf("Render(%s) error = %v, want ErrBlockedAddress", pageURL, err)
+		}
+	}
+	if calls.Load() != 0 {
+		t.Errorf("prerender calls = %d, want 0", calls.Load())
+	}
+}
@@ -0,0 +1,149 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+)
+
+const (
+	defaultMaxAttempts = 3
+	defaultRetryDelay  = 500 * time.Millisecond
+	maxRetryDelay      = 10 * time.Second
+	maxRetryAfter      = 30 * time.Second
+	maxHostDelay       = 5 * time.Second
+)
+
+// hostLimiter spaces out requests to a single host, leaving at least its delay
+// between the end of one request and the start of the next. The delay grows
+// when the host signals throttling and decays back towards requestDelay
+// afterwards.
+type hostLimiter struct {
+	mu    sync.Mutex
+	next  time.Time
+	delay time.Duration
+}
+
+// wait blocks until the limiter allows another request or ctx is done.
+func (l *hostLimiter) wait(ctx context.Context) {
+	l.mu.Lock()
+	now := time.Now()
+	start := now
+	if l.next.After(now) {
+		start = l.next
+	}
+	l.next = start.Add(l.delay)
+	l.mu.Unlock()
+
+	if d := start.Sub(now); d > 0 {
+		sleep(ctx, d)
+	}
+}
+
+// done holds off the next request until the delay has passed since a request
+// finished, so that slow responses do not leave the host without a pause.
+func (l *hostLimiter) done() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := time.Now().Add(l.delay); until.After(l.next) {
+		l.next = until
+	}
+}
+
+// throttle doubles the delay between requests and holds off further requests
+// for at least retryAfter.
+func (l *hostLimiter) throttle(retryAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.delay = min(l.delay*2, maxHostDelay)
+	if until := time.Now().Add(retryAfter); until.After(l.next) {
+		l.next = until
+	}
+}
+
+// relax eases the delay back towards requestDelay after a successful request.
+func (l *hostLimiter) relax() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.delay = max(l.delay*3/4, requestDelay)
+}
+
+func (c *HTTPCrawler) limiter(host string) *hostLimiter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.limiters == nil {
+		c.limiters = make(map[string]*hostLimiter)
+	}
+	l, ok := c.limiters[host]
+	if !ok {
+		l = &hostLimiter{delay: requestDelay}
+		c.limiters[host] = l
+	}
+	return l
+}
+
+func (c *HTTPCrawler) maxAttempts() int {
+	if c.MaxAttempts > 0 {
+		return c.MaxAttempts
+	}
+	return defaultMaxAttempts
+}
+
+// backoff returns the jittered, capped exponential delay before the given retry.
+func (c *HTTPCrawler) backoff(attempt int) time.Duration {
+	base := c.RetryDelay
+	if base <= 0 {
+		base = defaultRetryDelay
+	}
+	d := min(base<<(attempt-1), maxRetryDelay)
+	return d/2 + rand.N(d/2+1)
+}
+
+// isRetryable reports whether a failed attempt may succeed if repeated: a
+// 429 or 5xx response, a timeout, or a connection that was refused, reset or
+// closed early. Anything else, such as a certificate error, an invalid URL or
+// a blocked address, fails the same way every time.
+func isRetryable(err error) bool {
+	var pe *domain.PageError
+	if errors.As(err, &pe) {
+		return pe.Status == http.StatusTooManyRequests || pe.Status >= 500
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTimeout || dnsErr.IsTemporary
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, context.DeadlineExceeded) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
+
+// isThrottled reports whether a response status asks the client to slow down.
+func isThrottled(status int) bool {
+	return status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable
+}
+
+// parseRetryAfter interprets a Retry-After header given in seconds or as an HTTP date.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(secs)*time.Second, 0)
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0)
+	}
+	return 0
+}
@@ -0,0 +1,213 @@
+package crawler
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+	"github.com/adsouza/llms.txt-generator/internal/usecases"
+)
+
+func TestFetchPage_RetriesTransientErrors(t *testing.T) {
+	var hits atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = fmt.Fprint(w, `<html><head><title>Recovered</title></head></html>`)
+	}))
+	defer ts.Close()
+
+	var retries []domain.ProgressEvent
+	ctx := usecases.WithProgress(context.Background(), func(ev domain.ProgressEvent) {
+		retries = append(retries, ev)
+	})
+
+	c := &HTTPCrawler{Client: ts.Client(), RetryDelay: time.Millisecond}
+	page, err := c.FetchPage(ctx, ts.URL+"/page")
+	if err != nil {
+		t.Fatalf("FetchPage() error: %v", err)
+	}
+	if page.Title != "Recovered" {
+		t.Errorf("title = %q, want %q", page.Title, "Recovered")
+	}
+	if len(retries) != 2 {
+		t.Fatalf("got %d retry events, want 2", len(retries))
+	}
+	if retries[0].Type != "retry" || retries[0].Attempt != 1 || retries[0].Status != http.StatusBadGateway {
+		t.Errorf("first retry event = %+v", retries[0])
+	}
+}
+
+func TestFetchPage_GivesUpAfterMaxAttempts(t *testing.T) {
+	var hits atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	c := &HTTPCrawler{Client: ts.Client(), MaxAttempts: 2, RetryDelay: time.Millisecond}
+	_, err := c.FetchPage(context.Background(), ts.URL+"/page")
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if got := hits.Load(); got != 2 {
+		t.Errorf("server hit %d times, want 2", got)
+	}
+}
+
+func TestFetchPage_DoesNotRetryNotFound(t *testing.T) {
+	var hits atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		http.NotFound(w, r)
+	}))
+	defer ts.Close()
+
+	c := &HTTPCrawler{Client: ts.Client(), RetryDelay: time.Millisecond}
+	if _, err := c.FetchPage(context.Background(), ts.URL+"/page"); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if got := hits.Load(); got != 1 {
+		t.Errorf("server hit %d times, want 1", got)
+	}
+}
+
+func TestFetchPage_HonorsRetryAfter(t *testing.T) {
+	var hits atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = fmt.Fprint(w, `<html><head><title>OK</title></head></html>`)
+	}))
+	defer ts.Close()
+
+	c := &HTTPCrawler{Client: ts.Client(), RetryDelay: time.Millisecond}
+	start := time.Now()
+	if _, err := c.FetchPage(context.Background(), ts.URL+"/page"); err != nil {
+		t.Fatalf("FetchPage() error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %v, want at least 1s", elapsed)
+	}
+}
+
+func TestHostLimiter_ThrottleSlowsDown(t *testing.T) {
+	l := &hostLimiter{delay: requestDelay}
+	l.throttle(0)
+	if l.delay != 2*requestDelay {
+		t.Errorf("delay = %v, want %v", l.delay, 2*requestDelay)
+	}
+	for range 10 {
+		l.throttle(0)
+	}
+	if l.delay != maxHostDelay {
+		t.Errorf("delay = %v, want cap %v", l.delay, maxHostDelay)
+	}
+	l.relax()
+	if l.delay >= maxHostDelay || l.delay < requestDelay {
+		t.Errorf("delay after relax = %v, want between %v and %v", l.delay, requestDelay, maxHostDelay)
+	}
+}
+
+func TestFetchPage_PausesBetweenPages(t *testing.T) {
+	var starts []time.Time
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		starts = append(starts, time.Now())
+		// A slow response must not count towards the pause before the next.
+		time.Sleep(2 * requestDelay)
+		_, _ = w.Write([]byte(`<html><head><title>Page</title></head></html>`))
+	}))
+	defer ts.Close()
+
+	c := &HTTPCrawler{Client: ts.Client()}
+	ends := make([]time.Time, 2)
+	for i, path := range []string{"/one", "/two"} {
+		if _, err := c.FetchPage(context.Background(), ts.URL+path); err != nil {
+			t.Fatalf("FetchPage() error: %v", err)
+		}
+		ends[i] = time.Now()
+	}
+	if gap := starts[1].Sub(ends[0]); gap < requestDelay*9/10 {
+		t.Errorf("second request started %v after the first page, want at least %v", gap, requestDelay)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"too many requests", &domain.PageError{Status: http.StatusTooManyRequests}, true},
+		{"server error", &domain.PageError{Status: http.StatusInternalServerError}, true},
+		{"gateway timeout", &domain.PageError{Status: http.StatusGatewayTimeout}, true},
+		{"not found", &domain.PageError{Status: http.StatusNotFound}, false},
+		{"request timeout", &url.Error{Op: "Get", URL: "https://example.com", Err: context.DeadlineExceeded}, true},
+		{"connection refused", &url.Error{Op: "Get", URL: "https://example.com", Err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}}, true},
+		{"connection reset", &url.Error{Op: "Get", URL: "https://example.com", Err: &net.OpError{Op: "read", Err: syscall.ECONNRESET}}, true},
+		{"connection closed", &url.Error{Op: "Get", URL: "https://example.com", Err: io.EOF}, true},
+		{"temporary DNS failure", &net.DNSError{Err: "server misbehaving", IsTemporary: true}, true},
+		{"unknown host", &net.DNSError{Err: "no such host", IsNotFound: true}, false},
+		{"certificate", &url.Error{Op: "Get", URL: "https://example.com", Err: &tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}}}, false},
+		{"invalid URL", &url.Error{Op: "parse", URL: "::", Err: errors.New("missing protocol scheme")}, false},
+		{"blocked address", &url.Error{Op: "Get", URL: "http://10.0.0.1", Err: &net.OpError{Op: "dial", Err: fmt.Errorf("%w: 10.0.0.1", domain.ErrBlockedAddress)}}, false},
+		{"too many redirects", fmt.Errorf("%w: stopped after 10", errTooManyRedirects), false},
+	}
+	for _, tt := range tests {
+		if got := isRetryable(tt.err); got != tt.want {
+			t.Errorf("%s: isRetryable(%v) = %v, want %v", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestFetchPage_TimeoutExcludesHostDelay(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<html><head><title>Page</title></head></html>`))
+	}))
+	defer ts.Close()
+
+	// The pause before the second request is longer than its timeout.
+	c := &HTTPCrawler{Client: ts.Client(), RequestTimeout: requestDelay / 3, MaxAttempts: 1}
+	for _, path := range []string{"/one", "/two"} {
+		if _, err := c.FetchPage(context.Background(), ts.URL+path); err != nil {
+			t.Fatalf("FetchPage(%s) error: %v", path, err)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"5", 5 * time.Second},
+		{"-3", 0},
+		{"Wed, 01 Jan 2025 12:00:30 GMT", 30 * time.Second},
+		{"garbage", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package crawler
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// TransportConfig configures the crawler's outbound connections.
+type TransportConfig struct {
+	// Guard, if set, vets the address of every site the crawler connects to.
+	Guard *Guard
+
+	// ProxyURL sends requests through an HTTP, HTTPS or SOCKS5 proxy, such as
+	// "http://proxy.internal:3128" or "socks5://proxy.internal:1080".
+	ProxyURL string
+
+	// CAFile names a PEM bundle of root certificates to trust in addition to
+	// the system roots, for sites signed by a private CA.
+	CAFile string
+
+	// InsecureSkipVerify disables TLS certificate verification. It is meant
+	// for staging sites with self-signed certificates only.
+	InsecureSkipVerify bool
+}
+
+// NewTransport builds the crawler's HTTP transport. Through a proxy the guard
+// only sees the proxy's address when dialing, so it instead resolves and
+// checks each request's host before the request is handed to the proxy.
+func NewTransport(cfg TransportConfig) (http.RoundTripper, error) {
+	var t *http.Transport
+	if cfg.Guard != nil && cfg.ProxyURL == "" {
+		t = cfg.Guard.Transport()
+	} else {
+		t = http.DefaultTransport.(*http.Transport).Clone()
+		t.Proxy = nil
+	}
+
+	if cfg.ProxyURL != "" {
+		proxy, err := url.Parse(cfg.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		switch proxy.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, fmt.Errorf("invalid proxy URL %q: scheme must be http, https, socks5 or socks5h", proxy.Redacted())
+		}
+		t.Proxy = http.ProxyURL(proxy)
+	}
+
+	if cfg.CAFile != "" || cfg.InsecureSkipVerify {
+		tlsConfig := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+		if cfg.CAFile != "" {
+			pool, err := loadCertPool(cfg.CAFile)
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.RootCAs = pool
+		}
+		t.TLSClientConfig = tlsConfig
+	}
+
+	if cfg.Guard != nil && cfg.ProxyURL != "" {
+		return &proxyGuard{base: t, guard: cfg.Guard}, nil
+	}
+	return t, nil
+}
+
+// loadCertPool returns the system roots extended with the certificates in a PEM file.
+func loadCertPool(path string) (*x509.CertPool, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading CA bundle: %w", err)
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in CA bundle %s", path)
+	}
+	return pool, nil
+}
+
+// proxyGuard checks the target host of each request sent through a proxy.
+// Unlike the dial-time check it cannot see the address the proxy finally
+// connects to, so it does not protect against DNS rebinding.
+type proxyGuard struct {
+	base  http.RoundTripper
+	guard *Guard
+}
+
+func (p *proxyGuard) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := p.guard.CheckHost(req.Context(), req.URL.Hostname()); err != nil {
+		return nil, err
+	}
+	return p.base.RoundTrip(req)
+}
@@ -0,0 +1,132 @@
+package crawler
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+)
+
+func newTLSSite(t *testing.T) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("<html><head><title>Staging</title></head></html>"))
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestNewTransport_TLS(t *testing.T) {
+	ts := newTLSSite(t)
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	if err := os.WriteFile(caFile, certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		cfg     TransportConfig
+		wantErr bool
+	}{
+		{"system roots only", TransportConfig{}, true},
+		{"custom CA bundle", TransportConfig{CAFile: caFile}, false},
+		{"insecure", TransportConfig{InsecureSkipVerify: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport, err := NewTransport(tt.cfg)
+			if err != nil {
+				t.Fatalf("NewTransport() error: %v", err)
+			}
+			c := &HTTPCrawler{Client: &http.Client{Transport: transport}, MaxAttempts: 1}
+			_, err = c.FetchPage(context.Background(), ts.URL+"/")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("FetchPage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewTransport_InvalidConfig(t *testing.T) {
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	if err := os.WriteFile(empty, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, cfg := range []TransportConfig{
+		{ProxyURL: "ftp://proxy.example.com"},
+		{CAFile: filepath.Join(t.TempDir(), "missing.pem")},
+		{CAFile: empty},
+	} {
+		if _, err := NewTransport(cfg); err == nil {
+			t.Errorf("NewTransport(%+v) succeeded, want error", cfg)
+		}
+	}
+}
+
+func TestNewTransport_Proxy(t *testing.T) {
+	var proxied atomic.Int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A forward proxy receives the target's absolute URL.
+		if !r.URL.IsAbs() {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		proxied.Add(1)
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("<html><head><title>Via proxy</title></head></html>"))
+	}))
+	defer proxy.Close()
+
+	t.Run("forwards requests", func(t *testing.T) {
+		transport, err := NewTransport(TransportConfig{ProxyURL: proxy.URL})
+		if err != nil {
+			t.Fatalf("NewTransport() error: %v", err)
+		}
+		c := &HTTPCrawler{Client: &http.Client{Transport: transport}}
+		page, err := c.FetchPage(context.Background(), "http://docs.example.com/")
+		if err != nil {
+			t.Fatalf("FetchPage() error: %v", err)
+		}
+		if page.Title != "Via proxy" {
+			t.Errorf("title = %q, want %q", page.Title, "Via proxy")
+		}
+	})
+
+	t.Run("guard checks the target, not the proxy", func(t *testing.T) {
+		before := proxied.Load()
+		transport, err := NewTransport(TransportConfig{Guard: &Guard{}, ProxyURL: proxy.URL})
+		if err != nil {
+			t.Fatalf("NewTransport() error: %v", err)
+		}
+		c := &HTTPCrawler{Client: &http.Client{Transport: transport}}
+		_, err = c.FetchPage(context.Background(), "http://127.0.0.1/admin")
+		if !errors.Is(err, domain.ErrBlockedAddress) {
+			t.Errorf("FetchPage() error = %v, want ErrBlockedAddress", err)
+		}
+		if proxied.Load() != before {
+			t.Error("blocked request reached the proxy")
+		}
+
+		allowed := &Guard{Allow: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")}}
+		transport, err = NewTransport(TransportConfig{Guard: allowed, ProxyURL: proxy.URL})
+		if err != nil {
+			t.Fatalf("NewTransport() error: %v", err)
+		}
+		c = &HTTPCrawler{Client: &http.Client{Transport: transport}}
+		u, _ := url.Parse(proxy.URL)
+		if _, err := c.FetchPage(context.Background(), "http://"+u.Host+"/wiki"); err != nil {
+			t.Errorf("FetchPage() on allowed network error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,179 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines shown around each change.
+const contextLines = 3
+
+// maxEdits bounds the work spent looking for a minimal diff. Texts that differ
+// by more lines are shown as wholly replaced.
+const maxEdits = 1000
+
+// UnifiedDiff renders the line-by-line difference between two llms.txt files
+// in unified diff format, which Markdown renderers highlight in a ```diff
+// block. Identical texts produce an empty diff.
+type UnifiedDiff struct{}
+
+// edit is one line of a diff: kept (' '), removed ('-') or added ('+').
+type edit struct {
+	op   byte
+	line string
+}
+
+func (UnifiedDiff) Diff(oldText, newText string) string {
+	if oldText == newText {
+		return ""
+	}
+	edits := diffLines(splitLines(oldText), splitLines(newText))
+
+	var b strings.Builder
+	b.WriteString("--- previous/llms.txt\n+++ current/llms.txt\n")
+	for _, h := range hunks(edits) {
+		oldLine, newLine := 1, 1
+		for _, e := range edits[:h.start] {
+			if e.op != '+' {
+				oldLine++
+			}
+			if e.op != '-' {
+				newLine++
+			}
+		}
+		var oldCount, newCount int
+		for _, e := range edits[h.start:h.end] {
+			if e.op != '+' {
+				oldCount++
+			}
+			if e.op != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(oldLine, oldCount), hunkRange(newLine, newCount))
+		for _, e := range edits[h.start:h.end] {
+			b.WriteByte(e.op)
+			b.WriteString(e.line)
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// hunk is a range of edits shown together.
+type hunk struct{ start, end int }
+
+// hunks groups changes that are separated by at most twice contextLines
+// unchanged lines, each padded with up to contextLines lines of context.
+func hunks(edits []edit) []hunk {
+	var out []hunk
+	for i, e := range edits {
+		if e.op == ' ' {
+			continue
+		}
+		start, end := max(i-contextLines, 0), min(i+1+contextLines, len(edits))
+		if n := len(out); n > 0 && start <= out[n-1].end {
+			out[n-1].end = end
+		} else {
+			out = append(out, hunk{start, end})
+		}
+	}
+	return out
+}
+
+// hunkRange formats the start and length of one side of a hunk. An empty
+// range starts at the line before it, as in GNU diff.
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start-1)
+	case 1:
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// splitLines splits text into lines, ignoring the final newline.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// diffLines returns a shortest edit script turning a into b, using Myers'
+// algorithm. If more than maxEdits lines differ, every line of a is removed
+// and every line of b added.
+func diffLines(a, b []string) []edit {
+	n, m := len(a), len(b)
+	limit := min(n+m, maxEdits)
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	var trace [][]int
+	found := false
+	for d := 0; d <= limit && !found; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+	if !found {
+		edits := make([]edit, 0, n+m)
+		for _, line := range a {
+			edits = append(edits, edit{'-', line})
+		}
+		for _, line := range b {
+			edits = append(edits, edit{'+', line})
+		}
+		return edits
+	}
+
+	// Walk back from the end through the saved frontiers.
+	var edits []edit
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			edits = append(edits, edit{' ', a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				edits = append(edits, edit{'+', b[y-1]})
+				y--
+			} else {
+				edits = append(edits, edit{'-', a[x-1]})
+				x--
+			}
+		}
+	}
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
@@ -0,0 +1,81 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     string
+	}{
+		{
+			name: "identical",
+			old:  "# A\n",
+			new:  "# A\n",
+			want: "",
+		},
+		{
+			name: "changed line",
+			old:  "# A\n\n## Docs\n\n- [One](/1)\n- [Two](/2)\n",
+			new:  "# A\n\n## Docs\n\n- [One](/1): First\n- [Two](/2)\n",
+			want: "--- previous/llms.txt\n+++ current/llms.txt\n" +
+				"@@ -2,5 +2,5 @@\n \n ## Docs\n \n-- [One](/1)\n+- [One](/1): First\n - [Two](/2)\n",
+		},
+		{
+			name: "added to empty",
+			old:  "",
+			new:  "# A\n",
+			want: "--- previous/llms.txt\n+++ current/llms.txt\n@@ -0,0 +1 @@\n+# A\n",
+		},
+		{
+			name: "removed at end",
+			old:  "# A\n- [One](/1)\n",
+			new:  "# A\n",
+			want: "--- previous/llms.txt\n+++ current/llms.txt\n@@ -1,2 +1 @@\n # A\n-- [One](/1)\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (UnifiedDiff{}).Diff(tt.old, tt.new); got != tt.want {
+				t.Errorf("Diff() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnifiedDiff_SeparateHunks(t *testing.T) {
+	var oldLines, newLines []string
+	for i := 1; i <= 20; i++ {
+		oldLines = append(oldLines, fmt.Sprintf("line %d", i))
+		newLines = append(newLines, fmt.Sprintf("line %d", i))
+	}
+	newLines[1] = "changed 2"
+	newLines[17] = "changed 18"
+
+	got := UnifiedDiff{}.Diff(strings.Join(oldLines, "\n")+"\n", strings.Join(newLines, "\n")+"\n")
+	if n := strings.Count(got, "@@ -"); n != 2 {
+		t.Fatalf("got %d hunks, want 2:\n%s", n, got)
+	}
+	for _, header := range []string{"@@ -1,5 +1,5 @@", "@@ -15,6 +15,6 @@"} {
+		if !strings.Contains(got, header) {
+			t.Errorf("diff is missing %q:\n%s", header, got)
+		}
+	}
+}
+
+func TestUnifiedDiff_BeyondEditLimit(t *testing.T) {
+	var oldText, newText strings.Builder
+	for i := range maxEdits {
+		fmt.Fprintf(&oldText, "old %d\n", i)
+		fmt.Fprintf(&newText, "new %d\n", i)
+	}
+	got := UnifiedDiff{}.Diff(oldText.String(), newText.String())
+	want := fmt.Sprintf("@@ -1,%d +1,%d @@\n", maxEdits, maxEdits)
+	if !strings.Contains(got, want) {
+		t.Errorf("diff does not replace the whole text with %q", want)
+	}
+}
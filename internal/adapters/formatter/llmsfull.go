@@ -0,0 +1,48 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+)
+
+// LlmsFullTxt formats a domain.Site into llms-full.txt: the llms.txt header
+// followed by the full text of every page whose content could be extracted.
+// A site without any such page has no llms-full.txt, so the result is empty.
+type LlmsFullTxt struct{}
+
+func (f LlmsFullTxt) Format(site domain.Site) string {
+	var pages []domain.Page
+	for _, sec := range site.Sections {
+		pages = appendContent(pages, sec.Pages)
+	}
+	pages = appendContent(pages, site.Optional)
+	if len(pages) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s\n", site.Name)
+
+	if site.Description != "" {
+		fmt.Fprintf(&b, "\n> %s\n", site.Description)
+	}
+
+	for _, p := range pages {
+		fmt.Fprintf(&b, "\n## %s\n\nSource: %s\n\n%s\n", p.Title, p.URL, p.Content)
+	}
+
+	return b.String()
+}
+
+// appendContent appends the pages whose content could be extracted to dst.
+func appendContent(dst, pages []domain.Page) []domain.Page {
+	for _, p := range pages {
+		if p.Content != "" {
+			dst = append(dst, p)
+		}
+	}
+	return dst
+}
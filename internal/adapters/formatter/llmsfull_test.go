@@ -0,0 +1,65 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+)
+
+func TestFormatFull_IncludesContent(t *testing.T) {
+	site := domain.Site{
+		Name:        "Example Site",
+		Description: "A great website for examples.",
+		Sections: []domain.Section{
+			{
+				Name: "Documentation",
+				Pages: []domain.Page{
+					{URL: "https://example.com/docs/intro", Title: "Introduction"},
+					{URL: "https://example.com/docs/spec.md", Title: "Spec", Content: "# Spec\n\nThe full specification."},
+				},
+			},
+		},
+		Optional: []domain.Page{
+			{URL: "https://example.com/notes.txt", Title: "Notes", Content: "Release notes."},
+		},
+	}
+
+	want := `# Example Site
+
+> A great website for examples.
+
+## Spec
+
+Source: https://example.com/docs/spec.md
+
+# Spec
+
+The full specification.
+
+## Notes
+
+Source: https://example.com/notes.txt
+
+Release notes.
+`
+
+	got := LlmsFullTxt{}.Format(site)
+	if got != want {
+		t.Errorf("Format() mismatch.\nGot:\n%s\nWant:\n%s", got, want)
+	}
+}
+
+func TestFormatFull_EmptyWithoutContent(t *testing.T) {
+	site := domain.Site{
+		Name:        "Example Site",
+		Description: "A great website for examples.",
+		Sections: []domain.Section{
+			{Name: "Documentation", Pages: []domain.Page{{URL: "https://example.com/docs/intro", Title: "Introduction"}}},
+		},
+		Optional: []domain.Page{{URL: "https://example.com/about", Title: "About"}},
+	}
+
+	if got := (LlmsFullTxt{}).Format(site); got != "" {
+		t.Errorf("Format() = %q, want empty without any page content", got)
+	}
+}
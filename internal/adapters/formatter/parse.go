@@ -0,0 +1,114 @@
+package formatter
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+)
+
+// listItem matches the start of a list item holding a markdown link.
+var listItem = regexp.MustCompile(`^[-*+]\s+\[`)
+
+// parseLink reads a list item of an llms.txt section: a markdown link,
+// optionally followed by a colon and a description. The title may hold
+// brackets and the URL balanced parentheses, as in
+// "- [Go (language)](https://en.wikipedia.org/wiki/Go_(language))"; the
+// title ends at the first "](" that starts such a URL.
+func parseLink(line string) (domain.Page, bool) {
+	loc := listItem.FindStringIndex(line)
+	if loc == nil {
+		return domain.Page{}, false
+	}
+	rest := line[loc[1]:]
+	for i := strings.Index(rest, "]("); i >= 0; {
+		start := i + len("](")
+		if n, ok := linkURL(rest[start:]); ok {
+			tail := rest[start+n+1:]
+			if desc, ok := strings.CutPrefix(tail, ":"); ok || tail == "" {
+				return domain.Page{Title: rest[:i], URL: rest[start : start+n], Description: strings.TrimSpace(desc)}, true
+			}
+		}
+		next := strings.Index(rest[start:], "](")
+		if next < 0 {
+			break
+		}
+		i = start + next
+	}
+	return domain.Page{}, false
+}
+
+// linkURL returns the length of the URL at the start of s, which ends at the
+// ")" closing its balanced parentheses. URLs hold no whitespace.
+func linkURL(s string) (int, bool) {
+	depth := 0
+	for i, r := range s {
+		switch {
+		case r == ' ' || r == '\t':
+			return 0, false
+		case r == '(':
+			depth++
+		case r == ')' && depth == 0:
+			return i, i > 0
+		case r == ')':
+			depth--
+		}
+	}
+	return 0, false
+}
+
+// Parser reads llms.txt content back into a domain.Site. It accepts what
+// LlmsTxt produces as well as hand-written files: the description may span
+// several blockquote lines, and text that is neither a heading, a blockquote
+// nor a link is ignored. Links under an "Optional" heading become the site's
+// optional pages.
+type Parser struct{}
+
+func (Parser) Parse(text string) (domain.Site, error) {
+	var (
+		site     domain.Site
+		titled   bool
+		section  *domain.Section
+		optional bool
+		desc     []string
+	)
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+		case !titled:
+			name, ok := strings.CutPrefix(line, "# ")
+			if !ok {
+				return domain.Site{}, errors.New("llms.txt must start with a # heading")
+			}
+			site.Name = strings.TrimSpace(name)
+			titled = true
+		case strings.HasPrefix(line, "## "):
+			name := strings.TrimSpace(strings.TrimPrefix(line, "## "))
+			optional = name == "Optional"
+			section = nil
+			if !optional {
+				site.Sections = append(site.Sections, domain.Section{Name: name})
+				section = &site.Sections[len(site.Sections)-1]
+			}
+		case strings.HasPrefix(line, ">") && section == nil && !optional:
+			desc = append(desc, strings.TrimSpace(strings.TrimPrefix(line, ">")))
+		default:
+			p, ok := parseLink(line)
+			if !ok || (section == nil && !optional) {
+				continue
+			}
+			if optional {
+				site.Optional = append(site.Optional, p)
+			} else {
+				section.Pages = append(section.Pages, p)
+			}
+		}
+	}
+	if !titled {
+		return domain.Site{}, errors.New("llms.txt is empty")
+	}
+	site.Description = strings.Join(desc, " ")
+	return site, nil
+}
@@ -0,0 +1,104 @@
+package formatter
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+)
+
+func TestParse_RoundTrip(t *testing.T) {
+	site := domain.Site{
+		Name:        "Example Site",
+		Description: "A great website for examples.",
+		Sections: []domain.Section{
+			{Name: "Documentation", Pages: []domain.Page{
+				{URL: "https://example.com/docs/intro", Title: "Introduction", Description: "Getting started: the basics"},
+				{URL: "https://example.com/docs/api", Title: "API [v2] Reference"},
+			}},
+			{Name: "Blog", Pages: []domain.Page{
+				{URL: "https://example.com/blog/hello", Title: "Hello World", Description: "Our first post"},
+			}},
+		},
+		Optional: []domain.Page{
+			{URL: "https://example.com/about", Title: "About Us", Description: "Learn about our team"},
+		},
+	}
+
+	got, err := Parser{}.Parse(LlmsTxt{}.Format(site))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if !reflect.DeepEqual(got, site) {
+		t.Errorf("Parse(Format(site)) = %+v, want %+v", got, site)
+	}
+}
+
+func TestParse_HandWritten(t *testing.T) {
+	text := "\n# Docs  \n\n> First line\n> second line\n\nSome details about the site.\n\n" +
+		"## Guides\n\nIntro text\n* [Start](https://example.com/start)\n- [Deploy](https://example.com/deploy):  How to ship\n"
+
+	got, err := Parser{}.Parse(text)
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	want := domain.Site{
+		Name:        "Docs",
+		Description: "First line second line",
+		Sections: []domain.Section{{Name: "Guides", Pages: []domain.Page{
+			{URL: "https://example.com/start", Title: "Start"},
+			{URL: "https://example.com/deploy", Title: "Deploy", Description: "How to ship"},
+		}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseLink(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want domain.Page
+		ok   bool
+	}{
+		{"plain", "- [Intro](https://example.com/intro)", domain.Page{Title: "Intro", URL: "https://example.com/intro"}, true},
+		{"description", "- [Intro](https://example.com/intro): Start here", domain.Page{Title: "Intro", URL: "https://example.com/intro", Description: "Start here"}, true},
+		{"parentheses in URL", "- [Go](https://en.wikipedia.org/wiki/Go_(language)): The language",
+			domain.Page{Title: "Go", URL: "https://en.wikipedia.org/wiki/Go_(language)", Description: "The language"}, true},
+		{"bracket in title", "- [Step 1] Install](https://example.com/install)", domain.Page{Title: "Step 1] Install", URL: "https://example.com/install"}, true},
+		{"link in description", "- [API](https://example.com/api): See [Intro](https://example.com/intro)",
+			domain.Page{Title: "API", URL: "https://example.com/api", Description: "See [Intro](https://example.com/intro)"}, true},
+		{"unbalanced URL", "- [Go](https://example.com/Go_(language)", domain.Page{}, false},
+		{"space in URL", "- [Go](https://example.com/a b)", domain.Page{}, false},
+		{"empty URL", "- [Go]()", domain.Page{}, false},
+		{"trailing text", "- [Go](https://example.com/go) and more", domain.Page{}, false},
+		{"not a list item", "[Go](https://example.com/go)", domain.Page{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseLink(tt.line)
+			if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseLink(%q) = %+v, %v; want %+v, %v", tt.line, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"empty", ""},
+		{"blank", "\n \n"},
+		{"no title", "## Docs\n- [A](https://example.com/a)\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := (Parser{}).Parse(tt.text); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
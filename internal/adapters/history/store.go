@@ -0,0 +1,207 @@
+// Package history keeps the record of past generations in a directory, so no
+// database is needed.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+	"github.com/adsouza/llms.txt-generator/internal/usecases"
+)
+
+const indexFile = "index.jsonl"
+
+// Store implements usecases.History. Each generation is written to its own
+// file, named after its ID, and summarized on a line of an index that is only
+// appended to, so listing never reads the outputs. The index is kept in
+// memory once opened.
+type Store struct {
+	dir string
+
+	// MaxEntries, if positive, bounds the number of generations kept. The
+	// oldest are deleted first.
+	MaxEntries int
+
+	mu      sync.Mutex
+	entries []domain.Generation // summaries, oldest first
+	ids     map[string]bool
+}
+
+// Open returns a Store keeping its files in dir, which is created if needed.
+// Index lines that cannot be decoded, such as one cut short by a crash, are
+// skipped.
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	s := &Store{dir: dir, ids: make(map[string]bool)}
+	data, err := os.ReadFile(filepath.Join(dir, indexFile))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		var gen domain.Generation
+		if json.Unmarshal(sc.Bytes(), &gen) != nil || gen.ID == "" {
+			continue
+		}
+		s.entries = append(s.entries, gen)
+		s.ids[gen.ID] = true
+	}
+	return s, nil
+}
+
+// Add implements usecases.History.
+func (s *Store) Add(gen domain.Generation) (string, error) {
+	gen.ID = rand.Text()
+	data, err := json.Marshal(gen)
+	if err != nil {
+		return "", err
+	}
+	if err := writeFile(s.dir, gen.ID+".json", data); err != nil {
+		return "", err
+	}
+
+	summary := summarize(gen)
+	line, err := json.Marshal(summary)
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.appendIndex(append(line, '\n')); err != nil {
+		_ = os.Remove(s.file(gen.ID))
+		return "", err
+	}
+	s.entries = append(s.entries, summary)
+	s.ids[gen.ID] = true
+	if s.MaxEntries > 0 && len(s.entries) > s.MaxEntries {
+		s.prune(len(s.entries) - s.MaxEntries)
+	}
+	return gen.ID, nil
+}
+
+// List implements usecases.History.
+func (s *Store) List(query usecases.HistoryQuery) ([]domain.Generation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []domain.Generation
+	for i := len(s.entries) - 1; i >= 0; i-- {
+		if query.Limit > 0 && len(out) == query.Limit {
+			break
+		}
+		gen := s.entries[i]
+		if query.Host != "" && !strings.EqualFold(hostname(gen.URL), query.Host) {
+			continue
+		}
+		if query.Owner != "" && gen.Owner != query.Owner {
+			continue
+		}
+		out = append(out, gen)
+	}
+	return out, nil
+}
+
+// Get implements usecases.History.
+func (s *Store) Get(id string) (domain.Generation, error) {
+	s.mu.Lock()
+	known := s.ids[id]
+	s.mu.Unlock()
+	if !known {
+		return domain.Generation{}, usecases.ErrGenerationNotFound
+	}
+	data, err := os.ReadFile(s.file(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		// Pruned since it was looked up.
+		return domain.Generation{}, usecases.ErrGenerationNotFound
+	}
+	if err != nil {
+		return domain.Generation{}, err
+	}
+	var gen domain.Generation
+	if err := json.Unmarshal(data, &gen); err != nil {
+		return domain.Generation{}, fmt.Errorf("generation %s: %w", id, err)
+	}
+	return gen, nil
+}
+
+func (s *Store) appendIndex(line []byte) error {
+	f, err := os.OpenFile(filepath.Join(s.dir, indexFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(line); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// prune deletes the n oldest generations and rewrites the index without
+// them. If the index cannot be rewritten, the deleted generations stay listed
+// until the next prune, and Get reports them as not found.
+func (s *Store) prune(n int) {
+	for _, gen := range s.entries[:n] {
+		_ = os.Remove(s.file(gen.ID))
+		delete(s.ids, gen.ID)
+	}
+	s.entries = append([]domain.Generation(nil), s.entries[n:]...)
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, gen := range s.entries {
+		if err := enc.Encode(gen); err != nil {
+			return
+		}
+	}
+	_ = writeFile(s.dir, indexFile, buf.Bytes())
+}
+
+func (s *Store) file(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+// summarize returns gen without its output.
+func summarize(gen domain.Generation) domain.Generation {
+	gen.LlmsTxt, gen.LlmsFullTxt, gen.Locales = "", "", nil
+	return gen
+}
+
+func hostname(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// writeFile writes data to a temporary file and renames it into place, so
+// readers never see a partial file.
+func writeFile(dir, name string, data []byte) error {
+	tmp, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, name))
+}
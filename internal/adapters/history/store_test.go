@@ -0,0 +1,150 @@
+package history
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+	"github.com/adsouza/llms.txt-generator/internal/usecases"
+)
+
+func generation(url, output string) domain.Generation {
+	return domain.Generation{
+		URL:       url,
+		Options:   domain.Options{Language: "en"},
+		StartedAt: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+		Duration:  3 * time.Second,
+		Pages:     2,
+		Failures:  []domain.PageError{{URL: url + "/gone", Status: 404, Reason: "not found"}},
+		LlmsTxt:   output,
+	}
+}
+
+func TestStore_AddGetList(t *testing.T) {
+	s, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	first, err := s.Add(generation("https://example.com", "# Example\n"))
+	if err != nil {
+		t.Fatalf("Add() error: %v", err)
+	}
+	second, err := s.Add(generation("https://Other.example/docs", "# Other\n"))
+	if err != nil {
+		t.Fatalf("Add() error: %v", err)
+	}
+
+	gen, err := s.Get(first)
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	if gen.ID != first || gen.LlmsTxt != "# Example\n" || gen.Options.Language != "en" || len(gen.Failures) != 1 {
+		t.Errorf("Get() = %+v, want the first generation with its output", gen)
+	}
+
+	all, err := s.List(usecases.HistoryQuery{})
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if len(all) != 2 || all[0].ID != second || all[1].ID != first {
+		t.Fatalf("List() = %+v, want both, newest first", all)
+	}
+	if all[0].LlmsTxt != "" {
+		t.Error("List() includes the output")
+	}
+
+	other, _ := s.List(usecases.HistoryQuery{Host: "other.EXAMPLE"})
+	if len(other) != 1 || other[0].ID != second {
+		t.Errorf("List(host) = %+v, want the second generation", other)
+	}
+	limited, _ := s.List(usecases.HistoryQuery{Limit: 1})
+	if len(limited) != 1 || limited[0].ID != second {
+		t.Errorf("List(limit 1) = %+v, want the newest generation", limited)
+	}
+
+	owned := generation("https://example.com", "# Mine\n")
+	owned.Owner = "key:partner"
+	mine, _ := s.Add(owned)
+	if got, _ := s.List(usecases.HistoryQuery{Owner: "key:partner"}); len(got) != 1 || got[0].ID != mine {
+		t.Errorf("List(owner) = %+v, want only the owner's generation", got)
+	}
+}
+
+func TestStore_GetUnknown(t *testing.T) {
+	s, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	for _, id := range []string{"NOPE", "../index"} {
+		if _, err := s.Get(id); !errors.Is(err, usecases.ErrGenerationNotFound) {
+			t.Errorf("Get(%q) error = %v, want ErrGenerationNotFound", id, err)
+		}
+	}
+}
+
+func TestStore_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	id, err := s.Add(generation("https://example.com", "# Example\n"))
+	if err != nil {
+		t.Fatalf("Add() error: %v", err)
+	}
+	// A line cut short by a crash is skipped.
+	f, err := os.OpenFile(filepath.Join(dir, indexFile), os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString(`{"ID":"HALF`)
+	_ = f.Close()
+
+	reopened, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	all, _ := reopened.List(usecases.HistoryQuery{})
+	if len(all) != 1 || all[0].ID != id {
+		t.Fatalf("List() after reopening = %+v, want the generation added", all)
+	}
+	if gen, err := reopened.Get(id); err != nil || gen.LlmsTxt != "# Example\n" {
+		t.Errorf("Get() after reopening = %+v, %v", gen, err)
+	}
+}
+
+func TestStore_MaxEntries(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	s.MaxEntries = 2
+
+	var ids []string
+	for range 3 {
+		id, err := s.Add(generation("https://example.com", "# Example\n"))
+		if err != nil {
+			t.Fatalf("Add() error: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if _, err := s.Get(ids[0]); !errors.Is(err, usecases.ErrGenerationNotFound) {
+		t.Errorf("Get(oldest) error = %v, want ErrGenerationNotFound", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ids[0]+".json")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("oldest generation's file was not deleted: %v", err)
+	}
+	reopened, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	all, _ := reopened.List(usecases.HistoryQuery{})
+	if len(all) != 2 || all[0].ID != ids[2] || all[1].ID != ids[1] {
+		t.Errorf("List() after pruning = %+v, want the two newest", all)
+	}
+}
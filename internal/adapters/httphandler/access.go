@@ -0,0 +1,251 @@
+package httphandler
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/adsouza/llms.txt-generator/internal/usecases"
+)
+
+// Access authenticates API requests and holds each client to its quotas.
+// Clients with an API key are counted by key name, others by IP address.
+type Access struct {
+	// Keys are the accepted API keys, sent as "Authorization: Bearer <key>"
+	// or X-API-Key. Requests with any other key are rejected.
+	Keys []APIKey
+
+	// RequireKey rejects requests without an API key. Otherwise they are
+	// allowed, within the Anonymous limits of their IP address.
+	RequireKey bool
+	Anonymous  usecases.Limits
+
+	// ProxyHops is the number of reverse proxies in front of the server,
+	// each appending the address it received the request from to
+	// X-Forwarded-For. The client's address is taken that many entries from
+	// the end; with none, the header is ignored.
+	ProxyHops int
+
+	// PagesPerJob is the most pages a job may crawl, reserved against the
+	// client's daily page quota while it runs, so that jobs started together
+	// cannot crawl past it. It should match the crawler's page limit.
+	PagesPerJob int
+
+	Quotas *usecases.Quotas
+}
+
+// APIKey identifies a client. Keys sharing a name share their quotas, so a
+// key can be rotated without resetting them.
+type APIKey struct {
+	Name   string
+	Key    string
+	Limits usecases.Limits
+}
+
+// client is the caller of a request, as identified by Access.
+type client struct {
+	id     string
+	limits usecases.Limits
+}
+
+type clientKey struct{}
+
+// jobSlotKey carries a job admitted before an operation started.
+type jobSlotKey struct{}
+
+// jobSlot is a job admitted for a request, claimed by the job it starts.
+type jobSlot struct {
+	res     *usecases.Reservation
+	ticket  *ticket
+	claimed bool
+}
+
+var errMissingKey = errors.New("an API key is required: send it as \"Authorization: Bearer <key>\" or X-API-Key")
+
+// identify returns the caller of a request.
+func (a *Access) identify(ctx huma.Context) (client, error) {
+	key := ctx.Header("X-API-Key")
+	if auth := ctx.Header("Authorization"); key == "" && auth != "" {
+		scheme, token, _ := strings.Cut(auth, " ")
+		if !strings.EqualFold(scheme, "Bearer") {
+			return client{}, errors.New("unsupported authorization scheme; use Bearer")
+		}
+		key = strings.TrimSpace(token)
+	}
+	if key == "" {
+		if a.RequireKey {
+			return client{}, errMissingKey
+		}
+		return client{id: "ip:" + a.clientIP(ctx), limits: a.Anonymous}, nil
+	}
+	for _, k := range a.Keys {
+		if subtle.ConstantTimeCompare([]byte(k.Key), []byte(key)) == 1 {
+			return client{id: "key:" + k.Name, limits: k.Limits}, nil
+		}
+	}
+	return client{}, errors.New("invalid API key")
+}
+
+// clientIP returns the address of the client, as seen by the first of
+// ProxyHops proxies.
+func (a *Access) clientIP(ctx huma.Context) string {
+	if a.ProxyHops > 0 {
+		var hops []string
+		ctx.EachHeader(func(name, value string) {
+			if strings.EqualFold(name, "X-Forwarded-For") {
+				for _, hop := range strings.Split(value, ",") {
+					hops = append(hops, strings.TrimSpace(hop))
+				}
+			}
+		})
+		if len(hops) > 0 {
+			return hops[max(len(hops)-a.ProxyHops, 0)]
+		}
+	}
+	host, _, err := net.SplitHostPort(ctx.RemoteAddr())
+	if err != nil {
+		return ctx.RemoteAddr()
+	}
+	return host
+}
+
+// authenticate identifies the caller of every API request and counts it
+// against the caller's hourly quota, reporting the quota in RateLimit
+// headers. Only paths under /api/ are checked, so the published files stay
+// public.
+func (h *Handler) authenticate(api huma.API) func(huma.Context, func(huma.Context)) {
+	return func(ctx huma.Context, next func(huma.Context)) {
+		if !strings.HasPrefix(ctx.URL().Path, "/api/") {
+			next(ctx)
+			return
+		}
+		c, err := h.Access.identify(ctx)
+		if err != nil {
+			ctx.SetHeader("WWW-Authenticate", `Bearer realm="api"`)
+			_ = huma.WriteErr(api, ctx, http.StatusUnauthorized, err.Error())
+			return
+		}
+		usage, err := h.Access.Quotas.Request(c.id, c.limits)
+		if usage.Limit > 0 {
+			ctx.SetHeader("RateLimit-Limit", strconv.Itoa(usage.Limit))
+			ctx.SetHeader("RateLimit-Remaining", strconv.Itoa(usage.Remaining))
+			ctx.SetHeader("RateLimit-Reset", strconv.Itoa(seconds(usage.Reset)))
+			ctx.SetHeader("RateLimit-Policy", fmt.Sprintf("%d;w=%d", usage.Limit, int(time.Hour.Seconds())))
+		}
+		if err != nil {
+			writeAdmissionError(api, ctx, err)
+			return
+		}
+		ctx = huma.WithValue(ctx, clientKey{}, c)
+		next(huma.WithContext(ctx, usecases.WithOwner(ctx.Context(), c.id)))
+	}
+}
+
+// admitJobs admits a job for the caller before the operation starts, for
+// operations that cannot report errors once they have started, such as
+// event streams. The job's reservation and place in the queue are given up if
+// no job claims them.
+func (h *Handler) admitJobs(api huma.API) func(huma.Context, func(huma.Context)) {
+	return func(ctx huma.Context, next func(huma.Context)) {
+		res, t, err := h.admitJob(ctx.Context())
+		if err != nil {
+			writeAdmissionError(api, ctx, err)
+			return
+		}
+		slot := &jobSlot{res: res, ticket: t}
+		next(huma.WithValue(ctx, jobSlotKey{}, slot))
+		if !slot.claimed {
+			res.Release(0)
+			h.slots.drop(t)
+		}
+	}
+}
+
+// reserveJob counts a job against the quotas of the caller in ctx.
+func (h *Handler) reserveJob(ctx context.Context) (*usecases.Reservation, error) {
+	return h.jobReserver(ctx)()
+}
+
+// jobReserver returns a function that counts a job against the quotas of the
+// caller in ctx, for jobs started after the request has ended.
+func (h *Handler) jobReserver(ctx context.Context) func() (*usecases.Reservation, error) {
+	c, ok := ctx.Value(clientKey{}).(client)
+	if h.Access == nil || !ok {
+		return func() (*usecases.Reservation, error) { return nil, nil }
+	}
+	return func() (*usecases.Reservation, error) {
+		return h.Access.Quotas.StartJob(c.id, c.limits, h.Access.PagesPerJob)
+	}
+}
+
+// quotaFreed returns a channel that is closed when a job releases its quota,
+// or nil, which never is, without access control.
+func (h *Handler) quotaFreed() <-chan struct{} {
+	if h.Access == nil {
+		return nil
+	}
+	return h.Access.Quotas.Freed()
+}
+
+// jobLimit returns how many jobs the caller in ctx may run at once, or 0 if
+// it is not limited.
+func (h *Handler) jobLimit(ctx context.Context) int {
+	c, _ := ctx.Value(clientKey{}).(client)
+	return c.limits.ConcurrentJobs
+}
+
+// admissionError converts an error from admitJob or reserveJob to a 429
+// response, or a 503 if the queue is full.
+func admissionError(err error) error {
+	status, msg, headers := admissionProblem(err)
+	if headers == nil {
+		return huma.NewError(status, msg)
+	}
+	return huma.ErrorWithHeaders(huma.NewError(status, msg), headers)
+}
+
+// writeAdmissionError responds with the error of admissionError from a
+// middleware.
+func writeAdmissionError(api huma.API, ctx huma.Context, err error) {
+	status, msg, headers := admissionProblem(err)
+	for name, values := range headers {
+		ctx.SetHeader(name, values[0])
+	}
+	_ = huma.WriteErr(api, ctx, status, msg)
+}
+
+// admissionProblem returns the status, message and headers of the response
+// for an error from admitJob, with nil headers for unexpected errors.
+func admissionProblem(err error) (int, string, http.Header) {
+	if errors.Is(err, errQueueFull) {
+		return http.StatusServiceUnavailable, err.Error(), retryAfter(queueRetryAfter)
+	}
+	var quotaErr *usecases.QuotaError
+	if !errors.As(err, &quotaErr) {
+		return http.StatusInternalServerError, "checking quotas failed: " + err.Error(), nil
+	}
+	headers := http.Header{}
+	if quotaErr.RetryAfter > 0 {
+		headers = retryAfter(quotaErr.RetryAfter)
+	}
+	return http.StatusTooManyRequests, "rate limit exceeded: " + quotaErr.Error(), headers
+}
+
+// retryAfter returns a Retry-After header suggesting to retry after d.
+func retryAfter(d time.Duration) http.Header {
+	return http.Header{"Retry-After": {strconv.Itoa(seconds(d))}}
+}
+
+// seconds rounds d up to whole seconds.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
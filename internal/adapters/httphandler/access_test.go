@@ -0,0 +1,198 @@
+package httphandler
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2/humatest"
+
+	"github.com/adsouza/llms.txt-generator/internal/usecases"
+)
+
+func newAccessAPI(t *testing.T, access *Access, gen *fakeStreamGenerator) humatest.TestAPI {
+	access.Quotas = &usecases.Quotas{}
+	h := New(&fakeGenerator{}, gen, 5)
+	h.History = &fakeHistory{}
+	h.Access = access
+	_, api := humatest.New(t)
+	h.Register(api)
+	return api
+}
+
+func TestAccess_APIKeys(t *testing.T) {
+	api := newAccessAPI(t, &Access{
+		Keys:       []APIKey{{Name: "partner", Key: "s3cret", Limits: usecases.Limits{RequestsPerHour: 2}}},
+		RequireKey: true,
+	}, &fakeStreamGenerator{})
+
+	for _, header := range []string{"", "Authorization: Bearer wrong", "Authorization: Basic czNjcmV0"} {
+		var resp = api.Get("/api/jobs/NOPE")
+		if header != "" {
+			resp = api.Get("/api/jobs/NOPE", header)
+		}
+		if resp.Code != http.StatusUnauthorized || resp.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("with %q: status = %d, want 401 with WWW-Authenticate", header, resp.Code)
+		}
+	}
+
+	for i, header := range []string{"Authorization: Bearer s3cret", "X-API-Key: s3cret"} {
+		resp := api.Get("/api/jobs/NOPE", header)
+		if resp.Code != http.StatusNotFound {
+			t.Fatalf("with %q: status = %d, want the request through", header, resp.Code)
+		}
+		if got, want := resp.Header().Get("RateLimit-Remaining"), []string{"1", "0"}[i]; got != want || resp.Header().Get("RateLimit-Limit") != "2" {
+			t.Errorf("RateLimit-Limit = %q, RateLimit-Remaining = %q; want 2 and %s", resp.Header().Get("RateLimit-Limit"), got, want)
+		}
+		if got := resp.Header().Get("RateLimit-Policy"); got != "2;w=3600" {
+			t.Errorf("RateLimit-Policy = %q", got)
+		}
+	}
+
+	resp := api.Get("/api/jobs/NOPE", "X-API-Key: s3cret")
+	if resp.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", resp.Code, http.StatusTooManyRequests)
+	}
+	if resp.Header().Get("Retry-After") == "" || !strings.Contains(resp.Header().Get("Content-Type"), "problem+json") {
+		t.Errorf("429 headers = %v, want Retry-After and Problem JSON", resp.Header())
+	}
+	if !strings.Contains(resp.Body.String(), "requests_per_hour") {
+		t.Errorf("body = %s, want the quota named", resp.Body.String())
+	}
+
+	if resp := api.Get("/sites/example.com/llms.txt"); resp.Code != http.StatusNotFound {
+		t.Errorf("published file status = %d, want it served without a key", resp.Code)
+	}
+}
+
+func TestAccess_PerIP(t *testing.T) {
+	api := newAccessAPI(t, &Access{
+		Anonymous: usecases.Limits{RequestsPerHour: 1},
+		ProxyHops: 1,
+	}, &fakeStreamGenerator{})
+
+	tests := []struct {
+		forwardedFor string
+		want         int
+	}{
+		{"203.0.113.9", http.StatusNotFound},
+		{"203.0.113.9, 198.51.100.1", http.StatusNotFound},
+		{"198.51.100.1", http.StatusTooManyRequests},
+		{"198.51.100.2", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		resp := api.Get("/api/jobs/NOPE", "X-Forwarded-For: "+tt.forwardedFor)
+		if resp.Code != tt.want {
+			t.Errorf("X-Forwarded-For %q: status = %d, want %d", tt.forwardedFor, resp.Code, tt.want)
+		}
+	}
+}
+
+func TestAccess_ConcurrentJobs(t *testing.T) {
+	gen := &fakeStreamGenerator{block: make(chan struct{})}
+	api := newAccessAPI(t, &Access{Anonymous: usecases.Limits{ConcurrentJobs: 1}}, gen)
+
+	resp := api.Post("/api/jobs", map[string]any{"url": "https://example.com"})
+	if resp.Code != http.StatusAccepted {
+		t.Fatalf("status = %d: %s", resp.Code, resp.Body.String())
+	}
+	first := decodeJob(t, resp.Body.String())
+
+	for _, path := range []string{"/api/jobs", "/api/generate-stream", "/api/batch", "/api/generate"} {
+		body := map[string]any{"url": "https://other.example"}
+		if path == "/api/batch" {
+			body = map[string]any{"urls": []string{"https://other.example"}}
+		}
+		resp := api.Post(path, body)
+		if resp.Code != http.StatusTooManyRequests || !strings.Contains(resp.Body.String(), "concurrent_jobs") {
+			t.Errorf("POST %s status = %d, want 429 naming concurrent_jobs: %s", path, resp.Code, resp.Body.String())
+		}
+	}
+
+	close(gen.block)
+	waitForJob(t, api, first.ID, jobSucceeded)
+	deadline := time.Now().Add(5 * time.Second)
+	for api.Post("/api/jobs", map[string]any{"url": "https://example.com"}).Code != http.StatusAccepted {
+		if time.Now().After(deadline) {
+			t.Fatal("job slot not released after the first job finished")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAccess_PagesPerDay(t *testing.T) {
+	api := newAccessAPI(t, &Access{Anonymous: usecases.Limits{PagesPerDay: 1}}, &fakeStreamGenerator{})
+
+	resp := api.Post("/api/jobs", map[string]any{"url": "https://example.com"})
+	waitForJob(t, api, decodeJob(t, resp.Body.String()).ID, jobSucceeded)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp = api.Post("/api/jobs", map[string]any{"url": "https://example.com"})
+		if resp.Code == http.StatusTooManyRequests {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("status = %d, want 429 once the crawled pages are charged", resp.Code)
+		}
+		waitForJob(t, api, decodeJob(t, resp.Body.String()).ID, jobSucceeded)
+	}
+	if !strings.Contains(resp.Body.String(), "pages_per_day") || resp.Header().Get("Retry-After") == "" {
+		t.Errorf("429 = %v %s, want pages_per_day with Retry-After", resp.Header(), resp.Body.String())
+	}
+}
+
+func TestAccess_ReservesPagesPerJob(t *testing.T) {
+	gen := &fakeStreamGenerator{block: make(chan struct{})}
+	api := newAccessAPI(t, &Access{Anonymous: usecases.Limits{PagesPerDay: 20}, PagesPerJob: 10}, gen)
+
+	var jobs []string
+	for range 2 {
+		resp := api.Post("/api/jobs", map[string]any{"url": "https://example.com"})
+		if resp.Code != http.StatusAccepted {
+			t.Fatalf("status = %d: %s", resp.Code, resp.Body.String())
+		}
+		jobs = append(jobs, decodeJob(t, resp.Body.String()).ID)
+	}
+	resp := api.Post("/api/jobs", map[string]any{"url": "https://example.com"})
+	if resp.Code != http.StatusTooManyRequests || !strings.Contains(resp.Body.String(), "pages_per_day") {
+		t.Errorf("status = %d, want 429 naming pages_per_day while every page is reserved: %s", resp.Code, resp.Body.String())
+	}
+
+	// Finished jobs are charged the two pages they crawled, refunding the rest.
+	close(gen.block)
+	for _, id := range jobs {
+		waitForJob(t, api, id, jobSucceeded)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for api.Post("/api/jobs", map[string]any{"url": "https://example.com"}).Code != http.StatusAccepted {
+		if time.Now().After(deadline) {
+			t.Fatal("reserved pages not refunded after the jobs finished")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAccess_BatchSitesCountAsJobs(t *testing.T) {
+	api := newAccessAPI(t, &Access{Anonymous: usecases.Limits{PagesPerDay: 4}, PagesPerJob: 2}, &fakeStreamGenerator{})
+
+	resp := api.Post("/api/batch", map[string]any{"urls": []string{
+		"https://one.example", "https://two.example", "https://three.example", "https://four.example",
+	}})
+	if resp.Code != http.StatusAccepted {
+		t.Fatalf("status = %d: %s", resp.Code, resp.Body.String())
+	}
+	status := waitForBatch(t, api, decodeBatch(t, resp.Body.String()).ID, hasStatus(jobFailed))
+	if status.Succeeded != 2 || status.Failed != 2 {
+		t.Fatalf("succeeded %d, failed %d; want the sites that fit in the page quota to succeed", status.Succeeded, status.Failed)
+	}
+	for _, item := range status.Items {
+		if item.Status == jobFailed && !strings.Contains(item.Error, "pages_per_day") {
+			t.Errorf("site %s error = %q, want pages_per_day", item.URL, item.Error)
+		}
+	}
+	if resp := api.Post("/api/batch", map[string]any{"urls": []string{"https://five.example"}}); resp.Code != http.StatusTooManyRequests {
+		t.Errorf("status = %d, want 429 once the page quota is used up", resp.Code)
+	}
+}
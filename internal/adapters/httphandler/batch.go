@@ -0,0 +1,504 @@
+package httphandler
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+	"github.com/adsouza/llms.txt-generator/internal/usecases"
+)
+
+// BatchRequest generates llms.txt for many sites with shared options.
+type BatchRequest struct {
+	URLs          []string `json:"urls" doc:"Website URLs to generate llms.txt for" minItems:"1" maxItems:"100"`
+	MaxErrorRatio float64  `json:"max_error_ratio,omitempty" doc:"Strict mode: fail a site if the fraction of its pages that could not be fetched exceeds this ratio (0 disables)" minimum:"0" maximum:"1"`
+	Language      string   `json:"language,omitempty" doc:"Keep only pages in this locale, e.g. en or pt-BR (defaults to each homepage's locale)" maxLength:"35"`
+	PerLocale     bool     `json:"per_locale,omitempty" doc:"Also generate one llms.txt per locale"`
+	ForceRefresh  bool     `json:"force_refresh,omitempty" doc:"Crawl the sites even if cached results are available"`
+	CallbackURL   string   `json:"callback_url,omitempty" doc:"URL that receives the batch's final status once every site has finished, signed with X-Webhook-Signature"`
+}
+
+func (r BatchRequest) options() domain.Options {
+	return domain.Options{MaxErrorRatio: r.MaxErrorRatio, Language: r.Language, PerLocale: r.PerLocale, ForceRefresh: r.ForceRefresh}
+}
+
+// BatchInput is the Huma request body for starting a batch.
+type BatchInput struct {
+	Body BatchRequest
+}
+
+// Resolve implements huma.Resolver, checking every URL before any work
+// starts.
+func (i *BatchInput) Resolve(ctx huma.Context) []error {
+	var errs []error
+	seen := make(map[string]bool, len(i.Body.URLs))
+	for n, siteURL := range i.Body.URLs {
+		location := fmt.Sprintf("body.urls[%d]", n)
+		if err := checkSiteURL(location, siteURL); err != nil {
+			errs = append(errs, err)
+		} else if seen[siteURL] {
+			errs = append(errs, badRequest(location, "duplicate URL", siteURL))
+		}
+		seen[siteURL] = true
+	}
+	if i.Body.CallbackURL != "" {
+		if err := checkCallback(ctx, "body.callback_url", i.Body.CallbackURL); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// BatchItem describes the generation of one site of a batch.
+type BatchItem struct {
+	JobID     string `json:"job_id" doc:"ID of the site's job, whose status includes the generated content"`
+	URL       string `json:"url" doc:"Website URL"`
+	Status    string `json:"status" enum:"queued,running,succeeded,failed,cancelled" doc:"Job state"`
+	Done      int    `json:"done" doc:"Pages processed so far"`
+	Total     int    `json:"total" doc:"Pages to fetch, once discovery has finished"`
+	Failures  int    `json:"failures" doc:"Pages that could not be fetched so far"`
+	Error     string `json:"error,omitempty" doc:"Why the site failed"`
+	HistoryID string `json:"history_id,omitempty" doc:"ID of the generation in the history, once succeeded, when one is kept"`
+}
+
+// BatchStatus describes a batch and the progress of its sites.
+type BatchStatus struct {
+	ID          string      `json:"id" doc:"Batch ID"`
+	Status      string      `json:"status" enum:"queued,running,succeeded,failed,cancelled" doc:"Batch state: succeeded once every site has, failed if any site failed or was cancelled"`
+	Sites       int         `json:"sites" doc:"Sites in the batch"`
+	Queued      int         `json:"queued" doc:"Sites waiting for a crawl slot"`
+	Running     int         `json:"running" doc:"Sites being generated"`
+	Succeeded   int         `json:"succeeded" doc:"Sites generated"`
+	Failed      int         `json:"failed" doc:"Sites that failed"`
+	Cancelled   int         `json:"cancelled" doc:"Sites cancelled"`
+	PagesDone   int         `json:"pages_done" doc:"Pages processed so far, across all sites"`
+	PagesTotal  int         `json:"pages_total" doc:"Pages to fetch across the sites whose discovery has finished"`
+	CreatedAt   time.Time   `json:"created_at" doc:"When the batch was submitted"`
+	FinishedAt  *time.Time  `json:"finished_at,omitempty" doc:"When the last site finished; the batch expires a while later"`
+	CallbackURL string      `json:"callback_url,omitempty" doc:"URL notified when the batch finishes, without its query or credentials"`
+	ArchiveURL  string      `json:"archive_url" doc:"URL of a zip archive of the files generated so far"`
+	Items       []BatchItem `json:"items" doc:"Sites in the order submitted"`
+}
+
+// BatchIDInput identifies a batch.
+type BatchIDInput struct {
+	ID string `path:"id" doc:"Batch ID"`
+}
+
+// BatchOutput is the Huma response for batch operations.
+type BatchOutput struct {
+	Location string `header:"Location" doc:"URL of the batch's status"`
+	Body     BatchStatus
+}
+
+// batch is a set of jobs submitted together, which take turns for the crawl
+// slots batches may use.
+type batch struct {
+	id          string
+	callbackURL string
+	owner       string // client that started the batch, empty without access control
+	created     time.Time
+	cancel      context.CancelFunc
+	jobs        []*job
+	reserve     func() (*usecases.Reservation, error) // counts a site against the caller's quotas
+
+	mu        sync.Mutex
+	cancelled bool
+	finished  time.Time
+}
+
+func (b *batch) finishedAt() time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.finished
+}
+
+// stop cancels every job of the batch that has not finished.
+func (b *batch) stop() {
+	b.mu.Lock()
+	if b.finished.IsZero() {
+		b.cancelled = true
+	}
+	b.mu.Unlock()
+	b.cancel()
+}
+
+func (b *batch) finish() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.finished = time.Now()
+}
+
+// snapshot aggregates the status of the batch's jobs.
+func (b *batch) snapshot() BatchStatus {
+	s := BatchStatus{
+		ID:          b.id,
+		Sites:       len(b.jobs),
+		CreatedAt:   b.created,
+		CallbackURL: redactURL(b.callbackURL),
+		ArchiveURL:  "/api/batch/" + b.id + "/archive",
+		Items:       make([]BatchItem, len(b.jobs)),
+	}
+	for i, j := range b.jobs {
+		js := j.snapshot()
+		s.Items[i] = BatchItem{
+			JobID:     js.ID,
+			URL:       js.URL,
+			Status:    js.Status,
+			Done:      js.Done,
+			Total:     js.Total,
+			Failures:  len(js.Failures),
+			Error:     js.Error,
+			HistoryID: js.HistoryID,
+		}
+		s.PagesDone += js.Done
+		s.PagesTotal += js.Total
+		switch js.Status {
+		case jobQueued:
+			s.Queued++
+		case jobRunning:
+			s.Running++
+		case jobSucceeded:
+			s.Succeeded++
+		case jobFailed:
+			s.Failed++
+		case jobCancelled:
+			s.Cancelled++
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case !b.finished.IsZero():
+		finished := b.finished
+		s.FinishedAt = &finished
+		s.Status = jobFailed
+		if b.cancelled {
+			s.Status = jobCancelled
+		} else if s.Succeeded == s.Sites {
+			s.Status = jobSucceeded
+		}
+	case s.Queued == s.Sites:
+		s.Status = jobQueued
+	default:
+		s.Status = jobRunning
+	}
+	return s
+}
+
+// batchConcurrency returns how many crawl slots batches may use together.
+func (h *Handler) batchConcurrency() int {
+	if h.BatchConcurrency > 0 {
+		return min(h.BatchConcurrency, h.slots.slots)
+	}
+	return max(h.slots.slots/2, 1)
+}
+
+func (h *Handler) registerBatches(api huma.API) {
+	h.batchSlots = make(chan struct{}, h.batchConcurrency())
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "create-batch",
+		Method:        http.MethodPost,
+		Path:          "/api/batch",
+		Summary:       "Start generating llms.txt for many sites",
+		Description:   "Returns immediately with a batch ID. Each site runs as a job; batches share a limited number of crawl slots in turn, leaving the rest to other requests.",
+		Tags:          []string{"Batches"},
+		DefaultStatus: http.StatusAccepted,
+		Middlewares:   huma.Middlewares{h.acceptCallbacks},
+	}, h.handleCreateBatch)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "get-batch",
+		Method:      http.MethodGet,
+		Path:        "/api/batch/{id}",
+		Summary:     "Get a batch's progress and per-site results",
+		Tags:        []string{"Batches"},
+	}, h.handleGetBatch)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "cancel-batch",
+		Method:        http.MethodDelete,
+		Path:          "/api/batch/{id}",
+		Summary:       "Cancel every unfinished site of a batch",
+		Tags:          []string{"Batches"},
+		DefaultStatus: http.StatusAccepted,
+	}, h.handleCancelBatch)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "get-batch-archive",
+		Method:      http.MethodGet,
+		Path:        "/api/batch/{id}/archive",
+		Summary:     "Download a zip archive of a batch's generated files",
+		Description: "Holds a directory per generated site with its llms.txt, llms-full.txt and per-locale files, and batch.json with the batch's status. Sites that have not succeeded yet are left out.",
+		Tags:        []string{"Batches"},
+		Responses: map[string]*huma.Response{
+			"200": {
+				Description: "Zip archive",
+				Content:     map[string]*huma.MediaType{"application/zip": {Schema: &huma.Schema{Type: "string", Format: "binary"}}},
+			},
+		},
+	}, h.handleBatchArchive)
+}
+
+func (h *Handler) handleCreateBatch(ctx context.Context, input *BatchInput) (*BatchOutput, error) {
+	b, err := h.startBatch(ctx, input.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &BatchOutput{Location: "/api/batch/" + b.id, Body: b.snapshot()}, nil
+}
+
+// startBatch registers a job for every site of req and runs them in the
+// background. Each site counts as a job against the quotas of the caller in
+// ctx, reserved when its turn comes; the first is reserved now, so that a
+// caller out of quota is refused outright.
+func (h *Handler) startBatch(ctx context.Context, req BatchRequest) (*batch, error) {
+	reserve := h.jobReserver(ctx)
+	res, err := reserve()
+	if err != nil {
+		return nil, admissionError(err)
+	}
+	batchCtx, cancel := context.WithCancel(usecases.WithOwner(context.Background(), usecases.Owner(ctx)))
+	b := &batch{
+		id:          rand.Text(),
+		callbackURL: req.CallbackURL,
+		owner:       usecases.Owner(ctx),
+		created:     time.Now(),
+		cancel:      cancel,
+		reserve:     reserve,
+	}
+	contexts := make([]context.Context, len(req.URLs))
+	for i, siteURL := range req.URLs {
+		var j *job
+		j, contexts[i] = h.newJob(batchCtx, GenerateRequest{URL: siteURL})
+		b.jobs = append(b.jobs, j)
+	}
+	workers := min(len(b.jobs), h.batchConcurrency())
+	if limit := h.jobLimit(ctx); limit > 0 {
+		workers = min(workers, limit)
+	}
+	h.batches.add(b.id, b, h.jobTTL(), h.maxJobs())
+	go h.runBatch(b, contexts, req.options(), res, workers)
+	return b, nil
+}
+
+// runBatch runs the batch's jobs with the given number of workers, then
+// notifies its callback URL. Each worker takes one of the slots shared by all
+// batches before taking a crawl slot, so batches never hold more crawl slots
+// than batchConcurrency. Waiting workers are served in turn, whatever their
+// batch, so a large batch does not starve the others. first is the
+// reservation of the first site; the others are reserved as they start,
+// waiting for the caller's other jobs to free its quota if need be.
+func (h *Handler) runBatch(b *batch, contexts []context.Context, opts domain.Options, first *usecases.Reservation, workers int) {
+	defer b.cancel()
+
+	queue := make(chan int, len(b.jobs))
+	for i := range b.jobs {
+		queue <- i
+	}
+	close(queue)
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				res, err := first, error(nil)
+				if i > 0 {
+					res, err = h.reserveBatchJob(contexts[i], b)
+				}
+				if err != nil && contexts[i].Err() != nil {
+					b.jobs[i].cancel()
+					b.jobs[i].finish(jobCancelled)
+					continue
+				}
+				if err != nil {
+					_, msg, _ := admissionProblem(err)
+					b.jobs[i].fail(msg)
+					continue
+				}
+				h.runBatchJob(contexts[i], b.jobs[i], opts)
+				res.Release(b.jobs[i].snapshot().Done)
+			}
+		}()
+	}
+	wg.Wait()
+	b.finish()
+
+	if b.callbackURL != "" && h.Callbacks != nil {
+		status := b.snapshot()
+		_ = h.Callbacks.Deliver(usecases.WithOwner(context.Background(), b.owner), b.callbackURL, "batch."+status.Status, b.id, status)
+	}
+}
+
+// reserveBatchJob counts a site of the batch against the caller's quotas.
+// While they are held by the caller's other jobs, including those of the
+// batch, it waits for one to finish, until ctx ends; it only fails for quotas
+// that free up when their window starts over, such as a day's pages.
+func (h *Handler) reserveBatchJob(ctx context.Context, b *batch) (*usecases.Reservation, error) {
+	for {
+		freed := h.quotaFreed()
+		res, err := b.reserve()
+		var quotaErr *usecases.QuotaError
+		if err == nil || !errors.As(err, &quotaErr) || quotaErr.RetryAfter > 0 {
+			return res, err
+		}
+		select {
+		case <-freed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// runBatchJob runs one job of a batch once it has a batch slot. Batch jobs
+// have been accepted already, so they wait in the crawl queue for as long as
+// it takes, without counting towards its limit.
+func (h *Handler) runBatchJob(ctx context.Context, j *job, opts domain.Options) {
+	select {
+	case h.batchSlots <- struct{}{}:
+		defer func() { <-h.batchSlots }()
+	case <-ctx.Done():
+		j.cancel()
+		j.finish(jobCancelled)
+		return
+	}
+	t, _ := h.slots.join(0)
+	h.executeJob(ctx, j, opts, t, 0)
+}
+
+// ownBatch returns the batch with the given ID if the caller in ctx may see
+// it. Another client's batch is reported as not found.
+func (h *Handler) ownBatch(ctx context.Context, id string) (*batch, bool) {
+	b, ok := h.batches.get(id, h.jobTTL())
+	if !ok || !startedBy(b.owner, usecases.Owner(ctx)) {
+		return nil, false
+	}
+	return b, true
+}
+
+func (h *Handler) handleGetBatch(ctx context.Context, input *BatchIDInput) (*BatchOutput, error) {
+	b, ok := h.ownBatch(ctx, input.ID)
+	if !ok {
+		return nil, huma.Error404NotFound("batch not found")
+	}
+	return &BatchOutput{Location: "/api/batch/" + b.id, Body: b.snapshot()}, nil
+}
+
+func (h *Handler) handleCancelBatch(ctx context.Context, input *BatchIDInput) (*BatchOutput, error) {
+	b, ok := h.ownBatch(ctx, input.ID)
+	if !ok {
+		return nil, huma.Error404NotFound("batch not found")
+	}
+	b.stop()
+	return &BatchOutput{Location: "/api/batch/" + b.id, Body: b.snapshot()}, nil
+}
+
+// handleBatchArchive streams the batch's archive as it is zipped. Once the
+// headers are sent, a failure can only cut the archive short, which leaves it
+// without its central directory so that clients reject it.
+func (h *Handler) handleBatchArchive(ctx context.Context, input *BatchIDInput) (*huma.StreamResponse, error) {
+	b, ok := h.ownBatch(ctx, input.ID)
+	if !ok {
+		return nil, huma.Error404NotFound("batch not found")
+	}
+	return &huma.StreamResponse{Body: func(ctx huma.Context) {
+		ctx.SetHeader("Content-Type", "application/zip")
+		ctx.SetHeader("Content-Disposition", fmt.Sprintf(`attachment; filename="batch-%s.zip"`, b.id))
+		ctx.SetStatus(http.StatusOK)
+		_ = archive(b, ctx.BodyWriter())
+	}}, nil
+}
+
+// archive writes a zip of the files of the batch's succeeded jobs to w, each
+// in a directory named after its URL, with the batch's status in batch.json.
+func archive(b *batch, w io.Writer) error {
+	zw := zip.NewWriter(w)
+	now := time.Now()
+	add := func(name, content string) error {
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: now})
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(f, content)
+		return err
+	}
+
+	dirs := make(map[string]bool)
+	for _, j := range b.jobs {
+		s := j.snapshot()
+		if s.Status != jobSucceeded {
+			continue
+		}
+		dir := archiveDir(s.URL, dirs)
+		if err := add(dir+"/llms.txt", s.LlmsTxt); err != nil {
+			return err
+		}
+		if s.LlmsFullTxt != "" {
+			if err := add(dir+"/llms-full.txt", s.LlmsFullTxt); err != nil {
+				return err
+			}
+		}
+		for locale, content := range s.Locales {
+			if err := add(dir+"/"+safeName(locale)+"/llms.txt", content); err != nil {
+				return err
+			}
+		}
+	}
+
+	status, err := json.MarshalIndent(b.snapshot(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := add("batch.json", string(status)+"\n"); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// archiveDir names the archive directory of siteURL after its host and path,
+// e.g. example.com_docs, numbering names already in use.
+func archiveDir(siteURL string, used map[string]bool) string {
+	name := siteURL
+	if u, err := url.Parse(siteURL); err == nil {
+		name = u.Host + strings.TrimSuffix(u.Path, "/")
+	}
+	name = safeName(name)
+	dir := name
+	for n := 2; used[dir]; n++ {
+		dir = fmt.Sprintf("%s-%d", name, n)
+	}
+	used[dir] = true
+	return dir
+}
+
+// safeName replaces the characters of s that are not safe in a file name,
+// and leading dots, so that names never climb out of the archive.
+func safeName(s string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-':
+			return r
+		}
+		return '_'
+	}, s)
+	if trimmed := strings.TrimLeft(name, "."); trimmed != name || name == "" {
+		name = "_" + trimmed
+	}
+	return name
+}
@@ -0,0 +1,298 @@
+package httphandler
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2/humatest"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+	"github.com/adsouza/llms.txt-generator/internal/usecases"
+)
+
+func decodeBatch(t *testing.T, body string) BatchStatus {
+	t.Helper()
+	var status BatchStatus
+	if err := json.Unmarshal([]byte(body), &status); err != nil {
+		t.Fatalf("decoding batch: %v: %s", err, body)
+	}
+	return status
+}
+
+// waitForBatch polls a batch until done reports true for its status.
+func waitForBatch(t *testing.T, api humatest.TestAPI, id string, done func(BatchStatus) bool) BatchStatus {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp := api.Get("/api/batch/" + id)
+		if resp.Code != http.StatusOK {
+			t.Fatalf("GET batch status = %d: %s", resp.Code, resp.Body.String())
+		}
+		status := decodeBatch(t, resp.Body.String())
+		if done(status) {
+			return status
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("batch did not reach the expected state: %+v", status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func hasStatus(state string) func(BatchStatus) bool {
+	return func(s BatchStatus) bool { return s.Status == state }
+}
+
+func TestBatch_Lifecycle(t *testing.T) {
+	callbacks := &fakeCallbacks{done: make(chan struct{})}
+	h := New(nil, &fakeStreamGenerator{}, 5)
+	h.Callbacks = callbacks
+	_, api := humatest.New(t)
+	h.Register(api)
+
+	resp := api.Post("/api/batch", map[string]any{
+		"urls":         []string{"https://example.com", "https://example.com/docs", "https://other.example"},
+		"callback_url": "https://ci.example/hook",
+	})
+	if resp.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want %d: %s", resp.Code, http.StatusAccepted, resp.Body.String())
+	}
+	created := decodeBatch(t, resp.Body.String())
+	if resp.Header().Get("Location") != "/api/batch/"+created.ID || created.Sites != 3 || len(created.Items) != 3 {
+		t.Fatalf("created batch %+v at %q", created, resp.Header().Get("Location"))
+	}
+
+	done := waitForBatch(t, api, created.ID, hasStatus(jobSucceeded))
+	if done.Succeeded != 3 || done.Queued+done.Running+done.Failed+done.Cancelled != 0 {
+		t.Errorf("counts = %+v, want 3 succeeded", done)
+	}
+	if done.PagesDone != 6 || done.PagesTotal != 6 || done.FinishedAt == nil {
+		t.Errorf("pages = %d/%d, finished at %v; want 6/6 and a finish time", done.PagesDone, done.PagesTotal, done.FinishedAt)
+	}
+	item := done.Items[1]
+	if item.URL != "https://example.com/docs" || item.Status != jobSucceeded || item.Failures != 1 {
+		t.Errorf("item = %+v, want the second site with its failure", item)
+	}
+	if job := waitForJob(t, api, item.JobID, jobSucceeded); job.LlmsTxt != "# Example\n" {
+		t.Errorf("job llms_txt = %q, want the site's result", job.LlmsTxt)
+	}
+
+	select {
+	case <-callbacks.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("callback not delivered")
+	}
+	if got := callbacks.sent[0]; got.event != "batch.succeeded" || got.subject != created.ID {
+		t.Errorf("delivered %s about %s, want batch.succeeded about the batch", got.event, got.subject)
+	}
+
+	resp = api.Get(done.ArchiveURL)
+	if resp.Code != http.StatusOK || resp.Header().Get("Content-Type") != "application/zip" {
+		t.Fatalf("archive status = %d, type %q", resp.Code, resp.Header().Get("Content-Type"))
+	}
+	zr, err := zip.NewReader(bytes.NewReader(resp.Body.Bytes()), int64(resp.Body.Len()))
+	if err != nil {
+		t.Fatalf("reading archive: %v", err)
+	}
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rc)
+		_ = rc.Close()
+		files[f.Name] = string(data)
+	}
+	for _, name := range []string{"example.com/llms.txt", "example.com_docs/llms.txt", "other.example/llms.txt"} {
+		if files[name] != "# Example\n" {
+			t.Errorf("archive %s = %q, want the site's llms.txt", name, files[name])
+		}
+	}
+	if !strings.Contains(files["batch.json"], created.ID) {
+		t.Errorf("batch.json = %q, want the batch status", files["batch.json"])
+	}
+}
+
+func TestBatch_SharesCrawlSlots(t *testing.T) {
+	gen := &fakeStreamGenerator{block: make(chan struct{})}
+	h := New(nil, gen, 4)
+	_, api := humatest.New(t)
+	h.Register(api)
+
+	resp := api.Post("/api/batch", map[string]any{"urls": []string{
+		"https://a.example", "https://b.example", "https://c.example", "https://d.example", "https://e.example",
+	}})
+	batch := decodeBatch(t, resp.Body.String())
+	waitForBatch(t, api, batch.ID, func(s BatchStatus) bool { return s.Running == 2 })
+
+	// Batches hold half the slots, so other requests still get a crawl.
+	resp = api.Post("/api/jobs", map[string]any{"url": "https://interactive.example"})
+	job := decodeJob(t, resp.Body.String())
+	waitForJob(t, api, job.ID, jobRunning)
+	if s := decodeBatch(t, api.Get("/api/batch/"+batch.ID).Body.String()); s.Running != 2 || s.Queued != 3 {
+		t.Errorf("running = %d, queued = %d; want the batch held to 2 slots", s.Running, s.Queued)
+	}
+
+	close(gen.block)
+	waitForBatch(t, api, batch.ID, hasStatus(jobSucceeded))
+}
+
+func TestBatch_Cancel(t *testing.T) {
+	h := New(nil, &fakeStreamGenerator{block: make(chan struct{})}, 2)
+	_, api := humatest.New(t)
+	h.Register(api)
+
+	resp := api.Post("/api/batch", map[string]any{"urls": []string{"https://a.example", "https://b.example", "https://c.example"}})
+	batch := decodeBatch(t, resp.Body.String())
+	waitForBatch(t, api, batch.ID, func(s BatchStatus) bool { return s.Running == 1 })
+
+	if resp := api.Delete("/api/batch/" + batch.ID); resp.Code != http.StatusAccepted {
+		t.Fatalf("DELETE status = %d: %s", resp.Code, resp.Body.String())
+	}
+	done := waitForBatch(t, api, batch.ID, hasStatus(jobCancelled))
+	if done.Cancelled != 3 {
+		t.Errorf("cancelled = %d, want every site cancelled", done.Cancelled)
+	}
+
+	if resp := api.Get("/api/batch/NOPE"); resp.Code != http.StatusNotFound {
+		t.Errorf("unknown batch status = %d, want %d", resp.Code, http.StatusNotFound)
+	}
+}
+
+func TestBatch_ScopedToOwner(t *testing.T) {
+	gen := &fakeStreamGenerator{block: make(chan struct{})}
+	defer close(gen.block)
+	h := New(nil, gen, 5)
+	h.Access = &Access{
+		Keys:       []APIKey{{Name: "mine", Key: "k1"}, {Name: "theirs", Key: "k2"}},
+		RequireKey: true,
+		Quotas:     &usecases.Quotas{},
+	}
+	_, api := humatest.New(t)
+	h.Register(api)
+
+	resp := api.Post("/api/batch", "X-API-Key: k1", map[string]any{"urls": []string{"https://example.com"}})
+	if resp.Code != http.StatusAccepted {
+		t.Fatalf("status = %d: %s", resp.Code, resp.Body.String())
+	}
+	created := decodeBatch(t, resp.Body.String())
+	if resp := api.Get("/api/batch/"+created.ID, "X-API-Key: k1"); resp.Code != http.StatusOK {
+		t.Errorf("owner's get status = %d, want 200", resp.Code)
+	}
+	for _, resp := range []*httptest.ResponseRecorder{
+		api.Get("/api/batch/"+created.ID, "X-API-Key: k2"),
+		api.Get("/api/batch/"+created.ID+"/archive", "X-API-Key: k2"),
+		api.Delete("/api/batch/"+created.ID, "X-API-Key: k2"),
+	} {
+		if resp.Code != http.StatusNotFound {
+			t.Errorf("status = %d, want 404 for another client's batch", resp.Code)
+		}
+	}
+	if b, _ := h.batches.get(created.ID, time.Hour); b.snapshot().Status == jobCancelled {
+		t.Error("another client cancelled the batch")
+	}
+}
+
+func TestBatch_InvalidRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		body map[string]any
+		want string
+	}{
+		{"invalid URL", map[string]any{"urls": []string{"https://example.com", "ftp://example.com"}}, "body.urls[1]"},
+		{"duplicate URL", map[string]any{"urls": []string{"https://example.com", "https://example.com"}}, "duplicate URL"},
+		{"no URLs", map[string]any{"urls": []string{}}, "urls"},
+		{"callbacks disabled", map[string]any{"urls": []string{"https://example.com"}, "callback_url": "https://ci.example/hook"}, "not enabled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := New(nil, &fakeStreamGenerator{}, 5)
+			_, api := humatest.New(t)
+			h.Register(api)
+
+			resp := api.Post("/api/batch", tt.body)
+			if resp.Code != http.StatusBadRequest && resp.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("status = %d, want a validation error: %s", resp.Code, resp.Body.String())
+			}
+			if !strings.Contains(resp.Body.String(), tt.want) {
+				t.Errorf("body = %s, want mention of %q", resp.Body.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestArchiveDir(t *testing.T) {
+	used := make(map[string]bool)
+	tests := []struct {
+		url, want string
+	}{
+		{"https://example.com", "example.com"},
+		{"https://example.com/", "example.com-2"},
+		{"https://example.com:8443/docs/api/", "example.com_8443_docs_api"},
+		{"http://../", "_"},
+	}
+	for _, tt := range tests {
+		if got := archiveDir(tt.url, used); got != tt.want {
+			t.Errorf("archiveDir(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+// gatedGenerator holds the generation of each site in gates until its
+// channel is closed.
+type gatedGenerator struct {
+	fakeStreamGenerator
+	gates map[string]chan struct{}
+}
+
+func (g *gatedGenerator) GenerateStream(ctx context.Context, siteURL string, opts domain.Options, events chan<- domain.ProgressEvent) {
+	if gate, ok := g.gates[siteURL]; ok {
+		select {
+		case <-gate:
+		case <-ctx.Done():
+		}
+	}
+	g.fakeStreamGenerator.GenerateStream(ctx, siteURL, opts, events)
+}
+
+func TestBatch_WaitsForConcurrentJobQuota(t *testing.T) {
+	gen := &gatedGenerator{gates: map[string]chan struct{}{
+		"https://slow.example": make(chan struct{}),
+		"https://example.com":  make(chan struct{}),
+	}}
+	h := New(nil, gen, 5)
+	h.Access = &Access{Anonymous: usecases.Limits{ConcurrentJobs: 2}, Quotas: &usecases.Quotas{}}
+	_, api := humatest.New(t)
+	h.Register(api)
+
+	job := decodeJob(t, api.Post("/api/jobs", map[string]any{"url": "https://slow.example"}).Body.String())
+	resp := api.Post("/api/batch", map[string]any{"urls": []string{"https://example.com", "https://other.example"}})
+	if resp.Code != http.StatusAccepted {
+		t.Fatalf("status = %d: %s", resp.Code, resp.Body.String())
+	}
+	created := decodeBatch(t, resp.Body.String())
+
+	// The first site and the job hold both of the client's job slots, so the
+	// second site waits rather than failing.
+	time.Sleep(50 * time.Millisecond)
+	if status := decodeBatch(t, api.Get("/api/batch/"+created.ID).Body.String()); status.Items[1].Status != jobQueued {
+		t.Fatalf("second site = %+v while the quota is taken, want it queued", status.Items[1])
+	}
+
+	close(gen.gates["https://slow.example"])
+	waitForJob(t, api, job.ID, jobSucceeded)
+	waitForBatch(t, api, created.ID, func(s BatchStatus) bool { return s.Items[1].Status == jobSucceeded })
+	close(gen.gates["https://example.com"])
+	if done := waitForBatch(t, api, created.ID, hasStatus(jobSucceeded)); done.Succeeded != 2 {
+		t.Errorf("batch = %+v, want both sites succeeded", done)
+	}
+}
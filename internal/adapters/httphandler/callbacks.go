@@ -0,0 +1,149 @@
+package httphandler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+	"github.com/adsouza/llms.txt-generator/internal/usecases"
+)
+
+// Callbacks delivers job outcomes to the callback URLs given by clients.
+type Callbacks interface {
+	// Check reports whether callbackURL may be contacted.
+	Check(ctx context.Context, callbackURL string) error
+	// Deliver posts payload to callbackURL, retrying failed attempts.
+	Deliver(ctx context.Context, callbackURL, event, subject string, payload any) error
+	// Deliveries returns the logged deliveries about subject, or all of
+	// them if subject is empty, newest first. Each records the owner in the
+	// context given to Deliver.
+	Deliveries(subject string) []domain.Delivery
+}
+
+// callbackCheckKey carries the callback URL check to GenerateInput.Resolve.
+type callbackCheckKey struct{}
+
+// errCallbacksDisabled is reported for a callback_url or webhook_url when the
+// server has no Callbacks.
+var errCallbacksDisabled = errors.New("callbacks and webhooks are not enabled on this server")
+
+// acceptCallbacks lets the operation's requests carry a callback_url or
+// webhook_url, checked before the operation starts.
+func (h *Handler) acceptCallbacks(ctx huma.Context, next func(huma.Context)) {
+	check := func(context.Context, string) error { return errCallbacksDisabled }
+	if h.Callbacks != nil {
+		check = h.Callbacks.Check
+	}
+	next(huma.WithValue(ctx, callbackCheckKey{}, check))
+}
+
+// checkCallback validates the callback or webhook URL at location with the
+// check acceptCallbacks put in ctx. Operations without one do not take
+// callbacks.
+func checkCallback(ctx huma.Context, location, callbackURL string) error {
+	check, ok := ctx.Context().Value(callbackCheckKey{}).(func(context.Context, string) error)
+	if !ok {
+		return badRequest(location, "callback_url is only accepted by /api/jobs, /api/batch and /api/generate-stream", callbackURL)
+	}
+	if err := checkSiteURL(location, callbackURL); err != nil {
+		return err
+	}
+	if err := check(ctx.Context(), callbackURL); err != nil {
+		return badRequest(location, "invalid callback URL: "+err.Error(), callbackURL)
+	}
+	return nil
+}
+
+// notifyCallback posts a finished job's status to its callback URL. The
+// delivery outlives the job's context, so cancelled jobs are reported too.
+func (h *Handler) notifyCallback(j *job) {
+	if j.callbackURL == "" || h.Callbacks == nil {
+		return
+	}
+	status := j.snapshot()
+	_ = h.Callbacks.Deliver(usecases.WithOwner(context.Background(), j.owner), j.callbackURL, "job."+status.Status, j.id, status)
+}
+
+// Delivery describes the delivery of a notification to a callback URL or
+// webhook.
+type Delivery struct {
+	ID         string     `json:"id" doc:"Delivery ID, sent as X-Webhook-Delivery with every attempt"`
+	Event      string     `json:"event" doc:"Event notified, e.g. job.succeeded or llms_txt.changed"`
+	Subject    string     `json:"subject" doc:"ID of the job or schedule notified about"`
+	URL        string     `json:"url" doc:"URL notified, without its query or credentials"`
+	CreatedAt  time.Time  `json:"created_at" doc:"When the delivery started"`
+	FinishedAt *time.Time `json:"finished_at,omitempty" doc:"When the last attempt finished, omitted while retrying"`
+	Attempts   int        `json:"attempts" doc:"Attempts made"`
+	Status     int        `json:"status,omitempty" doc:"HTTP status of the last attempt, omitted if no response was received"`
+	Error      string     `json:"error,omitempty" doc:"Why the last attempt failed"`
+	Delivered  bool       `json:"delivered" doc:"Whether the notification was accepted"`
+}
+
+// DeliveriesInput filters the delivery log.
+type DeliveriesInput struct {
+	Subject string `query:"subject" doc:"Only deliveries about this job or schedule ID"`
+}
+
+// DeliveriesOutput is the Huma response for the delivery log.
+type DeliveriesOutput struct {
+	Body struct {
+		Deliveries []Delivery `json:"deliveries" doc:"Recent deliveries, newest first"`
+	}
+}
+
+func (h *Handler) registerCallbacks(api huma.API) {
+	huma.Register(api, huma.Operation{
+		OperationID: "list-deliveries",
+		Method:      http.MethodGet,
+		Path:        "/api/deliveries",
+		Summary:     "List recent callback and webhook deliveries",
+		Tags:        []string{"Jobs"},
+	}, h.handleListDeliveries)
+}
+
+// handleListDeliveries lists the deliveries about the caller's jobs,
+// batches and schedules.
+func (h *Handler) handleListDeliveries(ctx context.Context, input *DeliveriesInput) (*DeliveriesOutput, error) {
+	owner := usecases.Owner(ctx)
+	out := &DeliveriesOutput{}
+	out.Body.Deliveries = []Delivery{}
+	for _, d := range h.Callbacks.Deliveries(input.Subject) {
+		if owner != "" && d.Owner != owner {
+			continue
+		}
+		delivery := Delivery{
+			ID:        d.ID,
+			Event:     d.Event,
+			Subject:   d.Subject,
+			URL:       redactURL(d.URL),
+			CreatedAt: d.CreatedAt,
+			Attempts:  d.Attempts,
+			Status:    d.Status,
+			Error:     d.Error,
+			Delivered: d.Delivered,
+		}
+		if !d.FinishedAt.IsZero() {
+			delivery.FinishedAt = &d.FinishedAt
+		}
+		out.Body.Deliveries = append(out.Body.Deliveries, delivery)
+	}
+	return out, nil
+}
+
+// redactURL strips the credentials, query and fragment, which may carry
+// tokens, from a callback or webhook URL before it is shown.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	u.User = nil
+	u.RawQuery, u.ForceQuery = "", false
+	u.Fragment, u.RawFragment = "", ""
+	return u.String()
+}
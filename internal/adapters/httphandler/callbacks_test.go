@@ -0,0 +1,181 @@
+package httphandler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2/humatest"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+	"github.com/adsouza/llms.txt-generator/internal/usecases"
+)
+
+// delivered is a notification passed to fakeCallbacks.
+type delivered struct {
+	url, event, subject, owner string
+	payload                    any
+}
+
+// fakeCallbacks records deliveries and refuses hosts ending in ".internal".
+// Its delivery log is log, or a single delivery if log is nil.
+type fakeCallbacks struct {
+	mu   sync.Mutex
+	sent []delivered
+	done chan struct{}
+	log  []domain.Delivery
+}
+
+func (f *fakeCallbacks) Check(_ context.Context, callbackURL string) error {
+	if strings.Contains(callbackURL, ".internal") {
+		return fmt.Errorf("metadata.internal: %w", domain.ErrBlockedAddress)
+	}
+	return nil
+}
+
+func (f *fakeCallbacks) Deliver(ctx context.Context, callbackURL, event, subject string, payload any) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, delivered{callbackURL, event, subject, usecases.Owner(ctx), payload})
+	close(f.done)
+	return nil
+}
+
+func (f *fakeCallbacks) Deliveries(subject string) []domain.Delivery {
+	if f.log != nil {
+		return f.log
+	}
+	return []domain.Delivery{{ID: "DELIVERY", Event: "job.succeeded", Subject: subject, URL: "https://ci.example/hook", Attempts: 2, Status: 200, Delivered: true, FinishedAt: generatedAt}}
+}
+
+func newCallbackAPI(t *testing.T) (humatest.TestAPI, *fakeCallbacks) {
+	callbacks := &fakeCallbacks{done: make(chan struct{})}
+	h := New(&fakeGenerator{}, &fakeStreamGenerator{}, 5)
+	h.Callbacks = callbacks
+	_, api := humatest.New(t)
+	h.Register(api)
+	return api, callbacks
+}
+
+func TestCallbacks_JobFinished(t *testing.T) {
+	api, callbacks := newCallbackAPI(t)
+
+	resp := api.Post("/api/jobs", map[string]any{"url": "https://example.com", "callback_url": "https://ci.example/hook"})
+	if resp.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want %d: %s", resp.Code, http.StatusAccepted, resp.Body.String())
+	}
+	created := decodeJob(t, resp.Body.String())
+	if created.CallbackURL != "https://ci.example/hook" {
+		t.Errorf("callback_url = %q", created.CallbackURL)
+	}
+
+	select {
+	case <-callbacks.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("callback not delivered")
+	}
+	got := callbacks.sent[0]
+	if got.url != "https://ci.example/hook" || got.event != "job.succeeded" || got.subject != created.ID {
+		t.Errorf("delivered %s %s about %s", got.event, got.url, got.subject)
+	}
+	if status, ok := got.payload.(JobStatus); !ok || status.LlmsTxt != "# Example\n" || status.FinishedAt == nil {
+		t.Errorf("payload = %+v, want the finished job", got.payload)
+	}
+}
+
+func TestCallbacks_InvalidURL(t *testing.T) {
+	tests := []struct {
+		name, path, callback, want string
+	}{
+		{"blocked address", "/api/jobs", "http://metadata.internal/hook", "not publicly routable"},
+		{"not http", "/api/jobs", "ftp://ci.example/hook", "invalid URL"},
+		{"blocked address on stream", "/api/generate-stream", "http://metadata.internal/hook", "not publicly routable"},
+		{"synchronous endpoint", "/api/generate", "https://ci.example/hook", "only accepted by"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api, _ := newCallbackAPI(t)
+			resp := api.Post(tt.path, map[string]any{"url": "https://example.com", "callback_url": tt.callback})
+			if resp.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want %d: %s", resp.Code, http.StatusBadRequest, resp.Body.String())
+			}
+			body := resp.Body.String()
+			if !strings.Contains(body, "body.callback_url") || !strings.Contains(body, tt.want) {
+				t.Errorf("body = %s, want body.callback_url and %q", body, tt.want)
+			}
+		})
+	}
+}
+
+func TestCallbacks_Disabled(t *testing.T) {
+	h := New(nil, &fakeStreamGenerator{}, 5)
+	_, api := humatest.New(t)
+	h.Register(api)
+
+	resp := api.Post("/api/jobs", map[string]any{"url": "https://example.com", "callback_url": "https://ci.example/hook"})
+	if resp.Code != http.StatusBadRequest || !strings.Contains(resp.Body.String(), "not enabled") {
+		t.Errorf("status = %d, body = %s; want 400 saying callbacks are not enabled", resp.Code, resp.Body.String())
+	}
+}
+
+func TestCallbacks_Deliveries(t *testing.T) {
+	api, _ := newCallbackAPI(t)
+
+	resp := api.Get("/api/deliveries?subject=JOB")
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.Code, resp.Body.String())
+	}
+	var body DeliveriesOutput
+	if err := json.NewDecoder(resp.Body).Decode(&body.Body); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if len(body.Body.Deliveries) != 1 {
+		t.Fatalf("deliveries = %+v", body.Body.Deliveries)
+	}
+	d := body.Body.Deliveries[0]
+	if d.Subject != "JOB" || !d.Delivered || d.Attempts != 2 || d.FinishedAt == nil {
+		t.Errorf("delivery = %+v", d)
+	}
+}
+
+func TestCallbacks_RedactedAndScopedToOwner(t *testing.T) {
+	callbacks := &fakeCallbacks{done: make(chan struct{}), log: []domain.Delivery{
+		{ID: "MINE", Subject: "JOB1", Owner: "key:mine", URL: "https://user:pw@ci.example/hook?token=abc"},
+		{ID: "THEIRS", Subject: "JOB2", Owner: "key:theirs", URL: "https://ci.example/other"},
+	}}
+	h := New(&fakeGenerator{}, &fakeStreamGenerator{}, 5)
+	h.Callbacks = callbacks
+	h.Access = &Access{
+		Keys:       []APIKey{{Name: "mine", Key: "k1"}, {Name: "theirs", Key: "k2"}},
+		RequireKey: true,
+		Quotas:     &usecases.Quotas{},
+	}
+	_, api := humatest.New(t)
+	h.Register(api)
+
+	resp := api.Post("/api/jobs", "X-API-Key: k1", map[string]any{"url": "https://example.com", "callback_url": "https://ci.example/hook?token=abc"})
+	if created := decodeJob(t, resp.Body.String()); created.CallbackURL != "https://ci.example/hook" {
+		t.Errorf("callback_url = %q, want it without the query", created.CallbackURL)
+	}
+	select {
+	case <-callbacks.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("callback not delivered")
+	}
+	if got := callbacks.sent[0]; got.url != "https://ci.example/hook?token=abc" || got.owner != "key:mine" {
+		t.Errorf("delivered to %s for %q, want the full URL for key:mine", got.url, got.owner)
+	}
+
+	var body DeliveriesOutput
+	if err := json.NewDecoder(api.Get("/api/deliveries", "X-API-Key: k1").Body).Decode(&body.Body); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if d := body.Body.Deliveries; len(d) != 1 || d[0].ID != "MINE" || d[0].URL != "https://ci.example/hook" {
+		t.Errorf("deliveries = %+v, want only the caller's, redacted", d)
+	}
+}
@@ -0,0 +1,99 @@
+package httphandler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/adsouza/llms.txt-generator/internal/adapters/sitediff"
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+	"github.com/adsouza/llms.txt-generator/internal/usecases"
+)
+
+// maxDiffLength bounds each llms.txt file submitted for comparison.
+const maxDiffLength = 1 << 20
+
+// DiffRequest compares an earlier llms.txt with either a newer one or a fresh
+// generation of the site.
+type DiffRequest struct {
+	Previous     string `json:"previous" doc:"Earlier llms.txt content" minLength:"1" maxLength:"1048576"`
+	Current      string `json:"current,omitempty" doc:"Newer llms.txt content; omit it and set url to compare with a fresh generation" maxLength:"1048576"`
+	URL          string `json:"url,omitempty" doc:"Website URL to generate the newer llms.txt for, instead of current"`
+	Language     string `json:"language,omitempty" doc:"Keep only pages in this locale when generating from url" maxLength:"35"`
+	ForceRefresh bool   `json:"force_refresh,omitempty" doc:"Crawl the site even if a cached result is available"`
+}
+
+// DiffInput is the Huma request body for the diff endpoint.
+type DiffInput struct {
+	Body DiffRequest
+}
+
+// Resolve implements huma.Resolver, requiring exactly one of current and url.
+func (i *DiffInput) Resolve(huma.Context) []error {
+	r := i.Body
+	switch {
+	case r.Current == "" && r.URL == "":
+		return []error{badRequest("body.current", "either current or url is required", nil)}
+	case r.Current != "" && r.URL != "":
+		return []error{badRequest("body.url", "url cannot be combined with current", r.URL)}
+	case r.URL != "":
+		if err := checkSiteURL("body.url", r.URL); err != nil {
+			return []error{err}
+		}
+	}
+	return nil
+}
+
+// DiffOutput is the Huma response body for the diff endpoint.
+type DiffOutput struct {
+	Body struct {
+		Changed bool                 `json:"changed" doc:"Whether the two versions list different pages or text"`
+		Changes sitediff.SiteChanges `json:"changes" doc:"Structured changes"`
+		Unified string               `json:"unified" doc:"Unified diff of the two files, empty if they are identical"`
+		Current string               `json:"current,omitempty" doc:"Generated llms.txt, when url was given"`
+	}
+}
+
+func (h *Handler) registerDiff(api huma.API) {
+	huma.Register(api, huma.Operation{
+		OperationID:  "diff-llmstxt",
+		Method:       http.MethodPost,
+		Path:         "/api/diff",
+		Summary:      "Compare two versions of a site's llms.txt",
+		Description:  "Compares previous with current, or with a fresh generation of url, listing pages added, removed, moved between sections and edited, along with a unified diff.",
+		Tags:         []string{"Generator"},
+		MaxBodyBytes: 3 * maxDiffLength,
+	}, h.handleDiff)
+}
+
+func (h *Handler) handleDiff(ctx context.Context, input *DiffInput) (*DiffOutput, error) {
+	r := input.Body
+	current := r.Current
+	if r.URL != "" {
+		opts := domain.Options{Language: r.Language, ForceRefresh: r.ForceRefresh}
+		result, err := h.generate(ctx, r.URL, opts)
+		if err != nil {
+			return nil, err
+		}
+		current = result.LlmsTxt
+	}
+
+	diff, err := h.Differ.Diff(r.Previous, current)
+	if errors.Is(err, usecases.ErrInvalidLlmsTxt) {
+		return nil, huma.Error400BadRequest(err.Error())
+	}
+	if err != nil {
+		return nil, huma.Error500InternalServerError("diff failed: " + err.Error())
+	}
+
+	out := &DiffOutput{}
+	out.Body.Changed = diff.Unified != ""
+	out.Body.Changes = sitediff.From(diff.Changes)
+	out.Body.Unified = diff.Unified
+	if r.URL != "" {
+		out.Body.Current = current
+	}
+	return out, nil
+}
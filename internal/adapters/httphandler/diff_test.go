@@ -0,0 +1,118 @@
+package httphandler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2/humatest"
+
+	"github.com/adsouza/llms.txt-generator/internal/adapters/formatter"
+	"github.com/adsouza/llms.txt-generator/internal/adapters/sitediff"
+	"github.com/adsouza/llms.txt-generator/internal/usecases"
+)
+
+func newDiffAPI(t *testing.T, gen *fakeGenerator) humatest.TestAPI {
+	h := New(gen, nil, 5)
+	h.Differ = &usecases.DiffService{Parser: formatter.Parser{}, TextDiffer: formatter.UnifiedDiff{}}
+	_, api := humatest.New(t)
+	h.Register(api)
+	return api
+}
+
+type diffBody struct {
+	Changed bool                 `json:"changed"`
+	Changes sitediff.SiteChanges `json:"changes"`
+	Unified string               `json:"unified"`
+	Current string               `json:"current"`
+}
+
+func decodeDiff(t *testing.T, resp *httptest.ResponseRecorder) diffBody {
+	t.Helper()
+	var body diffBody
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	return body
+}
+
+func TestHandleDiff_Texts(t *testing.T) {
+	api := newDiffAPI(t, &fakeGenerator{})
+
+	req := map[string]string{
+		"previous": "# Site\n\n## Docs\n\n- [A](https://example.com/a)\n- [B](https://example.com/b)\n",
+		"current":  "# Site\n\n## Docs\n\n- [A](https://example.com/a): About A\n- [C](https://example.com/c)\n",
+	}
+	resp := api.Post("/api/diff", req)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body: %s", resp.Code, http.StatusOK, resp.Body.String())
+	}
+
+	body := decodeDiff(t, resp)
+	if !body.Changed {
+		t.Error("changed = false, want true")
+	}
+	if len(body.Changes.Added) != 1 || body.Changes.Added[0].URL != "https://example.com/c" {
+		t.Errorf("added = %+v, want the C page", body.Changes.Added)
+	}
+	if len(body.Changes.Removed) != 1 || body.Changes.Removed[0].URL != "https://example.com/b" {
+		t.Errorf("removed = %+v, want the B page", body.Changes.Removed)
+	}
+	if len(body.Changes.Edited) != 1 || body.Changes.Edited[0].Description == nil || body.Changes.Edited[0].Description.New != "About A" {
+		t.Errorf("edited = %+v, want A's new description", body.Changes.Edited)
+	}
+	if !strings.Contains(body.Unified, "+- [C](https://example.com/c)\n") {
+		t.Errorf("unified diff does not add C:\n%s", body.Unified)
+	}
+	if body.Current != "" {
+		t.Errorf("current = %q, want it omitted", body.Current)
+	}
+}
+
+func TestHandleDiff_URL(t *testing.T) {
+	gen := &fakeGenerator{result: "# Site\n\n## Docs\n\n- [A](https://example.com/a)\n"}
+	api := newDiffAPI(t, gen)
+
+	resp := api.Post("/api/diff", map[string]any{
+		"previous":      "# Site\n\n## Docs\n\n- [A](https://example.com/a)\n",
+		"url":           "https://example.com",
+		"force_refresh": true,
+	})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body: %s", resp.Code, http.StatusOK, resp.Body.String())
+	}
+
+	body := decodeDiff(t, resp)
+	if body.Changed || body.Unified != "" {
+		t.Errorf("changed = %v, unified = %q; want no changes", body.Changed, body.Unified)
+	}
+	if body.Current != gen.result {
+		t.Errorf("current = %q, want the generated llms.txt", body.Current)
+	}
+	if !gen.opts.ForceRefresh {
+		t.Error("force_refresh was not passed to the generator")
+	}
+}
+
+func TestHandleDiff_InvalidRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		body map[string]string
+	}{
+		{"neither current nor url", map[string]string{"previous": "# A\n"}},
+		{"both current and url", map[string]string{"previous": "# A\n", "current": "# B\n", "url": "https://example.com"}},
+		{"bad url", map[string]string{"previous": "# A\n", "url": "ftp://example.com"}},
+		{"unparseable previous", map[string]string{"previous": "no heading", "current": "# B\n"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newDiffAPI(t, &fakeGenerator{})
+			resp := api.Post("/api/diff", tt.body)
+			if resp.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d; body: %s", resp.Code, http.StatusBadRequest, resp.Body.String())
+			}
+		})
+	}
+}
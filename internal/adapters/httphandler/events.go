@@ -0,0 +1,213 @@
+package httphandler
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/sse"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+)
+
+// JobEvent is the first event of a generation stream. It names the job, whose
+// event stream a client can reconnect to if its connection drops.
+type JobEvent struct {
+	ID        string `json:"id" doc:"Job ID"`
+	EventsURL string `json:"events_url" doc:"URL of the job's event stream, which accepts Last-Event-ID to resume"`
+}
+
+// QueuedEvent reports that the generation is waiting for a crawl slot. It is
+// sent again whenever the generation moves up the queue.
+type QueuedEvent struct {
+	Position int `json:"position" doc:"Place in the queue, 1 being next"`
+}
+
+// DiscoveredEvent lists the pages that will be fetched.
+type DiscoveredEvent struct {
+	URLs  []string `json:"urls" doc:"Pages to fetch"`
+	Total int      `json:"total" doc:"Number of pages to fetch"`
+}
+
+// ProgressEvent reports that a page has been processed.
+type ProgressEvent struct {
+	URL   string `json:"url" doc:"Page processed"`
+	Done  int    `json:"done" doc:"Pages processed so far"`
+	Total int    `json:"total" doc:"Pages to fetch"`
+}
+
+// RetryEvent reports a failed attempt to fetch a page that will be retried.
+type RetryEvent struct {
+	URL     string `json:"url" doc:"Page being fetched"`
+	Attempt int    `json:"attempt" doc:"Number of the attempt that failed"`
+	Status  int    `json:"status,omitempty" doc:"HTTP status code, omitted if no response was received"`
+	Reason  string `json:"reason" doc:"Why the attempt failed"`
+}
+
+// PageErrorEvent reports a page that could not be fetched.
+type PageErrorEvent struct {
+	URL    string `json:"url" doc:"Page URL"`
+	Status int    `json:"status,omitempty" doc:"HTTP status code, omitted if no response was received"`
+	Reason string `json:"reason" doc:"Why the page could not be fetched"`
+	Done   int    `json:"done" doc:"Pages processed so far"`
+	Total  int    `json:"total" doc:"Pages to fetch"`
+}
+
+// DoneEvent carries the result of a successful generation.
+type DoneEvent struct {
+	LlmsTxt     string            `json:"llms_txt" doc:"Generated llms.txt content"`
+	LlmsFullTxt string            `json:"llms_full_txt,omitempty" doc:"Generated llms-full.txt content, with the full text of documents that provide it"`
+	Locales     map[string]string `json:"locales,omitempty" doc:"llms.txt content for each locale, when per_locale is set"`
+	Failures    []PageFailure     `json:"failures" doc:"Pages that could not be fetched"`
+	GeneratedAt time.Time         `json:"generated_at" doc:"When the site was crawled, earlier than now if the result was cached"`
+	HistoryID   string            `json:"history_id,omitempty" doc:"ID of the generation in the history, when one is kept"`
+}
+
+// ErrorEvent reports that the generation failed.
+type ErrorEvent struct {
+	Error    string        `json:"error" doc:"Why the generation failed"`
+	Failures []PageFailure `json:"failures,omitempty" doc:"Pages that could not be fetched, when too many failed"`
+}
+
+// GapEvent starts a resumed stream when events after Last-Event-ID are no
+// longer kept; the job's status still reflects them.
+type GapEvent struct {
+	Missed int `json:"missed" doc:"Events dropped before the next one"`
+}
+
+// jobEventTypes maps the event names of a job's stream to their data.
+var jobEventTypes = map[string]any{
+	"queued":     QueuedEvent{},
+	"discovered": DiscoveredEvent{},
+	"progress":   ProgressEvent{},
+	"retry":      RetryEvent{},
+	"page_error": PageErrorEvent{},
+	"done":       DoneEvent{},
+	"error":      ErrorEvent{},
+	"gap":        GapEvent{},
+}
+
+// generateEventTypes adds the job event that starts a generation stream.
+var generateEventTypes = func() map[string]any {
+	types := map[string]any{"job": JobEvent{}}
+	for name, data := range jobEventTypes {
+		types[name] = data
+	}
+	return types
+}()
+
+// eventData converts a progress event to the data of its SSE message.
+func eventData(ev domain.ProgressEvent) any {
+	switch ev.Type {
+	case "queued":
+		return QueuedEvent{Position: ev.Position}
+	case "discovered":
+		return DiscoveredEvent{URLs: ev.URLs, Total: ev.Total}
+	case "progress":
+		return ProgressEvent{URL: ev.CurrentURL, Done: ev.Done, Total: ev.Total}
+	case "retry":
+		return RetryEvent{URL: ev.CurrentURL, Attempt: ev.Attempt, Status: ev.Status, Reason: ev.Error}
+	case "page_error":
+		return PageErrorEvent{URL: ev.CurrentURL, Status: ev.Status, Reason: ev.Error, Done: ev.Done, Total: ev.Total}
+	case "done":
+		return DoneEvent{
+			LlmsTxt:     ev.Result,
+			LlmsFullTxt: ev.FullResult,
+			Locales:     ev.Locales,
+			Failures:    pageFailures(ev.Failures),
+			GeneratedAt: ev.GeneratedAt,
+			HistoryID:   ev.HistoryID,
+		}
+	case "error":
+		out := ErrorEvent{Error: ev.Error}
+		if len(ev.Failures) > 0 {
+			out.Failures = pageFailures(ev.Failures)
+		}
+		return out
+	}
+	return nil
+}
+
+// streamJob sends a job's events until it finishes or the client disconnects,
+// starting after lastEventID. Each event carries its ID, so a client that
+// reconnects with a Last-Event-ID header resumes after the last one it saw,
+// or with a gap event if some of those that followed have been dropped.
+func (h *Handler) streamJob(ctx context.Context, j *job, lastEventID string, send sse.Sender) {
+	sent := 0
+	if id, err := strconv.Atoi(lastEventID); err == nil && id > 0 {
+		sent = id
+	}
+	heartbeat := time.NewTicker(h.heartbeatInterval())
+	defer heartbeat.Stop()
+
+	for {
+		events, missed, finished, changed := j.eventsSince(sent)
+		if missed > 0 {
+			if err := send(sse.Message{ID: sent + missed, Data: GapEvent{Missed: missed}}); err != nil {
+				return
+			}
+			sent += missed
+		}
+		for _, ev := range events {
+			data := eventData(ev)
+			if data == nil {
+				continue
+			}
+			if err := send(sse.Message{ID: ev.ID, Data: data}); err != nil {
+				return
+			}
+		}
+		sent += len(events)
+		if finished {
+			return
+		}
+		select {
+		case <-changed:
+		case <-heartbeat.C:
+			if err := sendHeartbeat(ctx); err != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+type streamWriterKey struct{}
+
+// exposeStreamWriter is an operation middleware that passes the response
+// writer on to an event stream's handler, which can then write comments that
+// sse.Sender cannot.
+func exposeStreamWriter(ctx huma.Context, next func(huma.Context)) {
+	next(huma.WithValue(ctx, streamWriterKey{}, ctx.BodyWriter()))
+}
+
+// sendHeartbeat writes a ": ping" comment to the stream in ctx so that idle
+// proxies keep the connection open. Clients ignore comments, so it takes no
+// event ID and reaches no event handler.
+func sendHeartbeat(ctx context.Context) error {
+	w, ok := ctx.Value(streamWriterKey{}).(io.Writer)
+	if !ok {
+		return nil
+	}
+	if _, err := io.WriteString(w, ": ping\n\n"); err != nil {
+		return err
+	}
+	if rw, ok := w.(http.ResponseWriter); ok {
+		return http.NewResponseController(rw).Flush()
+	}
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+func (h *Handler) heartbeatInterval() time.Duration {
+	if h.HeartbeatInterval > 0 {
+		return h.HeartbeatInterval
+	}
+	return defaultHeartbeatInterval
+}
@@ -2,41 +2,200 @@ package httphandler
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
+	"time"
 
 	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/sse"
 
 	"github.com/adsouza/llms.txt-generator/internal/domain"
 	"github.com/adsouza/llms.txt-generator/internal/usecases"
 )
 
-// GenerateInput is the Huma request body for the generate endpoint.
+// GenerateRequest holds the fields shared by the generate endpoints.
+type GenerateRequest struct {
+	URL           string       `json:"url" doc:"Website URL to generate llms.txt for" minLength:"1"`
+	MaxErrorRatio float64      `json:"max_error_ratio,omitempty" doc:"Strict mode: fail if the fraction of pages that could not be fetched exceeds this ratio (0 disables)" minimum:"0" maximum:"1"`
+	Language      string       `json:"language,omitempty" doc:"Keep only pages in this locale, e.g. en or pt-BR (defaults to the homepage's locale)" maxLength:"35"`
+	PerLocale     bool         `json:"per_locale,omitempty" doc:"Also generate one llms.txt per locale"`
+	Auth          *AuthRequest `json:"auth,omitempty" doc:"Credentials for a site behind a login, sent only to the site's own host"`
+	ForceRefresh  bool         `json:"force_refresh,omitempty" doc:"Crawl the site even if a cached result is available"`
+	CallbackURL   string       `json:"callback_url,omitempty" doc:"URL that receives the job's final status when it finishes, signed with X-Webhook-Signature (jobs and streams only)"`
+}
+
+// AuthRequest holds credentials for crawling a site behind a login.
+type AuthRequest struct {
+	Headers  map[string]string `json:"headers,omitempty" doc:"Extra request headers, e.g. {\"Authorization\": \"Bearer ...\"}"`
+	Cookies  map[string]string `json:"cookies,omitempty" doc:"Session cookies by name"`
+	Username string            `json:"username,omitempty" doc:"HTTP basic auth username"`
+	Password string            `json:"password,omitempty" doc:"HTTP basic auth password"`
+}
+
+// reservedHeaders are managed by the HTTP client and cannot be overridden.
+var reservedHeaders = map[string]bool{
+	"Host":              true,
+	"Content-Length":    true,
+	"Transfer-Encoding": true,
+	"Connection":        true,
+	"Cookie":            true,
+}
+
+func (r GenerateRequest) options() domain.Options {
+	opts := domain.Options{MaxErrorRatio: r.MaxErrorRatio, Language: r.Language, PerLocale: r.PerLocale, ForceRefresh: r.ForceRefresh}
+	if r.Auth != nil {
+		opts.Credentials = domain.Credentials{
+			Headers:  r.Auth.Headers,
+			Cookies:  r.Auth.Cookies,
+			Username: r.Auth.Username,
+			Password: r.Auth.Password,
+		}
+	}
+	return opts
+}
+
+// GenerateInput is the Huma request body for the generate endpoints.
 type GenerateInput struct {
-	Body struct {
-		URL string `json:"url" doc:"Website URL to generate llms.txt for" minLength:"1"`
+	Body GenerateRequest
+}
+
+// Resolve implements huma.Resolver, checking the fields that cannot be
+// expressed in the schema before any work starts. Its messages never include
+// credential values.
+func (i *GenerateInput) Resolve(ctx huma.Context) []error {
+	r := i.Body
+	if err := checkSiteURL("body.url", r.URL); err != nil {
+		return []error{err}
+	}
+	if r.CallbackURL != "" {
+		if err := checkCallback(ctx, "body.callback_url", r.CallbackURL); err != nil {
+			return []error{err}
+		}
 	}
+	if r.Auth != nil {
+		for name := range r.Auth.Headers {
+			if name == "" || strings.ContainsAny(name, " \t\r\n:") {
+				return []error{badRequest("body.auth.headers", fmt.Sprintf("invalid auth header name %q", name), nil)}
+			}
+			if reservedHeaders[http.CanonicalHeaderKey(name)] {
+				return []error{badRequest("body.auth.headers", fmt.Sprintf("auth header %q cannot be set; use cookies for Cookie", name), nil)}
+			}
+		}
+	}
+	return nil
+}
+
+// checkSiteURL reports an error at location unless raw is an absolute http or
+// https URL.
+func checkSiteURL(location, raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return badRequest(location, "invalid URL: must be a valid http or https URL", raw)
+	}
+	return nil
+}
+
+// requestError is a Problem detail for a field that failed validation, which
+// Huma reports with a 400 status rather than its default 422.
+type requestError struct {
+	detail *huma.ErrorDetail
+}
+
+func badRequest(location, msg string, value any) *requestError {
+	return &requestError{&huma.ErrorDetail{Location: location, Message: msg, Value: value}}
+}
+
+func (e *requestError) Error() string { return e.detail.Error() }
+
+// ErrorDetail implements huma.ErrorDetailer.
+func (e *requestError) ErrorDetail() *huma.ErrorDetail { return e.detail }
+
+// GetStatus implements huma.StatusError.
+func (e *requestError) GetStatus() int { return http.StatusBadRequest }
+
+// PageFailure describes a page that could not be fetched.
+type PageFailure struct {
+	URL    string `json:"url" doc:"Page URL"`
+	Status int    `json:"status,omitempty" doc:"HTTP status code, omitted if no response was received"`
+	Reason string `json:"reason" doc:"Why the page could not be fetched"`
 }
 
 // GenerateOutput is the Huma response body for the generate endpoint.
 type GenerateOutput struct {
 	Body struct {
-		LlmsTxt string `json:"llms_txt" doc:"Generated llms.txt content"`
+		LlmsTxt     string            `json:"llms_txt" doc:"Generated llms.txt content"`
+		LlmsFullTxt string            `json:"llms_full_txt,omitempty" doc:"Generated llms-full.txt content, with the full text of documents that provide it"`
+		Locales     map[string]string `json:"locales,omitempty" doc:"llms.txt content for each locale, when per_locale is set"`
+		Failures    []PageFailure     `json:"failures" doc:"Pages that could not be fetched"`
+		GeneratedAt time.Time         `json:"generated_at" doc:"When the site was crawled, earlier than now if the result was cached"`
+		HistoryID   string            `json:"history_id,omitempty" doc:"ID of the generation in the history, when one is kept"`
 	}
 }
 
 // StreamGenerator can generate llms.txt with progress events.
 type StreamGenerator interface {
-	GenerateStream(ctx context.Context, siteURL string, events chan<- domain.ProgressEvent)
+	GenerateStream(ctx context.Context, siteURL string, opts domain.Options, events chan<- domain.ProgressEvent)
 }
 
 // Handler adapts HTTP requests to the usecases.Generator.
 type Handler struct {
 	Generator       usecases.Generator
 	StreamGenerator StreamGenerator
-	sem             chan struct{}
+
+	// JobTTL is how long finished jobs, and their events, stay available.
+	// Defaults to 1h.
+	JobTTL time.Duration
+
+	// MaxJobs is how many jobs, and as many batches, are kept; beyond it,
+	// those that finished first are dropped before their JobTTL is up.
+	// Defaults to 1000.
+	MaxJobs int
+
+	// HeartbeatInterval is how often a heartbeat comment is written to quiet
+	// event streams. Defaults to 15s.
+	HeartbeatInterval time.Duration
+
+	// Differ compares llms.txt files for the diff endpoint, which is only
+	// registered when it is set.
+	Differ usecases.Differ
+
+	// History serves past generations and publishes the latest for each host
+	// under /sites/{host}/, whose endpoints are only registered when it is set.
+	History usecases.History
+
+	// Schedules registers sites for periodic regeneration, whose endpoints
+	// are only registered when it is set.
+	Schedules ScheduleManager
+
+	// Access, if set, authenticates API requests and enforces per-client
+	// quotas.
+	Access *Access
+
+	// Callbacks delivers finished jobs to their callback_url and vets
+	// schedule webhooks. Requests with a callback_url or webhook_url are
+	// rejected when it is not set.
+	Callbacks Callbacks
+
+	// BatchConcurrency is how many crawl slots batches may use together,
+	// leaving the rest to other requests. Defaults to half of maxConcurrent,
+	// at least 1.
+	BatchConcurrency int
+
+	// MaxQueued is how many requests may wait for a crawl slot once all are
+	// taken; more are turned away with 503. Defaults to 100.
+	MaxQueued int
+
+	// QueueTimeout is how long a request may wait for a crawl slot before it
+	// fails. Defaults to 5m.
+	QueueTimeout time.Duration
+
+	slots      *slotQueue
+	batchSlots chan struct{} // taken by batch jobs before a crawl slot
+	jobs       jobRegistry
+	batches    registry[*batch]
 }
 
 // New creates a Handler with the given generator and max concurrent crawls.
@@ -44,12 +203,16 @@ func New(gen usecases.Generator, streamGen StreamGenerator, maxConcurrent int) *
 	return &Handler{
 		Generator:       gen,
 		StreamGenerator: streamGen,
-		sem:             make(chan struct{}, maxConcurrent),
+		slots:           newSlotQueue(maxConcurrent),
 	}
 }
 
 // Register wires the Huma API operations.
 func (h *Handler) Register(api huma.API) {
+	if h.Access != nil {
+		api.UseMiddleware(h.authenticate(api))
+	}
+
 	huma.Register(api, huma.Operation{
 		OperationID: "generate-llmstxt",
 		Method:      http.MethodPost,
@@ -57,77 +220,112 @@ func (h *Handler) Register(api huma.API) {
 		Summary:     "Generate llms.txt for a website",
 		Tags:        []string{"Generator"},
 	}, h.handleGenerate)
-}
 
-// RegisterSSE registers the SSE streaming endpoint on the given mux.
-func (h *Handler) RegisterSSE(mux *http.ServeMux) {
-	mux.HandleFunc("POST /api/generate-stream", h.handleGenerateStream)
-}
-
-func (h *Handler) handleGenerate(ctx context.Context, input *GenerateInput) (*GenerateOutput, error) {
-	rawURL := input.Body.URL
+	sse.Register(api, huma.Operation{
+		OperationID: "generate-llmstxt-stream",
+		Method:      http.MethodPost,
+		Path:        "/api/generate-stream",
+		Summary:     "Generate llms.txt for a website, streaming progress events",
+		Description: "Runs the generation as a job. The first event names the job, whose event stream can be resumed with Last-Event-ID if the connection drops.",
+		Tags:        []string{"Generator"},
+		Middlewares: huma.Middlewares{h.acceptCallbacks, h.admitJobs(api), exposeStreamWriter},
+	}, generateEventTypes, h.handleGenerateStream)
 
-	parsed, err := url.Parse(rawURL)
-	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
-		return nil, huma.Error400BadRequest("invalid URL: must be a valid http or https URL")
+	h.registerJobs(api)
+	h.registerBatches(api)
+	if h.Differ != nil {
+		h.registerDiff(api)
 	}
-
-	select {
-	case h.sem <- struct{}{}:
-		defer func() { <-h.sem }()
-	case <-ctx.Done():
-		return nil, huma.Error500InternalServerError("request cancelled")
+	if h.History != nil {
+		h.registerHistory(api)
+		h.registerSites(api)
+	}
+	if h.Schedules != nil {
+		h.registerSchedules(api)
 	}
+	if h.Callbacks != nil {
+		h.registerCallbacks(api)
+	}
+}
 
-	result, err := h.Generator.Generate(ctx, rawURL)
+func (h *Handler) handleGenerate(ctx context.Context, input *GenerateInput) (*GenerateOutput, error) {
+	result, err := h.generate(ctx, input.Body.URL, input.Body.options())
 	if err != nil {
-		return nil, huma.Error500InternalServerError("generation failed: " + err.Error())
+		return nil, err
 	}
 
 	out := &GenerateOutput{}
-	out.Body.LlmsTxt = result
+	out.Body.LlmsTxt = result.LlmsTxt
+	out.Body.LlmsFullTxt = result.LlmsFullTxt
+	out.Body.Locales = result.Locales
+	out.Body.Failures = pageFailures(result.Failures)
+	out.Body.GeneratedAt = result.GeneratedAt
+	out.Body.HistoryID = result.HistoryID
 	return out, nil
 }
 
-func (h *Handler) handleGenerateStream(w http.ResponseWriter, r *http.Request) {
-	var body struct {
-		URL string `json:"url"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		http.Error(w, `{"error":"invalid JSON"}`, http.StatusBadRequest)
+// handleGenerateStream runs the generation as a job and streams its events.
+// The crawl carries on if the client disconnects, so that it can resume from
+// the job's event stream.
+func (h *Handler) handleGenerateStream(ctx context.Context, input *GenerateInput, send sse.Sender) {
+	// admitJobs has counted the job against the caller's quotas and queued it.
+	j, err := h.startJob(ctx, input.Body)
+	if err != nil {
+		_ = send.Data(ErrorEvent{Error: err.Error()})
 		return
 	}
-
-	parsed, err := url.Parse(body.URL)
-	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
-		http.Error(w, `{"error":"invalid URL: must be a valid http or https URL"}`, http.StatusBadRequest)
+	if err := send.Data(JobEvent{ID: j.id, EventsURL: "/api/jobs/" + j.id + "/events"}); err != nil {
 		return
 	}
+	h.streamJob(ctx, j, "", send)
+}
 
-	select {
-	case h.sem <- struct{}{}:
-		defer func() { <-h.sem }()
-	case <-r.Context().Done():
-		http.Error(w, `{"error":"request cancelled"}`, http.StatusServiceUnavailable)
-		return
+// generate runs a generation once a crawl slot is free, converting its errors
+// to HTTP responses. The pages it crawls count against the caller's quota.
+func (h *Handler) generate(ctx context.Context, siteURL string, opts domain.Options) (domain.Result, error) {
+	res, t, err := h.admitJob(ctx)
+	if err != nil {
+		return domain.Result{}, admissionError(err)
 	}
+	started, pages := time.Now(), 0
+	defer func() { res.Release(pages) }()
 
-	flusher, ok := w.(http.Flusher)
-	if !ok {
-		http.Error(w, `{"error":"streaming not supported"}`, http.StatusInternalServerError)
-		return
+	if err := h.slots.wait(ctx, t, h.queueTimeout(), nil); err != nil {
+		if errors.Is(err, errQueueTimeout) {
+			return domain.Result{}, huma.ErrorWithHeaders(huma.Error503ServiceUnavailable(err.Error()), retryAfter(queueRetryAfter))
+		}
+		return domain.Result{}, huma.Error503ServiceUnavailable("request cancelled while waiting for a crawl slot")
 	}
+	defer h.slots.release()
 
-	w.Header().Set("Content-Type", "text/event-stream")
-	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("Connection", "keep-alive")
+	result, err := h.Generator.Generate(ctx, siteURL, opts)
+	if err != nil || !result.GeneratedAt.Before(started) {
+		// Results answered from the cache crawled nothing.
+		pages = result.Pages + len(result.Failures)
+	}
+	switch {
+	case err == nil:
+		return result, nil
+	case errors.Is(err, domain.ErrBlockedAddress):
+		return result, huma.Error400BadRequest("invalid URL: " + err.Error())
+	case errors.Is(err, usecases.ErrTooManyFailures):
+		return result, huma.Error502BadGateway("generation failed: "+err.Error(), failureDetails(result.Failures)...)
+	}
+	return result, huma.Error500InternalServerError("generation failed: " + err.Error())
+}
 
-	events := make(chan domain.ProgressEvent, 10)
-	go h.StreamGenerator.GenerateStream(r.Context(), body.URL, events)
+func pageFailures(failures []domain.PageError) []PageFailure {
+	out := make([]PageFailure, len(failures))
+	for i, f := range failures {
+		out[i] = PageFailure{URL: f.URL, Status: f.Status, Reason: f.Reason}
+	}
+	return out
+}
 
-	for ev := range events {
-		data, _ := json.Marshal(ev)
-		_, _ = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, data)
-		flusher.Flush()
+func failureDetails(failures []domain.PageError) []error {
+	details := make([]error, len(failures))
+	for i, f := range failures {
+		details[i] = &huma.ErrorDetail{Message: f.Reason, Location: f.URL, Value: f.Status}
 	}
+	return details
 }
@@ -4,22 +4,32 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/danielgtaylor/huma/v2/humatest"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+	"github.com/adsouza/llms.txt-generator/internal/usecases"
 )
 
 type fakeGenerator struct {
-	result string
-	err    error
+	result   string
+	failures []domain.PageError
+	err      error
+	opts     domain.Options
 }
 
-func (f *fakeGenerator) Generate(_ context.Context, _ string) (string, error) {
-	return f.result, f.err
+func (f *fakeGenerator) Generate(_ context.Context, _ string, opts domain.Options) (domain.Result, error) {
+	f.opts = opts
+	return domain.Result{LlmsTxt: f.result, Failures: f.failures, GeneratedAt: generatedAt}, f.err
 }
 
+var generatedAt = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
 func TestHandleGenerate_Success(t *testing.T) {
 	gen := &fakeGenerator{result: "# Test Site\n"}
 	h := New(gen, nil, 5)
@@ -81,3 +91,111 @@ func TestHandleGenerate_GeneratorError(t *testing.T) {
 		t.Errorf("status = %d, want %d", resp.Code, http.StatusInternalServerError)
 	}
 }
+
+func TestHandleGenerate_ReportsFailures(t *testing.T) {
+	gen := &fakeGenerator{
+		result:   "# Test Site\n",
+		failures: []domain.PageError{{URL: "https://example.com/gone", Status: 404, Reason: "Not Found"}},
+	}
+	h := New(gen, nil, 5)
+
+	_, api := humatest.New(t)
+	h.Register(api)
+
+	resp := api.Post("/api/generate", strings.NewReader(`{"url":"https://example.com","max_error_ratio":0.5}`))
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body: %s", resp.Code, http.StatusOK, resp.Body.String())
+	}
+	if gen.opts.MaxErrorRatio != 0.5 {
+		t.Errorf("max error ratio = %v, want 0.5", gen.opts.MaxErrorRatio)
+	}
+
+	var body struct {
+		Failures []PageFailure `json:"failures"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if len(body.Failures) != 1 || body.Failures[0].Status != 404 || body.Failures[0].URL != "https://example.com/gone" {
+		t.Errorf("failures = %+v, want one 404 for /gone", body.Failures)
+	}
+}
+
+func TestHandleGenerate_StrictModeFailure(t *testing.T) {
+	gen := &fakeGenerator{err: usecases.ErrTooManyFailures}
+	h := New(gen, nil, 5)
+
+	_, api := humatest.New(t)
+	h.Register(api)
+
+	resp := api.Post("/api/generate", strings.NewReader(`{"url":"https://example.com","max_error_ratio":0.1}`))
+	if resp.Code != http.StatusBadGateway {
+		t.Errorf("status = %d, want %d", resp.Code, http.StatusBadGateway)
+	}
+}
+
+func TestHandleGenerate_BlockedAddress(t *testing.T) {
+	gen := &fakeGenerator{err: fmt.Errorf("%w: 10.0.0.1", domain.ErrBlockedAddress)}
+	h := New(gen, nil, 5)
+
+	_, api := humatest.New(t)
+	h.Register(api)
+
+	resp := api.Post("/api/generate", strings.NewReader(`{"url":"http://internal.example.com"}`))
+	if resp.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", resp.Code, http.StatusBadRequest)
+	}
+}
+
+func TestHandleGenerate_Credentials(t *testing.T) {
+	gen := &fakeGenerator{result: "# Example\n"}
+	h := New(gen, nil, 5)
+
+	_, api := humatest.New(t)
+	h.Register(api)
+
+	resp := api.Post("/api/generate", strings.NewReader(`{"url":"https://staging.example.com","auth":{
+		"headers":{"X-Api-Key":"k3y"},"cookies":{"session":"abc"},"username":"ci","password":"hunter2"}}`))
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", resp.Code, http.StatusOK, resp.Body.String())
+	}
+	creds := gen.opts.Credentials
+	if creds.Headers["X-Api-Key"] != "k3y" || creds.Cookies["session"] != "abc" || creds.Username != "ci" || creds.Password != "hunter2" {
+		t.Errorf("credentials = %#v, not passed through", creds)
+	}
+}
+
+func TestHandleGenerate_ForceRefresh(t *testing.T) {
+	gen := &fakeGenerator{result: "# Example\n"}
+	h := New(gen, nil, 5)
+
+	_, api := humatest.New(t)
+	h.Register(api)
+
+	resp := api.Post("/api/generate", strings.NewReader(`{"url":"https://example.com","force_refresh":true}`))
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", resp.Code, http.StatusOK, resp.Body.String())
+	}
+	if !gen.opts.ForceRefresh {
+		t.Error("force_refresh not passed through")
+	}
+	var body struct {
+		GeneratedAt time.Time `json:"generated_at"`
+	}
+	if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil || !body.GeneratedAt.Equal(generatedAt) {
+		t.Errorf("generated_at = %v, want %v", body.GeneratedAt, generatedAt)
+	}
+}
+
+func TestHandleGenerate_ReservedAuthHeader(t *testing.T) {
+	gen := &fakeGenerator{}
+	h := New(gen, nil, 5)
+
+	_, api := humatest.New(t)
+	h.Register(api)
+
+	resp := api.Post("/api/generate", strings.NewReader(`{"url":"https://example.com","auth":{"headers":{"host":"evil.example.com"}}}`))
+	if resp.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", resp.Code, http.StatusBadRequest)
+	}
+}
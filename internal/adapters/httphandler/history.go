@@ -0,0 +1,128 @@
+package httphandler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+	"github.com/adsouza/llms.txt-generator/internal/usecases"
+)
+
+// HistoryEntry summarizes a past generation.
+type HistoryEntry struct {
+	ID            string        `json:"id" doc:"Generation ID"`
+	URL           string        `json:"url" doc:"Website URL generated"`
+	Language      string        `json:"language,omitempty" doc:"Locale requested"`
+	PerLocale     bool          `json:"per_locale,omitempty" doc:"Whether one llms.txt per locale was requested"`
+	MaxErrorRatio float64       `json:"max_error_ratio,omitempty" doc:"Strict mode ratio requested"`
+	ForceRefresh  bool          `json:"force_refresh,omitempty" doc:"Whether the cache was bypassed"`
+	Authenticated bool          `json:"authenticated,omitempty" doc:"Whether credentials were given; their values are never kept"`
+	StartedAt     time.Time     `json:"started_at" doc:"When the generation started"`
+	DurationMS    int64         `json:"duration_ms" doc:"How long the generation took, in milliseconds"`
+	Cached        bool          `json:"cached,omitempty" doc:"Whether the result came from the cache"`
+	Pages         int           `json:"pages" doc:"Pages fetched successfully"`
+	Failures      []PageFailure `json:"failures" doc:"Pages that could not be fetched"`
+	Error         string        `json:"error,omitempty" doc:"Why the generation failed"`
+}
+
+// HistoryDetail is a past generation with its output.
+type HistoryDetail struct {
+	HistoryEntry
+	LlmsTxt     string            `json:"llms_txt,omitempty" doc:"Generated llms.txt content, if it succeeded"`
+	LlmsFullTxt string            `json:"llms_full_txt,omitempty" doc:"Generated llms-full.txt content, if any"`
+	Locales     map[string]string `json:"locales,omitempty" doc:"llms.txt content for each locale, when per_locale was set"`
+}
+
+// HistoryListInput filters the history.
+type HistoryListInput struct {
+	Host  string `query:"host" doc:"Only generations of this host, e.g. example.com"`
+	Limit int    `query:"limit" default:"50" minimum:"1" maximum:"500" doc:"Maximum number of generations to return"`
+}
+
+// HistoryListOutput is the Huma response for listing the history.
+type HistoryListOutput struct {
+	Body struct {
+		Generations []HistoryEntry `json:"generations" doc:"Past generations, newest first"`
+	}
+}
+
+// HistoryInput identifies a past generation.
+type HistoryInput struct {
+	ID string `path:"id" doc:"Generation ID"`
+}
+
+// HistoryOutput is the Huma response for a past generation.
+type HistoryOutput struct {
+	Body HistoryDetail
+}
+
+func (h *Handler) registerHistory(api huma.API) {
+	huma.Register(api, huma.Operation{
+		OperationID: "list-history",
+		Method:      http.MethodGet,
+		Path:        "/api/history",
+		Summary:     "List past generations",
+		Tags:        []string{"History"},
+	}, h.handleListHistory)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "get-history",
+		Method:      http.MethodGet,
+		Path:        "/api/history/{id}",
+		Summary:     "Get a past generation with its output",
+		Tags:        []string{"History"},
+	}, h.handleGetHistory)
+}
+
+// handleListHistory lists the generations made by the caller, or every one
+// when clients are not identified.
+func (h *Handler) handleListHistory(ctx context.Context, input *HistoryListInput) (*HistoryListOutput, error) {
+	gens, err := h.History.List(usecases.HistoryQuery{Host: input.Host, Owner: usecases.Owner(ctx), Limit: input.Limit})
+	if err != nil {
+		return nil, huma.Error500InternalServerError("reading history failed: " + err.Error())
+	}
+	out := &HistoryListOutput{}
+	out.Body.Generations = make([]HistoryEntry, len(gens))
+	for i, gen := range gens {
+		out.Body.Generations[i] = historyEntry(gen)
+	}
+	return out, nil
+}
+
+func (h *Handler) handleGetHistory(ctx context.Context, input *HistoryInput) (*HistoryOutput, error) {
+	gen, err := h.History.Get(input.ID)
+	if errors.Is(err, usecases.ErrGenerationNotFound) || (err == nil && !usecases.Visible(gen, usecases.Owner(ctx))) {
+		return nil, huma.Error404NotFound("generation not found")
+	}
+	if err != nil {
+		return nil, huma.Error500InternalServerError("reading history failed: " + err.Error())
+	}
+	return &HistoryOutput{Body: HistoryDetail{
+		HistoryEntry: historyEntry(gen),
+		LlmsTxt:      gen.LlmsTxt,
+		LlmsFullTxt:  gen.LlmsFullTxt,
+		Locales:      gen.Locales,
+	}}, nil
+}
+
+func historyEntry(gen domain.Generation) HistoryEntry {
+	return HistoryEntry{
+		ID:            gen.ID,
+		URL:           gen.URL,
+		Language:      gen.Options.Language,
+		PerLocale:     gen.Options.PerLocale,
+		MaxErrorRatio: gen.Options.MaxErrorRatio,
+		ForceRefresh:  gen.Options.ForceRefresh,
+		Authenticated: gen.Authenticated,
+		StartedAt:     gen.StartedAt,
+		DurationMS:    gen.Duration.Milliseconds(),
+		Cached:        gen.Cached,
+		Pages:         gen.Pages,
+		Failures:      pageFailures(gen.Failures),
+		Error:         gen.Error,
+	}
+}
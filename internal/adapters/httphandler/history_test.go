@@ -0,0 +1,153 @@
+package httphandler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2/humatest"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+	"github.com/adsouza/llms.txt-generator/internal/usecases"
+)
+
+// fakeHistory serves fixed generations, filtered by host and owner, and
+// records the last query.
+type fakeHistory struct {
+	gens  []domain.Generation
+	query usecases.HistoryQuery
+}
+
+func (f *fakeHistory) Add(domain.Generation) (string, error) { return "", nil }
+
+func (f *fakeHistory) List(query usecases.HistoryQuery) ([]domain.Generation, error) {
+	f.query = query
+	var out []domain.Generation
+	for _, gen := range f.gens {
+		if query.Host != "" && !strings.Contains(gen.URL, "//"+query.Host) {
+			continue
+		}
+		if query.Owner != "" && gen.Owner != query.Owner {
+			continue
+		}
+		gen.LlmsTxt, gen.LlmsFullTxt = "", ""
+		out = append(out, gen)
+	}
+	return out, nil
+}
+
+func (f *fakeHistory) Get(id string) (domain.Generation, error) {
+	for _, gen := range f.gens {
+		if gen.ID == id {
+			return gen, nil
+		}
+	}
+	return domain.Generation{}, usecases.ErrGenerationNotFound
+}
+
+func newHistoryAPI(t *testing.T) (humatest.TestAPI, *fakeHistory) {
+	history := &fakeHistory{gens: []domain.Generation{{
+		ID:            "GEN1",
+		URL:           "https://example.com",
+		Options:       domain.Options{Language: "en"},
+		Authenticated: true,
+		StartedAt:     generatedAt,
+		Duration:      1500 * time.Millisecond,
+		Pages:         3,
+		Failures:      []domain.PageError{{URL: "https://example.com/gone", Status: 404, Reason: "not found"}},
+		LlmsTxt:       "# Example\n",
+	}}}
+	h := New(&fakeGenerator{}, nil, 5)
+	h.History = history
+	_, api := humatest.New(t)
+	h.Register(api)
+	return api, history
+}
+
+func TestHistory_List(t *testing.T) {
+	api, history := newHistoryAPI(t)
+
+	resp := api.Get("/api/history?host=example.com")
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body: %s", resp.Code, http.StatusOK, resp.Body.String())
+	}
+	if history.query != (usecases.HistoryQuery{Host: "example.com", Limit: 50}) {
+		t.Errorf("query = %+v, want host example.com and the default limit", history.query)
+	}
+
+	var body struct {
+		Generations []map[string]any `json:"generations"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if len(body.Generations) != 1 {
+		t.Fatalf("got %d generations, want 1", len(body.Generations))
+	}
+	gen := body.Generations[0]
+	if gen["id"] != "GEN1" || gen["language"] != "en" || gen["duration_ms"] != 1500.0 || gen["pages"] != 3.0 || gen["authenticated"] != true {
+		t.Errorf("generation = %v", gen)
+	}
+	if _, ok := gen["llms_txt"]; ok {
+		t.Error("list includes the output")
+	}
+}
+
+func TestHistory_Get(t *testing.T) {
+	api, _ := newHistoryAPI(t)
+
+	resp := api.Get("/api/history/GEN1")
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body: %s", resp.Code, http.StatusOK, resp.Body.String())
+	}
+	var body HistoryDetail
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if body.LlmsTxt != "# Example\n" || body.URL != "https://example.com" || len(body.Failures) != 1 {
+		t.Errorf("generation = %+v, want it with its output", body)
+	}
+
+	resp = api.Get("/api/history/NOPE")
+	if resp.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", resp.Code, http.StatusNotFound)
+	}
+	if !strings.Contains(resp.Header().Get("Content-Type"), "problem+json") {
+		t.Errorf("Content-Type = %q, want Problem JSON", resp.Header().Get("Content-Type"))
+	}
+}
+
+func TestHistory_ScopedToOwner(t *testing.T) {
+	history := &fakeHistory{gens: []domain.Generation{
+		{ID: "MINE", URL: "https://example.com", Owner: "key:mine", LlmsTxt: "# Mine\n"},
+		{ID: "THEIRS", URL: "https://example.com", Owner: "key:theirs", LlmsTxt: "# Theirs\n"},
+	}}
+	h := New(&fakeGenerator{}, nil, 5)
+	h.History = history
+	h.Access = &Access{
+		Keys:       []APIKey{{Name: "mine", Key: "k1"}, {Name: "theirs", Key: "k2"}},
+		RequireKey: true,
+		Quotas:     &usecases.Quotas{},
+	}
+	_, api := humatest.New(t)
+	h.Register(api)
+
+	resp := api.Get("/api/history", "X-API-Key: k1")
+	var body struct {
+		Generations []HistoryEntry `json:"generations"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if len(body.Generations) != 1 || body.Generations[0].ID != "MINE" {
+		t.Errorf("generations = %+v, want only the caller's", body.Generations)
+	}
+	if resp := api.Get("/api/history/MINE", "X-API-Key: k1"); resp.Code != http.StatusOK {
+		t.Errorf("own generation status = %d, want 200", resp.Code)
+	}
+	if resp := api.Get("/api/history/THEIRS", "X-API-Key: k1"); resp.Code != http.StatusNotFound {
+		t.Errorf("other client's generation status = %d, want 404", resp.Code)
+	}
+}
@@ -0,0 +1,462 @@
+package httphandler
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/sse"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+	"github.com/adsouza/llms.txt-generator/internal/usecases"
+)
+
+const (
+	defaultJobTTL            = time.Hour
+	defaultMaxJobs           = 1000
+	defaultHeartbeatInterval = 15 * time.Second
+
+	// maxJobEvents bounds the events a job keeps for replay. Older ones are
+	// dropped, and a stream that resumes before them starts with a gap event.
+	maxJobEvents = 1000
+)
+
+// Job states reported by the jobs API.
+const (
+	jobQueued    = "queued"
+	jobRunning   = "running"
+	jobSucceeded = "succeeded"
+	jobFailed    = "failed"
+	jobCancelled = "cancelled"
+)
+
+// JobStatus describes an asynchronous generation job.
+type JobStatus struct {
+	ID            string            `json:"id" doc:"Job ID"`
+	URL           string            `json:"url" doc:"Website URL being generated"`
+	Status        string            `json:"status" enum:"queued,running,succeeded,failed,cancelled" doc:"Job state"`
+	QueuePosition int               `json:"queue_position,omitempty" doc:"Place in the queue for a crawl slot, 1 being next, while queued"`
+	Done          int               `json:"done" doc:"Pages processed so far"`
+	Total         int               `json:"total" doc:"Pages to fetch, once discovery has finished"`
+	CurrentURL    string            `json:"current_url,omitempty" doc:"Page processed most recently"`
+	CreatedAt     time.Time         `json:"created_at" doc:"When the job was submitted"`
+	FinishedAt    *time.Time        `json:"finished_at,omitempty" doc:"When the job finished; it expires a while later"`
+	LlmsTxt       string            `json:"llms_txt,omitempty" doc:"Generated llms.txt content, once succeeded"`
+	LlmsFullTxt   string            `json:"llms_full_txt,omitempty" doc:"Generated llms-full.txt content, once succeeded"`
+	Locales       map[string]string `json:"locales,omitempty" doc:"llms.txt content for each locale, when per_locale is set"`
+	Failures      []PageFailure     `json:"failures" doc:"Pages that could not be fetched so far"`
+	Error         string            `json:"error,omitempty" doc:"Why the job failed"`
+	HistoryID     string            `json:"history_id,omitempty" doc:"ID of the generation in the history, once succeeded, when one is kept"`
+	CallbackURL   string            `json:"callback_url,omitempty" doc:"URL notified when the job finishes, without its query or credentials; see GET /api/deliveries?subject={id}"`
+}
+
+// JobInput identifies a job.
+type JobInput struct {
+	ID string `path:"id" doc:"Job ID"`
+}
+
+// JobEventsInput identifies a job whose events to stream.
+type JobEventsInput struct {
+	ID          string `path:"id" doc:"Job ID"`
+	LastEventID string `header:"Last-Event-ID" doc:"ID of the last event received, to resume the stream after it"`
+}
+
+// JobOutput is the Huma response for job operations.
+type JobOutput struct {
+	Location string `header:"Location" doc:"URL of the job's status"`
+	Body     JobStatus
+}
+
+// job is a generation running in the background, independent of the request
+// that started it.
+type job struct {
+	id          string
+	url         string
+	callbackURL string
+	owner       string // client that started the job, empty without access control
+	created     time.Time
+	cancel      context.CancelFunc
+
+	mu       sync.Mutex
+	status   JobStatus
+	events   []domain.ProgressEvent // the last maxJobEvents events, replayed to new subscribers, as a ring
+	recorded int                    // events recorded so far, the ID of the last one
+	finished time.Time
+	changed  chan struct{} // closed and replaced whenever the job is updated
+}
+
+// record applies a progress event to the job's status.
+func (j *job) record(ev domain.ProgressEvent, cancelled bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.recorded++
+	ev.ID = j.recorded
+	if len(j.events) < maxJobEvents {
+		j.events = append(j.events, ev)
+	} else {
+		j.events[(ev.ID-1)%maxJobEvents] = ev
+	}
+
+	s := &j.status
+	switch ev.Type {
+	case "queued":
+		s.Status, s.QueuePosition = jobQueued, ev.Position
+	case "discovered":
+		s.Status = jobRunning
+		s.Total = ev.Total
+	case "progress", "page_error":
+		s.Status = jobRunning
+		s.Done, s.Total, s.CurrentURL = ev.Done, ev.Total, ev.CurrentURL
+		if ev.Type == "page_error" {
+			s.Failures = append(s.Failures, PageFailure{URL: ev.CurrentURL, Status: ev.Status, Reason: ev.Error})
+		}
+	case "done":
+		s.Status = jobSucceeded
+		s.LlmsTxt, s.LlmsFullTxt, s.Locales = ev.Result, ev.FullResult, ev.Locales
+		s.Failures = pageFailures(ev.Failures)
+		s.HistoryID = ev.HistoryID
+	case "error":
+		s.Status, s.Error = jobFailed, ev.Error
+		if cancelled {
+			s.Status, s.Error = jobCancelled, "cancelled"
+		}
+		if ev.Failures != nil {
+			s.Failures = pageFailures(ev.Failures)
+		}
+	}
+	j.notify()
+}
+
+// start marks the job as running once it has a crawl slot.
+func (j *job) start() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.status.Status, j.status.QueuePosition = jobRunning, 0
+	j.notify()
+}
+
+// fail finishes the job without running it, for the given reason.
+func (j *job) fail(reason string) {
+	j.record(domain.ProgressEvent{Type: "error", Error: reason}, false)
+	j.cancel()
+	j.finish(jobFailed)
+}
+
+// finish marks the job as finished, with the given state if it did not end
+// with a "done" or "error" event.
+func (j *job) finish(state string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if !j.isFinished() {
+		j.status.Status = state
+	}
+	j.finished = time.Now()
+	finished := j.finished
+	j.status.FinishedAt = &finished
+	j.notify()
+}
+
+func (j *job) isFinished() bool {
+	switch j.status.Status {
+	case jobSucceeded, jobFailed, jobCancelled:
+		return true
+	}
+	return false
+}
+
+// notify wakes up subscribers waiting on the job. The caller holds j.mu.
+func (j *job) notify() {
+	close(j.changed)
+	j.changed = make(chan struct{})
+}
+
+func (j *job) snapshot() JobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	s := j.status
+	s.Failures = append([]PageFailure{}, s.Failures...)
+	return s
+}
+
+// eventsSince returns the events after the first n, how many of those were
+// dropped before them, whether the job has finished, and a channel that is
+// closed on the next update.
+func (j *job) eventsSince(n int) ([]domain.ProgressEvent, int, bool, <-chan struct{}) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	first := j.recorded - len(j.events) + 1 // ID of the oldest event kept
+	missed := max(first-1-n, 0)
+	n = max(n, first-1)
+	events := make([]domain.ProgressEvent, 0, max(j.recorded-n, 0))
+	for id := n + 1; id <= j.recorded; id++ {
+		events = append(events, j.events[(id-1)%maxJobEvents])
+	}
+	return events, missed, !j.finished.IsZero(), j.changed
+}
+
+// finishedAt returns when the job finished, or the zero time while it runs.
+func (j *job) finishedAt() time.Time {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.finished
+}
+
+// expiring is held by a registry until it has been finished for a while.
+type expiring interface {
+	finishedAt() time.Time
+}
+
+// registry holds jobs or batches by ID until they expire, ttl after
+// finishing, or until more than a limit are held, when those that finished
+// first go.
+type registry[T expiring] struct {
+	mu    sync.Mutex
+	items map[string]T
+}
+
+// jobRegistry holds the jobs started through the API.
+type jobRegistry = registry[*job]
+
+func (r *registry[T]) add(id string, item T, ttl time.Duration, limit int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.expire(time.Now(), ttl)
+	r.trim(limit - 1)
+	if r.items == nil {
+		r.items = make(map[string]T)
+	}
+	r.items[id] = item
+}
+
+func (r *registry[T]) get(id string, ttl time.Duration) (T, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.expire(time.Now(), ttl)
+	item, ok := r.items[id]
+	return item, ok
+}
+
+// expire removes items that finished more than ttl before now. The caller holds r.mu.
+func (r *registry[T]) expire(now time.Time, ttl time.Duration) {
+	for id, item := range r.items {
+		if finished := item.finishedAt(); !finished.IsZero() && now.Sub(finished) > ttl {
+			delete(r.items, id)
+		}
+	}
+}
+
+// trim removes the items that finished first until at most limit are held,
+// keeping those still running. The caller holds r.mu.
+func (r *registry[T]) trim(limit int) {
+	if len(r.items) <= limit {
+		return
+	}
+	type finished struct {
+		id string
+		at time.Time
+	}
+	var done []finished
+	for id, item := range r.items {
+		if at := item.finishedAt(); !at.IsZero() {
+			done = append(done, finished{id, at})
+		}
+	}
+	sort.Slice(done, func(a, b int) bool { return done[a].at.Before(done[b].at) })
+	for _, f := range done[:min(len(r.items)-limit, len(done))] {
+		delete(r.items, f.id)
+	}
+}
+
+func (h *Handler) jobTTL() time.Duration {
+	if h.JobTTL > 0 {
+		return h.JobTTL
+	}
+	return defaultJobTTL
+}
+
+func (h *Handler) maxJobs() int {
+	if h.MaxJobs > 0 {
+		return h.MaxJobs
+	}
+	return defaultMaxJobs
+}
+
+// registerJobs wires the asynchronous job operations.
+func (h *Handler) registerJobs(api huma.API) {
+	huma.Register(api, huma.Operation{
+		OperationID:   "create-job",
+		Method:        http.MethodPost,
+		Path:          "/api/jobs",
+		Summary:       "Start generating llms.txt in the background",
+		Description:   "Returns immediately with a job ID. Poll the job or subscribe to its events for progress and the result.",
+		Tags:          []string{"Jobs"},
+		DefaultStatus: http.StatusAccepted,
+		Middlewares:   huma.Middlewares{h.acceptCallbacks},
+	}, h.handleCreateJob)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "get-job",
+		Method:      http.MethodGet,
+		Path:        "/api/jobs/{id}",
+		Summary:     "Get a job's status, progress and result",
+		Tags:        []string{"Jobs"},
+	}, h.handleGetJob)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "cancel-job",
+		Method:        http.MethodDelete,
+		Path:          "/api/jobs/{id}",
+		Summary:       "Cancel a job",
+		Tags:          []string{"Jobs"},
+		DefaultStatus: http.StatusAccepted,
+	}, h.handleCancelJob)
+
+	sse.Register(api, huma.Operation{
+		OperationID: "stream-job-events",
+		Method:      http.MethodGet,
+		Path:        "/api/jobs/{id}/events",
+		Summary:     "Stream a job's progress events",
+		Description: "Replays the events that have already happened, then streams new ones until the job finishes. Send Last-Event-ID to resume after a dropped connection.",
+		Tags:        []string{"Jobs"},
+		Middlewares: huma.Middlewares{h.requireJob(api), exposeStreamWriter},
+	}, jobEventTypes, h.handleJobEvents)
+}
+
+func (h *Handler) handleCreateJob(ctx context.Context, input *GenerateInput) (*JobOutput, error) {
+	j, err := h.startJob(ctx, input.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &JobOutput{Location: "/api/jobs/" + j.id, Body: j.snapshot()}, nil
+}
+
+// startJob registers a job for req and runs it in the background, counting it
+// against the quotas of the caller in ctx and queueing it for a crawl slot.
+// The job outlives the request that started it.
+func (h *Handler) startJob(ctx context.Context, req GenerateRequest) (*job, error) {
+	res, t, err := h.admitJob(ctx)
+	if err != nil {
+		return nil, admissionError(err)
+	}
+	j, jobCtx := h.newJob(usecases.WithOwner(context.Background(), usecases.Owner(ctx)), req)
+	go h.runJob(jobCtx, j, req.options(), res, t)
+	return j, nil
+}
+
+// newJob registers a queued job for req, returning it with the context it
+// runs in, which is cancelled along with parent or by cancelling the job.
+func (h *Handler) newJob(parent context.Context, req GenerateRequest) (*job, context.Context) {
+	ctx, cancel := context.WithCancel(parent)
+	j := &job{
+		id:          rand.Text(),
+		url:         req.URL,
+		callbackURL: req.CallbackURL,
+		owner:       usecases.Owner(parent),
+		created:     time.Now(),
+		cancel:      cancel,
+		changed:     make(chan struct{}),
+	}
+	j.status = JobStatus{ID: j.id, URL: j.url, Status: jobQueued, CreatedAt: j.created, Failures: []PageFailure{}, CallbackURL: redactURL(j.callbackURL)}
+	h.jobs.add(j.id, j, h.jobTTL(), h.maxJobs())
+	return j, ctx
+}
+
+// runJob runs the job, then releases its reservation and notifies its
+// callback URL once its crawl slot is free again.
+func (h *Handler) runJob(ctx context.Context, j *job, opts domain.Options, res *usecases.Reservation, t *ticket) {
+	h.executeJob(ctx, j, opts, t, h.queueTimeout())
+	res.Release(j.snapshot().Done)
+	h.notifyCallback(j)
+}
+
+// executeJob waits in the queue with ticket t for a crawl slot, recording its
+// position, then runs the generation and records its events. The job fails if
+// it waits longer than timeout, if positive.
+func (h *Handler) executeJob(ctx context.Context, j *job, opts domain.Options, t *ticket, timeout time.Duration) {
+	defer j.cancel()
+
+	err := h.slots.wait(ctx, t, timeout, func(position int) {
+		j.record(domain.ProgressEvent{Type: "queued", Position: position}, false)
+	})
+	switch {
+	case errors.Is(err, errQueueTimeout):
+		j.record(domain.ProgressEvent{Type: "error", Error: err.Error()}, false)
+		j.finish(jobFailed)
+		return
+	case err != nil:
+		j.finish(jobCancelled)
+		return
+	}
+	defer h.slots.release()
+	j.start()
+
+	events := make(chan domain.ProgressEvent, 10)
+	go h.StreamGenerator.GenerateStream(ctx, j.url, opts, events)
+	for ev := range events {
+		j.record(ev, ctx.Err() != nil)
+	}
+	if ctx.Err() != nil {
+		j.finish(jobCancelled)
+		return
+	}
+	j.finish(jobFailed)
+}
+
+// ownJob returns the job with the given ID if the caller in ctx may see it.
+// Another client's job is reported as not found.
+func (h *Handler) ownJob(ctx context.Context, id string) (*job, bool) {
+	j, ok := h.jobs.get(id, h.jobTTL())
+	if !ok || !startedBy(j.owner, usecases.Owner(ctx)) {
+		return nil, false
+	}
+	return j, true
+}
+
+// startedBy reports whether a job or batch started by starter may be seen by
+// owner: anyone may see those started without access control.
+func startedBy(starter, owner string) bool {
+	return starter == "" || starter == owner
+}
+
+// requireJob responds with 404 before a stream starts if the job does not
+// exist or belongs to another client.
+func (h *Handler) requireJob(api huma.API) func(huma.Context, func(huma.Context)) {
+	return func(ctx huma.Context, next func(huma.Context)) {
+		if _, ok := h.ownJob(ctx.Context(), ctx.Param("id")); !ok {
+			_ = huma.WriteErr(api, ctx, http.StatusNotFound, "job not found")
+			return
+		}
+		next(ctx)
+	}
+}
+
+func (h *Handler) handleGetJob(ctx context.Context, input *JobInput) (*JobOutput, error) {
+	j, ok := h.ownJob(ctx, input.ID)
+	if !ok {
+		return nil, huma.Error404NotFound("job not found")
+	}
+	return &JobOutput{Location: "/api/jobs/" + j.id, Body: j.snapshot()}, nil
+}
+
+func (h *Handler) handleCancelJob(ctx context.Context, input *JobInput) (*JobOutput, error) {
+	j, ok := h.ownJob(ctx, input.ID)
+	if !ok {
+		return nil, huma.Error404NotFound("job not found")
+	}
+	j.cancel()
+	return &JobOutput{Location: "/api/jobs/" + j.id, Body: j.snapshot()}, nil
+}
+
+// handleJobEvents streams a job's progress events, starting with those that
+// have already happened, until the job finishes or the client disconnects.
+func (h *Handler) handleJobEvents(ctx context.Context, input *JobEventsInput, send sse.Sender) {
+	// requireJob has checked that the job exists, but it may expire in between.
+	if j, ok := h.ownJob(ctx, input.ID); ok {
+		h.streamJob(ctx, j, input.LastEventID, send)
+	}
+}
@@ -0,0 +1,456 @@
+package httphandler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/adapters/humago"
+	"github.com/danielgtaylor/huma/v2/humatest"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+	"github.com/adsouza/llms.txt-generator/internal/usecases"
+)
+
+// fakeStreamGenerator emits a fixed crawl of two pages. If block is set, it
+// waits for it to be closed, or for cancellation, before finishing.
+type fakeStreamGenerator struct {
+	block chan struct{}
+}
+
+func (f *fakeStreamGenerator) GenerateStream(ctx context.Context, siteURL string, _ domain.Options, events chan<- domain.ProgressEvent) {
+	defer close(events)
+	events <- domain.ProgressEvent{Type: "discovered", URLs: []string{siteURL + "/", siteURL + "/docs"}, Total: 2}
+	events <- domain.ProgressEvent{Type: "progress", CurrentURL: siteURL + "/", Done: 1, Total: 2}
+	if f.block != nil {
+		select {
+		case <-f.block:
+		case <-ctx.Done():
+			events <- domain.ProgressEvent{Type: "error", Error: ctx.Err().Error()}
+			return
+		}
+	}
+	events <- domain.ProgressEvent{Type: "page_error", CurrentURL: siteURL + "/docs", Done: 2, Total: 2, Status: 404, Error: "Not Found"}
+	events <- domain.ProgressEvent{
+		Type:     "done",
+		Result:   "# Example\n",
+		Failures: []domain.PageError{{URL: siteURL + "/docs", Status: 404, Reason: "Not Found"}},
+	}
+}
+
+func decodeJob(t *testing.T, body string) JobStatus {
+	t.Helper()
+	var status JobStatus
+	if err := json.Unmarshal([]byte(body), &status); err != nil {
+		t.Fatalf("decoding job: %v: %s", err, body)
+	}
+	return status
+}
+
+// waitForJob polls a job until it reaches the given state.
+func waitForJob(t *testing.T, api humatest.TestAPI, id, state string) JobStatus {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp := api.Get("/api/jobs/" + id)
+		if resp.Code != http.StatusOK {
+			t.Fatalf("GET job status = %d: %s", resp.Code, resp.Body.String())
+		}
+		status := decodeJob(t, resp.Body.String())
+		if status.Status == state {
+			return status
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job status = %q, want %q", status.Status, state)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestJobs_Lifecycle(t *testing.T) {
+	h := New(nil, &fakeStreamGenerator{}, 5)
+	_, api := humatest.New(t)
+	h.Register(api)
+
+	resp := api.Post("/api/jobs", strings.NewReader(`{"url":"https://example.com"}`))
+	if resp.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want %d: %s", resp.Code, http.StatusAccepted, resp.Body.String())
+	}
+	created := decodeJob(t, resp.Body.String())
+	if created.ID == "" || resp.Header().Get("Location") != "/api/jobs/"+created.ID {
+		t.Fatalf("job ID %q, Location %q", created.ID, resp.Header().Get("Location"))
+	}
+
+	status := waitForJob(t, api, created.ID, jobSucceeded)
+	if status.LlmsTxt != "# Example\n" || status.Done != 2 || status.Total != 2 {
+		t.Errorf("unexpected job status: %+v", status)
+	}
+	if len(status.Failures) != 1 || status.Failures[0].Status != 404 {
+		t.Errorf("failures = %+v, want the 404", status.Failures)
+	}
+	if status.FinishedAt == nil {
+		t.Error("finished_at not set")
+	}
+}
+
+func TestJobs_Cancel(t *testing.T) {
+	h := New(nil, &fakeStreamGenerator{block: make(chan struct{})}, 5)
+	_, api := humatest.New(t)
+	h.Register(api)
+
+	created := decodeJob(t, api.Post("/api/jobs", strings.NewReader(`{"url":"https://example.com"}`)).Body.String())
+	waitForJob(t, api, created.ID, jobRunning)
+
+	if resp := api.Delete("/api/jobs/" + created.ID); resp.Code != http.StatusAccepted {
+		t.Fatalf("DELETE status = %d: %s", resp.Code, resp.Body.String())
+	}
+	waitForJob(t, api, created.ID, jobCancelled)
+}
+
+func TestJobs_WaitsForCrawlSlot(t *testing.T) {
+	gen := &fakeStreamGenerator{block: make(chan struct{})}
+	h := New(nil, gen, 1)
+	_, api := humatest.New(t)
+	h.Register(api)
+
+	first := decodeJob(t, api.Post("/api/jobs", strings.NewReader(`{"url":"https://one.example.com"}`)).Body.String())
+	waitForJob(t, api, first.ID, jobRunning)
+	second := decodeJob(t, api.Post("/api/jobs", strings.NewReader(`{"url":"https://two.example.com"}`)).Body.String())
+
+	time.Sleep(50 * time.Millisecond)
+	if status := waitForJob(t, api, second.ID, jobQueued); status.Done != 0 || status.QueuePosition != 1 {
+		t.Errorf("queued job made progress or is not next: %+v", status)
+	}
+	close(gen.block)
+	waitForJob(t, api, first.ID, jobSucceeded)
+	waitForJob(t, api, second.ID, jobSucceeded)
+}
+
+func TestJobs_NotFoundAndExpiry(t *testing.T) {
+	h := New(nil, &fakeStreamGenerator{}, 5)
+	h.JobTTL = time.Nanosecond
+	_, api := humatest.New(t)
+	h.Register(api)
+
+	if resp := api.Get("/api/jobs/nope"); resp.Code != http.StatusNotFound {
+		t.Errorf("unknown job status = %d, want 404", resp.Code)
+	}
+
+	created := decodeJob(t, api.Post("/api/jobs", strings.NewReader(`{"url":"https://example.com"}`)).Body.String())
+	j, _ := h.jobs.get(created.ID, time.Hour)
+	for _, _, finished, changed := j.eventsSince(0); !finished; _, _, finished, changed = j.eventsSince(0) {
+		<-changed
+	}
+	time.Sleep(time.Millisecond)
+	if resp := api.Get("/api/jobs/" + created.ID); resp.Code != http.StatusNotFound {
+		t.Errorf("expired job status = %d, want 404", resp.Code)
+	}
+}
+
+func TestJobs_Events(t *testing.T) {
+	gen := &fakeStreamGenerator{block: make(chan struct{})}
+	ts := newJobServer(t, New(nil, gen, 5))
+
+	resp, err := http.Post(ts.URL+"/api/jobs", "application/json", strings.NewReader(`{"url":"https://example.com"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var created JobStatus
+	_ = json.NewDecoder(resp.Body).Decode(&created)
+	_ = resp.Body.Close()
+
+	stream, err := http.Get(ts.URL + "/api/jobs/" + created.ID + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = stream.Body.Close() }()
+
+	// Events that happened before subscribing are replayed, then new ones follow.
+	var types []string
+	scanner := bufio.NewScanner(stream.Body)
+	for scanner.Scan() {
+		if name, ok := strings.CutPrefix(scanner.Text(), "event: "); ok {
+			types = append(types, name)
+			if name == "progress" {
+				close(gen.block)
+			}
+		}
+	}
+	if got, want := strings.Join(types, ","), "discovered,progress,page_error,done"; got != want {
+		t.Errorf("events = %s, want %s", got, want)
+	}
+}
+
+// newJobServer serves h's API from a real server, which event streams need.
+func newJobServer(t *testing.T, h *Handler) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	h.Register(humago.New(mux, huma.DefaultConfig("test", "1.0.0")))
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// readEvents reads an event stream to the end, returning "id:type" pairs.
+func readEvents(t *testing.T, resp *http.Response) []string {
+	t.Helper()
+	defer func() { _ = resp.Body.Close() }()
+	var events []string
+	var id string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if v, ok := strings.CutPrefix(scanner.Text(), "id: "); ok {
+			id = v
+		}
+		if name, ok := strings.CutPrefix(scanner.Text(), "event: "); ok {
+			events = append(events, id+":"+name)
+		}
+	}
+	return events
+}
+
+func TestGenerateStream_Events(t *testing.T) {
+	ts := newJobServer(t, New(nil, &fakeStreamGenerator{}, 5))
+
+	resp, err := http.Post(ts.URL+"/api/generate-stream", "application/json", strings.NewReader(`{"url":"https://example.com"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var types, data []string
+	var id string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if v, ok := strings.CutPrefix(line, "id: "); ok {
+			id = v
+		}
+		if name, ok := strings.CutPrefix(line, "event: "); ok {
+			types = append(types, id+":"+name)
+		}
+		if v, ok := strings.CutPrefix(line, "data: "); ok {
+			data = append(data, v)
+		}
+	}
+	if got, want := strings.Join(types, ","), ":job,1:discovered,2:progress,3:page_error,4:done"; got != want {
+		t.Errorf("events = %s, want %s", got, want)
+	}
+	if len(data) != 5 {
+		t.Fatalf("got %d data lines, want 5", len(data))
+	}
+
+	var job JobEvent
+	if err := json.Unmarshal([]byte(data[0]), &job); err != nil || job.EventsURL != "/api/jobs/"+job.ID+"/events" {
+		t.Errorf("job event = %s, want the job's event stream", data[0])
+	}
+	var pageErr PageErrorEvent
+	if err := json.Unmarshal([]byte(data[3]), &pageErr); err != nil {
+		t.Fatal(err)
+	}
+	if want := (PageErrorEvent{URL: "https://example.com/docs", Status: 404, Reason: "Not Found", Done: 2, Total: 2}); pageErr != want {
+		t.Errorf("page_error = %+v, want %+v", pageErr, want)
+	}
+	var done DoneEvent
+	if err := json.Unmarshal([]byte(data[4]), &done); err != nil {
+		t.Fatal(err)
+	}
+	if done.LlmsTxt != "# Example\n" || len(done.Failures) != 1 {
+		t.Errorf("done = %+v", done)
+	}
+}
+
+func TestGenerateStream_InvalidRequest(t *testing.T) {
+	ts := newJobServer(t, New(nil, &fakeStreamGenerator{}, 5))
+
+	tests := []struct {
+		body     string
+		location string
+	}{
+		{`{"url":"ftp://bad"}`, "body.url"},
+		{`{"url":"https://example.com","auth":{"headers":{"Host":"evil.example.com"}}}`, "body.auth.headers"},
+	}
+	for _, tt := range tests {
+		resp, err := http.Post(ts.URL+"/api/generate-stream", "application/json", strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var problem huma.ErrorModel
+		_ = json.NewDecoder(resp.Body).Decode(&problem)
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest || !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/problem+json") {
+			t.Errorf("%s: status = %d, content type %q, want 400 Problem JSON", tt.body, resp.StatusCode, resp.Header.Get("Content-Type"))
+		}
+		if len(problem.Errors) != 1 || problem.Errors[0].Location != tt.location {
+			t.Errorf("%s: errors = %+v, want one at %s", tt.body, problem.Errors, tt.location)
+		}
+	}
+}
+
+func TestJobs_EventsResumeFromLastEventID(t *testing.T) {
+	h := New(nil, &fakeStreamGenerator{}, 5)
+	ts := newJobServer(t, h)
+	_, api := humatest.New(t)
+	h.Register(api)
+
+	created := decodeJob(t, api.Post("/api/jobs", strings.NewReader(`{"url":"https://example.com"}`)).Body.String())
+	waitForJob(t, api, created.ID, jobSucceeded)
+
+	tests := []struct {
+		lastEventID string
+		want        string
+	}{
+		{"", "1:discovered,2:progress,3:page_error,4:done"},
+		{"2", "3:page_error,4:done"},
+		{"4", ""},
+		{"bogus", "1:discovered,2:progress,3:page_error,4:done"},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+"/api/jobs/"+created.ID+"/events", nil)
+		if tt.lastEventID != "" {
+			req.Header.Set("Last-Event-ID", tt.lastEventID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Join(readEvents(t, resp), ","); got != tt.want {
+			t.Errorf("Last-Event-ID %q: events = %s, want %s", tt.lastEventID, got, tt.want)
+		}
+	}
+}
+
+func TestJobs_EventsHeartbeat(t *testing.T) {
+	gen := &fakeStreamGenerator{block: make(chan struct{})}
+	h := New(nil, gen, 5)
+	h.HeartbeatInterval = 10 * time.Millisecond
+	ts := newJobServer(t, h)
+
+	resp, err := http.Post(ts.URL+"/api/generate-stream", "application/json", strings.NewReader(`{"url":"https://example.com"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	// Heartbeats are comments, which take no event ID.
+	var events []string
+	var id string
+	pinged := false
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == ": ping" && !pinged {
+			pinged = true
+			close(gen.block)
+		}
+		if v, ok := strings.CutPrefix(line, "id: "); ok {
+			id = v
+		}
+		if name, ok := strings.CutPrefix(line, "event: "); ok {
+			events = append(events, id+":"+name)
+		}
+	}
+	if !pinged {
+		t.Fatal("stream ended without a heartbeat")
+	}
+	if got, want := strings.Join(events, ","), ":job,1:discovered,2:progress,3:page_error,4:done"; got != want {
+		t.Errorf("events = %s, want %s", got, want)
+	}
+}
+
+func TestJobs_ScopedToOwner(t *testing.T) {
+	gen := &fakeStreamGenerator{block: make(chan struct{})}
+	defer close(gen.block)
+	h := New(nil, gen, 5)
+	h.Access = &Access{
+		Keys:       []APIKey{{Name: "mine", Key: "k1"}, {Name: "theirs", Key: "k2"}},
+		RequireKey: true,
+		Quotas:     &usecases.Quotas{},
+	}
+	_, api := humatest.New(t)
+	h.Register(api)
+
+	created := decodeJob(t, api.Post("/api/jobs", "X-API-Key: k1", strings.NewReader(`{"url":"https://example.com"}`)).Body.String())
+	if resp := api.Get("/api/jobs/"+created.ID, "X-API-Key: k1"); resp.Code != http.StatusOK {
+		t.Errorf("owner's get status = %d, want 200", resp.Code)
+	}
+	for _, resp := range []*httptest.ResponseRecorder{
+		api.Get("/api/jobs/"+created.ID, "X-API-Key: k2"),
+		api.Get("/api/jobs/"+created.ID+"/events", "X-API-Key: k2"),
+		api.Delete("/api/jobs/"+created.ID, "X-API-Key: k2"),
+	} {
+		if resp.Code != http.StatusNotFound {
+			t.Errorf("status = %d, want 404 for another client's job", resp.Code)
+		}
+	}
+	if j, _ := h.jobs.get(created.ID, time.Hour); j.snapshot().Status == jobCancelled {
+		t.Error("another client cancelled the job")
+	}
+}
+
+func TestJobs_EventsNotFound(t *testing.T) {
+	ts := newJobServer(t, New(nil, &fakeStreamGenerator{}, 5))
+
+	resp, err := http.Get(ts.URL + "/api/jobs/missing/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound || !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/problem+json") {
+		t.Errorf("status = %d, content type %q, want 404 Problem JSON", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+}
+
+func TestJob_EventsSinceKeepsTheLast(t *testing.T) {
+	j := &job{changed: make(chan struct{})}
+	for range maxJobEvents + 5 {
+		j.record(domain.ProgressEvent{Type: "progress"}, false)
+	}
+
+	tests := []struct {
+		since, missed, first, count int
+	}{
+		{0, 5, 6, maxJobEvents},
+		{3, 2, 6, maxJobEvents},
+		{5, 0, 6, maxJobEvents},
+		{maxJobEvents, 0, maxJobEvents + 1, 5},
+		{maxJobEvents + 5, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		events, missed, _, _ := j.eventsSince(tt.since)
+		if missed != tt.missed || len(events) != tt.count {
+			t.Errorf("eventsSince(%d) = %d events, %d missed; want %d, %d", tt.since, len(events), missed, tt.count, tt.missed)
+			continue
+		}
+		for i, ev := range events {
+			if ev.ID != tt.first+i {
+				t.Errorf("eventsSince(%d)[%d].ID = %d, want %d", tt.since, i, ev.ID, tt.first+i)
+				break
+			}
+		}
+	}
+}
+
+func TestRegistry_KeepsAtMostLimit(t *testing.T) {
+	var r jobRegistry
+	finished := func(id string, at time.Time) *job {
+		return &job{id: id, finished: at}
+	}
+	now := time.Now()
+	r.add("running", &job{id: "running"}, time.Hour, 3)
+	r.add("old", finished("old", now.Add(-2*time.Minute)), time.Hour, 3)
+	r.add("recent", finished("recent", now.Add(-time.Minute)), time.Hour, 3)
+	r.add("new", &job{id: "new"}, time.Hour, 3)
+
+	for id, want := range map[string]bool{"running": true, "old": false, "recent": true, "new": true} {
+		if _, ok := r.get(id, time.Hour); ok != want {
+			t.Errorf("job %q kept = %v, want %v", id, ok, want)
+		}
+	}
+}
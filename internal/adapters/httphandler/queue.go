@@ -0,0 +1,199 @@
+package httphandler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/adsouza/llms.txt-generator/internal/usecases"
+)
+
+const (
+	defaultMaxQueued    = 100
+	defaultQueueTimeout = 5 * time.Minute
+
+	// queueRetryAfter is suggested to clients turned away by a full queue.
+	queueRetryAfter = 30 * time.Second
+)
+
+var (
+	errQueueFull    = errors.New("all crawl slots are busy and the queue is full")
+	errQueueTimeout = errors.New("timed out waiting for a crawl slot")
+)
+
+// slotQueue hands out a fixed number of crawl slots in the order they were
+// asked for, unlike a channel semaphore, whose waiters also compete with
+// newcomers and cannot tell where they stand.
+type slotQueue struct {
+	slots int
+
+	mu      sync.Mutex
+	busy    int
+	waiting []*ticket // in order of arrival
+}
+
+// ticket is a place in the queue, or a slot once granted.
+type ticket struct {
+	joined  time.Time
+	granted chan struct{} // closed when the ticket gets a slot
+	moved   chan struct{} // signalled when the ticket moves up the queue
+}
+
+func newSlotQueue(slots int) *slotQueue {
+	return &slotQueue{slots: slots}
+}
+
+// join takes a free slot, or a place at the back of the queue if there is
+// none. It fails with errQueueFull if limit tickets are already waiting;
+// a limit of 0 lets the queue grow.
+func (q *slotQueue) join(limit int) (*ticket, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	t := &ticket{joined: time.Now(), granted: make(chan struct{}), moved: make(chan struct{}, 1)}
+	if q.busy < q.slots && len(q.waiting) == 0 {
+		q.busy++
+		close(t.granted)
+		return t, nil
+	}
+	if limit > 0 && len(q.waiting) >= limit {
+		return nil, errQueueFull
+	}
+	q.waiting = append(q.waiting, t)
+	return t, nil
+}
+
+// wait blocks until t gets a slot, calling queued with its position, from 1,
+// whenever it changes. It gives up with errQueueTimeout once t has waited for
+// timeout since joining, if positive, or with the context's error. A ticket
+// that gives up leaves the queue; one that gets a slot must be released.
+func (q *slotQueue) wait(ctx context.Context, t *ticket, timeout time.Duration, queued func(position int)) error {
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(time.Until(t.joined.Add(timeout)))
+		defer timer.Stop()
+		expired = timer.C
+	}
+	position := 0
+	for {
+		if p := q.position(t); p > 0 && p != position && queued != nil {
+			position = p
+			queued(p)
+		}
+		select {
+		case <-t.granted:
+			return nil
+		case <-t.moved:
+		case <-expired:
+			return q.leave(t, errQueueTimeout)
+		case <-ctx.Done():
+			return q.leave(t, ctx.Err())
+		}
+	}
+}
+
+// release frees a slot, handing it to the ticket at the front of the queue.
+func (q *slotQueue) release() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.waiting) == 0 {
+		q.busy--
+		return
+	}
+	next := q.waiting[0]
+	q.waiting = q.waiting[1:]
+	close(next.granted)
+	q.moved()
+}
+
+// drop gives up t, whether it is still waiting or has been granted a slot.
+func (q *slotQueue) drop(t *ticket) {
+	if q.leave(t, errQueueTimeout) == nil {
+		q.release()
+	}
+}
+
+// leave removes t from the queue, returning err, unless it has been granted a
+// slot in the meantime, which it then keeps.
+func (q *slotQueue) leave(t *ticket, err error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, w := range q.waiting {
+		if w == t {
+			q.waiting = append(q.waiting[:i], q.waiting[i+1:]...)
+			q.moved()
+			return err
+		}
+	}
+	return nil
+}
+
+// position returns where t stands in the queue, from 1, or 0 once granted.
+func (q *slotQueue) position(t *ticket) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, w := range q.waiting {
+		if w == t {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// moved tells the waiting tickets that they may have moved up. The caller
+// holds q.mu.
+func (q *slotQueue) moved() {
+	for _, w := range q.waiting {
+		select {
+		case w.moved <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (h *Handler) maxQueued() int {
+	if h.MaxQueued > 0 {
+		return h.MaxQueued
+	}
+	return defaultMaxQueued
+}
+
+func (h *Handler) queueTimeout() time.Duration {
+	if h.QueueTimeout > 0 {
+		return h.QueueTimeout
+	}
+	return defaultQueueTimeout
+}
+
+// AcquireSlot implements usecases.Slots, queueing for a crawl slot behind the
+// requests already waiting, without a limit on the queue or the wait.
+func (h *Handler) AcquireSlot(ctx context.Context) (func(), error) {
+	t, err := h.slots.join(0)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.slots.wait(ctx, t, 0, nil); err != nil {
+		return nil, err
+	}
+	return h.slots.release, nil
+}
+
+// admitJob reserves a job for the caller in ctx and a place in the queue for
+// a crawl slot, or returns those taken by admitJobs if there are any. It
+// fails with a QuotaError or errQueueFull.
+func (h *Handler) admitJob(ctx context.Context) (*usecases.Reservation, *ticket, error) {
+	if slot, ok := ctx.Value(jobSlotKey{}).(*jobSlot); ok && !slot.claimed {
+		slot.claimed = true
+		return slot.res, slot.ticket, nil
+	}
+	res, err := h.reserveJob(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	t, err := h.slots.join(h.maxQueued())
+	if err != nil {
+		res.Release(0)
+		return nil, nil, err
+	}
+	return res, t, nil
+}
@@ -0,0 +1,235 @@
+package httphandler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2/humatest"
+)
+
+func granted(t *ticket) bool {
+	select {
+	case <-t.granted:
+		return true
+	default:
+		return false
+	}
+}
+
+// waitForQueue waits until n tickets are waiting in q.
+func waitForQueue(t *testing.T, q *slotQueue, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		q.mu.Lock()
+		waiting := len(q.waiting)
+		q.mu.Unlock()
+		if waiting == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d tickets waiting, want %d", waiting, n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSlotQueue_GrantsInOrder(t *testing.T) {
+	q := newSlotQueue(1)
+	first, _ := q.join(0)
+	second, _ := q.join(0)
+	third, _ := q.join(0)
+	if !granted(first) || granted(second) || granted(third) {
+		t.Fatal("only the first ticket should have a slot")
+	}
+	if q.position(second) != 1 || q.position(third) != 2 {
+		t.Errorf("positions = %d, %d, want 1, 2", q.position(second), q.position(third))
+	}
+
+	q.release()
+	if !granted(second) || granted(third) {
+		t.Error("release should hand the slot to the second ticket")
+	}
+	// A newcomer queues behind the waiting ticket, even once a slot is free.
+	fourth, _ := q.join(0)
+	q.release()
+	if !granted(third) || granted(fourth) {
+		t.Error("release should hand the slot to the third ticket")
+	}
+	q.release()
+	if !granted(fourth) {
+		t.Error("release should hand the slot to the fourth ticket")
+	}
+	q.release()
+	if q.busy != 0 {
+		t.Errorf("busy = %d after releasing every slot", q.busy)
+	}
+}
+
+func TestSlotQueue_Full(t *testing.T) {
+	q := newSlotQueue(1)
+	for range 3 {
+		if _, err := q.join(2); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := q.join(2); !errors.Is(err, errQueueFull) {
+		t.Errorf("join() error = %v, want errQueueFull", err)
+	}
+	if _, err := q.join(0); err != nil {
+		t.Errorf("join() without a limit error = %v", err)
+	}
+}
+
+func TestSlotQueue_WaitReportsPositions(t *testing.T) {
+	q := newSlotQueue(1)
+	_, _ = q.join(0)
+	_, _ = q.join(0)
+	last, _ := q.join(0)
+
+	positions := make(chan int, 3)
+	done := make(chan error)
+	go func() { done <- q.wait(context.Background(), last, 0, func(p int) { positions <- p }) }()
+
+	if p := <-positions; p != 2 {
+		t.Errorf("first position = %d, want 2", p)
+	}
+	q.release()
+	if p := <-positions; p != 1 {
+		t.Errorf("second position = %d, want 1", p)
+	}
+	q.release()
+	if err := <-done; err != nil {
+		t.Errorf("wait() error = %v", err)
+	}
+}
+
+func TestSlotQueue_GivingUpLeavesQueue(t *testing.T) {
+	q := newSlotQueue(1)
+	_, _ = q.join(0)
+
+	timedOut, _ := q.join(0)
+	if err := q.wait(context.Background(), timedOut, 10*time.Millisecond, nil); !errors.Is(err, errQueueTimeout) {
+		t.Errorf("wait() error = %v, want errQueueTimeout", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cancelled, _ := q.join(0)
+	if err := q.wait(ctx, cancelled, 0, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("wait() error = %v, want context.Canceled", err)
+	}
+
+	next, _ := q.join(0)
+	if q.position(next) != 1 {
+		t.Errorf("position = %d, want 1 once the others have left", q.position(next))
+	}
+	q.release()
+	if !granted(next) {
+		t.Error("release should hand the slot to the remaining ticket")
+	}
+}
+
+func TestQueue_FullResponds503(t *testing.T) {
+	h := New(&fakeGenerator{result: "# Test\n"}, &fakeStreamGenerator{}, 1)
+	h.MaxQueued = 1
+	_, api := humatest.New(t)
+	h.Register(api)
+
+	// Take the only slot and the only place in the queue.
+	_, _ = h.slots.join(0)
+	_, _ = h.slots.join(0)
+
+	for _, path := range []string{"/api/generate", "/api/generate-stream", "/api/jobs"} {
+		resp := api.Post(path, strings.NewReader(`{"url":"https://example.com"}`))
+		if resp.Code != http.StatusServiceUnavailable || resp.Header().Get("Retry-After") != "30" {
+			t.Errorf("%s: status = %d, Retry-After %q, want 503 with 30", path, resp.Code, resp.Header().Get("Retry-After"))
+		}
+	}
+	waitForQueue(t, h.slots, 1)
+}
+
+func TestQueue_GenerateTimesOut(t *testing.T) {
+	h := New(&fakeGenerator{result: "# Test\n"}, nil, 1)
+	h.QueueTimeout = 10 * time.Millisecond
+	_, api := humatest.New(t)
+	h.Register(api)
+
+	_, _ = h.slots.join(0)
+	resp := api.Post("/api/generate", strings.NewReader(`{"url":"https://example.com"}`))
+	if resp.Code != http.StatusServiceUnavailable || resp.Header().Get("Retry-After") == "" {
+		t.Errorf("status = %d, Retry-After %q, want 503 with a delay", resp.Code, resp.Header().Get("Retry-After"))
+	}
+	if !strings.Contains(resp.Body.String(), errQueueTimeout.Error()) {
+		t.Errorf("body = %s, want the timeout", resp.Body.String())
+	}
+	waitForQueue(t, h.slots, 0)
+}
+
+func TestQueue_JobTimesOut(t *testing.T) {
+	h := New(nil, &fakeStreamGenerator{}, 1)
+	h.QueueTimeout = 10 * time.Millisecond
+	_, api := humatest.New(t)
+	h.Register(api)
+
+	_, _ = h.slots.join(0)
+	created := decodeJob(t, api.Post("/api/jobs", strings.NewReader(`{"url":"https://example.com"}`)).Body.String())
+	if status := waitForJob(t, api, created.ID, jobFailed); status.Error != errQueueTimeout.Error() {
+		t.Errorf("error = %q, want %q", status.Error, errQueueTimeout)
+	}
+}
+
+func TestQueue_StreamReportsPosition(t *testing.T) {
+	h := New(nil, &fakeStreamGenerator{}, 1)
+	ts := newJobServer(t, h)
+
+	// Free the slot once the stream's job has reported its position.
+	_, _ = h.slots.join(0)
+	go func() {
+		for {
+			h.jobs.mu.Lock()
+			queued := false
+			for _, j := range h.jobs.items {
+				queued = queued || j.snapshot().QueuePosition > 0
+			}
+			h.jobs.mu.Unlock()
+			if queued {
+				h.slots.release()
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}()
+
+	resp, err := http.Post(ts.URL+"/api/generate-stream", "application/json", strings.NewReader(`{"url":"https://example.com"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(readEvents(t, resp), ","), ":job,1:queued,2:discovered,3:progress,4:page_error,5:done"; got != want {
+		t.Errorf("events = %s, want %s", got, want)
+	}
+}
+
+func TestHandler_AcquireSlotQueues(t *testing.T) {
+	h := New(nil, nil, 1)
+	_, _ = h.slots.join(0)
+
+	acquired := make(chan func())
+	go func() {
+		release, err := h.AcquireSlot(context.Background())
+		if err != nil {
+			t.Errorf("AcquireSlot() error: %v", err)
+			release = func() {}
+		}
+		acquired <- release
+	}()
+	waitForQueue(t, h.slots, 1)
+	h.slots.release()
+	(<-acquired)()
+	if h.slots.busy != 0 {
+		t.Errorf("busy = %d after releasing the slot", h.slots.busy)
+	}
+}
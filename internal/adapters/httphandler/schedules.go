@@ -0,0 +1,221 @@
+package httphandler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+	"github.com/adsouza/llms.txt-generator/internal/usecases"
+)
+
+// ScheduleManager registers sites for periodic regeneration.
+type ScheduleManager interface {
+	Add(schedule domain.Schedule) (domain.Schedule, error)
+	List() ([]domain.Schedule, error)
+	Get(id string) (domain.Schedule, error)
+	Remove(id string) error
+}
+
+// ScheduleRequest registers a site for periodic regeneration.
+type ScheduleRequest struct {
+	URL           string  `json:"url" doc:"Website URL to regenerate" minLength:"1"`
+	Cron          string  `json:"cron" doc:"Cron expression with minute, hour, day of month, month and day of week, e.g. \"0 3 * * *\", or @hourly, @daily, @weekly, @monthly" minLength:"1"`
+	Timezone      string  `json:"timezone,omitempty" doc:"IANA time zone the expression is evaluated in, e.g. Europe/Lisbon (defaults to UTC)"`
+	WebhookURL    string  `json:"webhook_url,omitempty" doc:"URL notified with the diff when the output changes"`
+	Language      string  `json:"language,omitempty" doc:"Keep only pages in this locale" maxLength:"35"`
+	PerLocale     bool    `json:"per_locale,omitempty" doc:"Also generate one llms.txt per locale"`
+	MaxErrorRatio float64 `json:"max_error_ratio,omitempty" doc:"Fail the run if the fraction of pages that could not be fetched exceeds this ratio" minimum:"0" maximum:"1"`
+}
+
+// ScheduleInput is the Huma request body for registering a schedule.
+type ScheduleInput struct {
+	Body ScheduleRequest
+}
+
+// Resolve implements huma.Resolver, checking the URLs before registering.
+func (i *ScheduleInput) Resolve(ctx huma.Context) []error {
+	if err := checkSiteURL("body.url", i.Body.URL); err != nil {
+		return []error{err}
+	}
+	if i.Body.WebhookURL != "" {
+		if err := checkCallback(ctx, "body.webhook_url", i.Body.WebhookURL); err != nil {
+			return []error{err}
+		}
+	}
+	return nil
+}
+
+// ScheduleStatus describes a registered schedule and its last run.
+type ScheduleStatus struct {
+	ID            string     `json:"id" doc:"Schedule ID"`
+	URL           string     `json:"url" doc:"Website URL regenerated"`
+	Cron          string     `json:"cron" doc:"Cron expression"`
+	Timezone      string     `json:"timezone,omitempty" doc:"Time zone the expression is evaluated in"`
+	WebhookURL    string     `json:"webhook_url,omitempty" doc:"URL notified when the output changes, without its query or credentials"`
+	Language      string     `json:"language,omitempty" doc:"Locale generated"`
+	PerLocale     bool       `json:"per_locale,omitempty" doc:"Whether one llms.txt per locale is generated"`
+	MaxErrorRatio float64    `json:"max_error_ratio,omitempty" doc:"Strict mode ratio"`
+	CreatedAt     time.Time  `json:"created_at" doc:"When the schedule was registered"`
+	NextRunAt     time.Time  `json:"next_run_at" doc:"When the site is next regenerated"`
+	LastRunAt     *time.Time `json:"last_run_at,omitempty" doc:"When the site was last regenerated"`
+	LastHistoryID string     `json:"last_history_id,omitempty" doc:"History ID of the last successful run, whose output GET /api/history/{id} returns"`
+	LastError     string     `json:"last_error,omitempty" doc:"Why the last run failed"`
+	NotifyError   string     `json:"notify_error,omitempty" doc:"Why the last change notification could not be delivered"`
+}
+
+// ScheduleIDInput identifies a schedule.
+type ScheduleIDInput struct {
+	ID string `path:"id" doc:"Schedule ID"`
+}
+
+// ScheduleOutput is the Huma response for a schedule.
+type ScheduleOutput struct {
+	Location string `header:"Location" doc:"URL of the schedule"`
+	Body     ScheduleStatus
+}
+
+// ScheduleListOutput is the Huma response for listing schedules.
+type ScheduleListOutput struct {
+	Body struct {
+		Schedules []ScheduleStatus `json:"schedules" doc:"Registered schedules, oldest first"`
+	}
+}
+
+func (h *Handler) registerSchedules(api huma.API) {
+	huma.Register(api, huma.Operation{
+		OperationID:   "create-schedule",
+		Method:        http.MethodPost,
+		Path:          "/api/schedules",
+		Summary:       "Regenerate a site on a schedule",
+		Description:   "Each run is stored in the history. When the output differs from the previous run, webhook_url receives the diff.",
+		Tags:          []string{"Schedules"},
+		DefaultStatus: http.StatusCreated,
+		Middlewares:   huma.Middlewares{h.acceptCallbacks},
+	}, h.handleCreateSchedule)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "list-schedules",
+		Method:      http.MethodGet,
+		Path:        "/api/schedules",
+		Summary:     "List scheduled sites",
+		Tags:        []string{"Schedules"},
+	}, h.handleListSchedules)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "get-schedule",
+		Method:      http.MethodGet,
+		Path:        "/api/schedules/{id}",
+		Summary:     "Get a schedule and its last run",
+		Tags:        []string{"Schedules"},
+	}, h.handleGetSchedule)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "delete-schedule",
+		Method:        http.MethodDelete,
+		Path:          "/api/schedules/{id}",
+		Summary:       "Stop regenerating a site",
+		Tags:          []string{"Schedules"},
+		DefaultStatus: http.StatusNoContent,
+	}, h.handleDeleteSchedule)
+}
+
+func (h *Handler) handleCreateSchedule(ctx context.Context, input *ScheduleInput) (*ScheduleOutput, error) {
+	r := input.Body
+	schedule, err := h.Schedules.Add(domain.Schedule{
+		URL:        r.URL,
+		Cron:       r.Cron,
+		Timezone:   r.Timezone,
+		WebhookURL: r.WebhookURL,
+		Owner:      usecases.Owner(ctx),
+		Options:    domain.Options{Language: r.Language, PerLocale: r.PerLocale, MaxErrorRatio: r.MaxErrorRatio},
+	})
+	if errors.Is(err, usecases.ErrInvalidSchedule) {
+		return nil, huma.Error400BadRequest(err.Error())
+	}
+	if err != nil {
+		return nil, huma.Error500InternalServerError("saving schedule failed: " + err.Error())
+	}
+	return &ScheduleOutput{Location: "/api/schedules/" + schedule.ID, Body: scheduleStatus(schedule)}, nil
+}
+
+func (h *Handler) handleListSchedules(ctx context.Context, _ *struct{}) (*ScheduleListOutput, error) {
+	schedules, err := h.Schedules.List()
+	if err != nil {
+		return nil, huma.Error500InternalServerError("reading schedules failed: " + err.Error())
+	}
+	out := &ScheduleListOutput{}
+	out.Body.Schedules = []ScheduleStatus{}
+	for _, s := range schedules {
+		if ownedBy(s, usecases.Owner(ctx)) {
+			out.Body.Schedules = append(out.Body.Schedules, scheduleStatus(s))
+		}
+	}
+	return out, nil
+}
+
+func (h *Handler) handleGetSchedule(ctx context.Context, input *ScheduleIDInput) (*ScheduleOutput, error) {
+	schedule, err := h.ownSchedule(ctx, input.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &ScheduleOutput{Location: "/api/schedules/" + schedule.ID, Body: scheduleStatus(schedule)}, nil
+}
+
+func (h *Handler) handleDeleteSchedule(ctx context.Context, input *ScheduleIDInput) (*struct{}, error) {
+	if _, err := h.ownSchedule(ctx, input.ID); err != nil {
+		return nil, err
+	}
+	err := h.Schedules.Remove(input.ID)
+	if errors.Is(err, usecases.ErrScheduleNotFound) {
+		return nil, huma.Error404NotFound("schedule not found")
+	}
+	if err != nil {
+		return nil, huma.Error500InternalServerError("deleting schedule failed: " + err.Error())
+	}
+	return nil, nil
+}
+
+// ownSchedule returns the schedule with the given ID if the caller in ctx
+// registered it; other clients' schedules are not found.
+func (h *Handler) ownSchedule(ctx context.Context, id string) (domain.Schedule, error) {
+	schedule, err := h.Schedules.Get(id)
+	if errors.Is(err, usecases.ErrScheduleNotFound) || (err == nil && !ownedBy(schedule, usecases.Owner(ctx))) {
+		return domain.Schedule{}, huma.Error404NotFound("schedule not found")
+	}
+	if err != nil {
+		return domain.Schedule{}, huma.Error500InternalServerError("reading schedule failed: " + err.Error())
+	}
+	return schedule, nil
+}
+
+// ownedBy reports whether owner may see the schedule: anyone may without
+// access control, when owner is empty.
+func ownedBy(s domain.Schedule, owner string) bool {
+	return owner == "" || s.Owner == owner
+}
+
+func scheduleStatus(s domain.Schedule) ScheduleStatus {
+	out := ScheduleStatus{
+		ID:            s.ID,
+		URL:           s.URL,
+		Cron:          s.Cron,
+		Timezone:      s.Timezone,
+		WebhookURL:    redactURL(s.WebhookURL),
+		Language:      s.Options.Language,
+		PerLocale:     s.Options.PerLocale,
+		MaxErrorRatio: s.Options.MaxErrorRatio,
+		CreatedAt:     s.CreatedAt,
+		NextRunAt:     s.NextRunAt,
+		LastHistoryID: s.LastHistoryID,
+		LastError:     s.LastError,
+		NotifyError:   s.NotifyError,
+	}
+	if !s.LastRunAt.IsZero() {
+		out.LastRunAt = &s.LastRunAt
+	}
+	return out
+}
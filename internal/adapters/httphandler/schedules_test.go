@@ -0,0 +1,169 @@
+package httphandler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2/humatest"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+	"github.com/adsouza/llms.txt-generator/internal/usecases"
+)
+
+// fakeSchedules is a ScheduleManager in a map, with a fixed next run time.
+type fakeSchedules map[string]domain.Schedule
+
+func (f fakeSchedules) Add(s domain.Schedule) (domain.Schedule, error) {
+	if s.Cron == "never" {
+		return domain.Schedule{}, usecases.ErrInvalidSchedule
+	}
+	s.ID = "SCHED"
+	s.CreatedAt = generatedAt
+	s.NextRunAt = generatedAt.Add(time.Hour)
+	f[s.ID] = s
+	return s, nil
+}
+
+func (f fakeSchedules) List() ([]domain.Schedule, error) {
+	var out []domain.Schedule
+	for _, s := range f {
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+func (f fakeSchedules) Get(id string) (domain.Schedule, error) {
+	s, ok := f[id]
+	if !ok {
+		return domain.Schedule{}, usecases.ErrScheduleNotFound
+	}
+	return s, nil
+}
+
+func (f fakeSchedules) Remove(id string) error {
+	if _, ok := f[id]; !ok {
+		return usecases.ErrScheduleNotFound
+	}
+	delete(f, id)
+	return nil
+}
+
+func newScheduleAPI(t *testing.T) (humatest.TestAPI, fakeSchedules) {
+	schedules := fakeSchedules{}
+	h := New(&fakeGenerator{}, nil, 5)
+	h.Schedules = schedules
+	h.Callbacks = &fakeCallbacks{done: make(chan struct{})}
+	_, api := humatest.New(t)
+	h.Register(api)
+	return api, schedules
+}
+
+func TestSchedules_Lifecycle(t *testing.T) {
+	api, schedules := newScheduleAPI(t)
+
+	resp := api.Post("/api/schedules", map[string]any{
+		"url":         "https://example.com",
+		"cron":        "0 3 * * *",
+		"timezone":    "Europe/Lisbon",
+		"webhook_url": "https://hooks.example/llms",
+		"language":    "en",
+	})
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d; body: %s", resp.Code, http.StatusCreated, resp.Body.String())
+	}
+	if loc := resp.Header().Get("Location"); loc != "/api/schedules/SCHED" {
+		t.Errorf("Location = %q", loc)
+	}
+	if s := schedules["SCHED"]; s.Timezone != "Europe/Lisbon" || s.WebhookURL != "https://hooks.example/llms" || s.Options.Language != "en" {
+		t.Errorf("registered %+v", s)
+	}
+
+	resp = api.Get("/api/schedules")
+	var list struct {
+		Schedules []ScheduleStatus `json:"schedules"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if len(list.Schedules) != 1 || list.Schedules[0].Cron != "0 3 * * *" || list.Schedules[0].LastRunAt != nil {
+		t.Errorf("schedules = %+v", list.Schedules)
+	}
+
+	if resp := api.Delete("/api/schedules/SCHED"); resp.Code != http.StatusNoContent {
+		t.Errorf("delete status = %d, want %d", resp.Code, http.StatusNoContent)
+	}
+	if resp := api.Get("/api/schedules/SCHED"); resp.Code != http.StatusNotFound {
+		t.Errorf("get after delete status = %d, want %d", resp.Code, http.StatusNotFound)
+	}
+}
+
+func TestSchedules_InvalidRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		body map[string]any
+	}{
+		{"bad url", map[string]any{"url": "ftp://example.com", "cron": "@daily"}},
+		{"bad webhook", map[string]any{"url": "https://example.com", "cron": "@daily", "webhook_url": "hooks"}},
+		{"internal webhook", map[string]any{"url": "https://example.com", "cron": "@daily", "webhook_url": "http://metadata.internal/"}},
+		{"bad cron", map[string]any{"url": "https://example.com", "cron": "never"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api, _ := newScheduleAPI(t)
+			if resp := api.Post("/api/schedules", tt.body); resp.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d; body: %s", resp.Code, http.StatusBadRequest, resp.Body.String())
+			}
+		})
+	}
+}
+
+func TestSchedules_WebhookNeedsCallbacks(t *testing.T) {
+	h := New(&fakeGenerator{}, nil, 5)
+	h.Schedules = fakeSchedules{}
+	_, api := humatest.New(t)
+	h.Register(api)
+
+	resp := api.Post("/api/schedules", map[string]any{"url": "https://example.com", "cron": "@daily", "webhook_url": "https://hooks.example/llms"})
+	if resp.Code != http.StatusBadRequest || !strings.Contains(resp.Body.String(), errCallbacksDisabled.Error()) {
+		t.Errorf("status = %d, want 400 with webhooks disabled: %s", resp.Code, resp.Body.String())
+	}
+}
+
+func TestSchedules_ScopedToOwner(t *testing.T) {
+	schedules := fakeSchedules{"THEIRS": {ID: "THEIRS", URL: "https://example.com", Cron: "@daily", Owner: "key:theirs"}}
+	h := New(&fakeGenerator{}, nil, 5)
+	h.Schedules = schedules
+	h.Access = &Access{
+		Keys:       []APIKey{{Name: "mine", Key: "k1"}, {Name: "theirs", Key: "k2"}},
+		RequireKey: true,
+		Quotas:     &usecases.Quotas{},
+	}
+	_, api := humatest.New(t)
+	h.Register(api)
+
+	if resp := api.Post("/api/schedules", "X-API-Key: k1", map[string]any{"url": "https://example.com", "cron": "@daily"}); resp.Code != http.StatusCreated {
+		t.Fatalf("status = %d: %s", resp.Code, resp.Body.String())
+	}
+	if owner := schedules["SCHED"].Owner; owner != "key:mine" {
+		t.Errorf("owner = %q, want key:mine", owner)
+	}
+
+	var list struct {
+		Schedules []ScheduleStatus `json:"schedules"`
+	}
+	if err := json.NewDecoder(api.Get("/api/schedules", "X-API-Key: k1").Body).Decode(&list); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if len(list.Schedules) != 1 || list.Schedules[0].ID != "SCHED" {
+		t.Errorf("schedules = %+v, want only the caller's", list.Schedules)
+	}
+	if resp := api.Get("/api/schedules/THEIRS", "X-API-Key: k1"); resp.Code != http.StatusNotFound {
+		t.Errorf("get status = %d, want 404 for another client's schedule", resp.Code)
+	}
+	if resp := api.Delete("/api/schedules/THEIRS", "X-API-Key: k1"); resp.Code != http.StatusNotFound || schedules["THEIRS"].ID == "" {
+		t.Errorf("delete status = %d, want 404 leaving the schedule", resp.Code)
+	}
+}
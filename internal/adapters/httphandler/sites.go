@@ -0,0 +1,84 @@
+package httphandler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/conditional"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+	"github.com/adsouza/llms.txt-generator/internal/usecases"
+)
+
+// SiteFileInput identifies a published file, with the validators of the copy
+// the client already has.
+type SiteFileInput struct {
+	conditional.Params
+	Host string `path:"host" doc:"Host the file was generated for, e.g. example.com"`
+}
+
+// SiteFileOutput is a published file, served as plain text.
+type SiteFileOutput struct {
+	ContentType  string    `header:"Content-Type"`
+	CacheControl string    `header:"Cache-Control"`
+	ETag         string    `header:"ETag"`
+	LastModified time.Time `header:"Last-Modified"`
+	Body         []byte
+}
+
+func (h *Handler) registerSites(api huma.API) {
+	huma.Register(api, huma.Operation{
+		OperationID: "get-site-llms-txt",
+		Method:      http.MethodGet,
+		Path:        "/sites/{host}/llms.txt",
+		Summary:     "Get the latest llms.txt generated for a host",
+		Tags:        []string{"Sites"},
+	}, h.siteFile("llms.txt", func(gen domain.Generation) string { return gen.LlmsTxt }))
+
+	huma.Register(api, huma.Operation{
+		OperationID: "get-site-llms-full-txt",
+		Method:      http.MethodGet,
+		Path:        "/sites/{host}/llms-full.txt",
+		Summary:     "Get the latest llms-full.txt generated for a host",
+		Tags:        []string{"Sites"},
+	}, h.siteFile("llms-full.txt", func(gen domain.Generation) string { return gen.LlmsFullTxt }))
+}
+
+// siteFile returns a handler serving the content that file picks from the
+// host's published generation. Clients revalidate with If-None-Match or
+// If-Modified-Since, and get 304 Not Modified while it is unchanged.
+func (h *Handler) siteFile(name string, file func(domain.Generation) string) func(context.Context, *SiteFileInput) (*SiteFileOutput, error) {
+	return func(_ context.Context, input *SiteFileInput) (*SiteFileOutput, error) {
+		gen, err := usecases.Published(h.History, input.Host)
+		if errors.Is(err, usecases.ErrGenerationNotFound) {
+			return nil, huma.Error404NotFound("no " + name + " has been generated for " + input.Host)
+		}
+		if err != nil {
+			return nil, huma.Error500InternalServerError("reading history failed: " + err.Error())
+		}
+		content := file(gen)
+		if content == "" {
+			return nil, huma.Error404NotFound("no " + name + " has been generated for " + input.Host)
+		}
+
+		sum := sha256.Sum256([]byte(content))
+		etag := hex.EncodeToString(sum[:16])
+		// HTTP dates have a resolution of one second.
+		modified := gen.StartedAt.UTC().Truncate(time.Second)
+		if err := input.PreconditionFailed(etag, modified); err != nil {
+			return nil, err
+		}
+		return &SiteFileOutput{
+			ContentType:  "text/plain; charset=utf-8",
+			CacheControl: "public, no-cache",
+			ETag:         `"` + etag + `"`,
+			LastModified: modified,
+			Body:         []byte(content),
+		}, nil
+	}
+}
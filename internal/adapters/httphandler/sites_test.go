@@ -0,0 +1,87 @@
+package httphandler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2/humatest"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+)
+
+func newSitesAPI(t *testing.T, gens ...domain.Generation) humatest.TestAPI {
+	h := New(&fakeGenerator{}, nil, 5)
+	h.History = &fakeHistory{gens: gens}
+	_, api := humatest.New(t)
+	h.Register(api)
+	return api
+}
+
+func TestSites_ServesPublishedFile(t *testing.T) {
+	api := newSitesAPI(t,
+		domain.Generation{ID: "PRIVATE", URL: "https://example.com", Authenticated: true, StartedAt: generatedAt, LlmsTxt: "# Private\n"},
+		domain.Generation{ID: "GEN1", URL: "https://example.com", StartedAt: generatedAt, LlmsTxt: "# Example\n"},
+	)
+
+	resp := api.Get("/sites/example.com/llms.txt")
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.Code, resp.Body.String())
+	}
+	if got := resp.Body.String(); got != "# Example\n" {
+		t.Errorf("body = %q, want the published llms.txt", got)
+	}
+	if got := resp.Header().Get("Content-Type"); got != "text/plain; charset=utf-8" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if got := resp.Header().Get("Last-Modified"); got != generatedAt.Format(http.TimeFormat) {
+		t.Errorf("Last-Modified = %q, want %q", got, generatedAt.Format(http.TimeFormat))
+	}
+	etag := resp.Header().Get("ETag")
+	if len(etag) < 3 || etag[0] != '"' {
+		t.Fatalf("ETag = %q, want a quoted tag", etag)
+	}
+
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{"matching ETag", "If-None-Match: " + etag, http.StatusNotModified},
+		{"stale ETag", `If-None-Match: "0000"`, http.StatusOK},
+		{"not modified since", "If-Modified-Since: " + generatedAt.Format(http.TimeFormat), http.StatusNotModified},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := api.Get("/sites/example.com/llms.txt", tt.header)
+			if resp.Code != tt.want {
+				t.Errorf("status = %d, want %d", resp.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestSites_NotFound(t *testing.T) {
+	api := newSitesAPI(t,
+		domain.Generation{ID: "FAILED", URL: "https://down.example", Error: "timeout"},
+		domain.Generation{ID: "GEN1", URL: "https://example.com", StartedAt: generatedAt, LlmsTxt: "# Example\n"},
+	)
+
+	tests := []struct {
+		name, host string
+	}{
+		{"no llms-full.txt generated", "example.com"},
+		{"only failures", "down.example"},
+		{"never generated", "missing.example"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := "/sites/" + tt.host + "/llms-full.txt"
+			if tt.host != "example.com" {
+				path = "/sites/" + tt.host + "/llms.txt"
+			}
+			if resp := api.Get(path); resp.Code != http.StatusNotFound {
+				t.Errorf("GET %s status = %d, want %d", path, resp.Code, http.StatusNotFound)
+			}
+		})
+	}
+}
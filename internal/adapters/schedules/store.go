@@ -0,0 +1,122 @@
+// Package schedules keeps registered schedules in a directory, one JSON file
+// each, so they survive restarts without a database.
+package schedules
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+	"github.com/adsouza/llms.txt-generator/internal/usecases"
+)
+
+// Store implements usecases.ScheduleStore.
+type Store struct {
+	Dir string
+}
+
+// Open returns a Store keeping its files in dir, which is created if needed.
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &Store{Dir: dir}, nil
+}
+
+// List implements usecases.ScheduleStore, ordering schedules by creation.
+// Files that cannot be decoded are skipped.
+func (s *Store) List() ([]domain.Schedule, error) {
+	names, err := filepath.Glob(filepath.Join(s.Dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]domain.Schedule, 0, len(names))
+	for _, name := range names {
+		schedule, err := readSchedule(name)
+		if err != nil {
+			continue
+		}
+		out = append(out, schedule)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+// Get implements usecases.ScheduleStore.
+func (s *Store) Get(id string) (domain.Schedule, error) {
+	name, ok := s.file(id)
+	if !ok {
+		return domain.Schedule{}, usecases.ErrScheduleNotFound
+	}
+	schedule, err := readSchedule(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return domain.Schedule{}, usecases.ErrScheduleNotFound
+	}
+	return schedule, err
+}
+
+// Put implements usecases.ScheduleStore. The file is written to a temporary
+// file and renamed into place, so a crash never leaves it half written.
+func (s *Store) Put(schedule domain.Schedule) error {
+	name, ok := s.file(schedule.ID)
+	if !ok {
+		return fmt.Errorf("invalid schedule ID %q", schedule.ID)
+	}
+	data, err := json.Marshal(schedule)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.Dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+// Delete implements usecases.ScheduleStore.
+func (s *Store) Delete(id string) error {
+	name, ok := s.file(id)
+	if !ok {
+		return usecases.ErrScheduleNotFound
+	}
+	err := os.Remove(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return usecases.ErrScheduleNotFound
+	}
+	return err
+}
+
+// file returns the path of the schedule with the given ID, or false if the
+// ID could name a file outside the directory.
+func (s *Store) file(id string) (string, bool) {
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		return "", false
+	}
+	return filepath.Join(s.Dir, id+".json"), true
+}
+
+func readSchedule(name string) (domain.Schedule, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return domain.Schedule{}, err
+	}
+	var schedule domain.Schedule
+	if err := json.Unmarshal(data, &schedule); err != nil {
+		return domain.Schedule{}, fmt.Errorf("%s: %w", filepath.Base(name), err)
+	}
+	return schedule, nil
+}
@@ -0,0 +1,83 @@
+package schedules
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+	"github.com/adsouza/llms.txt-generator/internal/usecases"
+)
+
+func TestStore_PutGetListDelete(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	created := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	older := domain.Schedule{ID: "OLDER", URL: "https://a.example", Cron: "@daily", CreatedAt: created}
+	newer := domain.Schedule{ID: "NEWER", URL: "https://b.example", Cron: "@hourly", CreatedAt: created.Add(time.Hour), LastLlmsTxt: "# B\n"}
+	for _, schedule := range []domain.Schedule{newer, older} {
+		if err := s.Put(schedule); err != nil {
+			t.Fatalf("Put() error: %v", err)
+		}
+	}
+
+	// A restart sees the same schedules.
+	reopened, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	all, err := reopened.List()
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if len(all) != 2 || all[0].ID != "OLDER" || all[1].ID != "NEWER" {
+		t.Fatalf("List() = %+v, want both, oldest first", all)
+	}
+	got, err := reopened.Get("NEWER")
+	if err != nil || got.LastLlmsTxt != "# B\n" || !got.CreatedAt.Equal(newer.CreatedAt) {
+		t.Errorf("Get() = %+v, %v", got, err)
+	}
+
+	if err := reopened.Delete("OLDER"); err != nil {
+		t.Fatalf("Delete() error: %v", err)
+	}
+	if _, err := reopened.Get("OLDER"); !errors.Is(err, usecases.ErrScheduleNotFound) {
+		t.Errorf("Get(deleted) error = %v, want ErrScheduleNotFound", err)
+	}
+	if err := reopened.Delete("OLDER"); !errors.Is(err, usecases.ErrScheduleNotFound) {
+		t.Errorf("Delete(deleted) error = %v, want ErrScheduleNotFound", err)
+	}
+}
+
+func TestStore_RejectsPathsAndSkipsCorruptFiles(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	if _, err := s.Get("../secrets"); !errors.Is(err, usecases.ErrScheduleNotFound) {
+		t.Errorf("Get(../secrets) error = %v, want ErrScheduleNotFound", err)
+	}
+	if err := s.Put(domain.Schedule{ID: "a/b"}); err == nil {
+		t.Error("Put() with a path in the ID succeeded")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "BROKEN.json"), []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put(domain.Schedule{ID: "GOOD", URL: "https://example.com"}); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	all, err := s.List()
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if len(all) != 1 || all[0].ID != "GOOD" {
+		t.Errorf("List() = %+v, want only the readable schedule", all)
+	}
+}
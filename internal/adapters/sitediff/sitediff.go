@@ -0,0 +1,79 @@
+// Package sitediff is the JSON form of a domain.SiteDiff, shared by the diff
+// endpoint and change webhooks so that both describe changes the same way.
+package sitediff
+
+import "github.com/adsouza/llms.txt-generator/internal/domain"
+
+// TextChange is a changed piece of text.
+type TextChange struct {
+	Old string `json:"old" doc:"Text in the previous version"`
+	New string `json:"new" doc:"Text in the current version"`
+}
+
+// DiffPage is a page added or removed.
+type DiffPage struct {
+	URL     string `json:"url" doc:"Page URL"`
+	Title   string `json:"title" doc:"Page title"`
+	Section string `json:"section" doc:"Section the page is listed under"`
+}
+
+// PageMove is a page listed under a different section.
+type PageMove struct {
+	URL   string `json:"url" doc:"Page URL"`
+	Title string `json:"title" doc:"Page title in the current version"`
+	From  string `json:"from" doc:"Section in the previous version"`
+	To    string `json:"to" doc:"Section in the current version"`
+}
+
+// PageEdit is a page whose title or description changed.
+type PageEdit struct {
+	URL         string      `json:"url" doc:"Page URL"`
+	Section     string      `json:"section" doc:"Section in the current version"`
+	Title       *TextChange `json:"title,omitempty" doc:"Title change, omitted if unchanged"`
+	Description *TextChange `json:"description,omitempty" doc:"Description change, omitted if unchanged"`
+}
+
+// SiteChanges lists what changed between two versions of a site's llms.txt.
+// Pages are matched by URL.
+type SiteChanges struct {
+	Name        *TextChange `json:"name,omitempty" doc:"Site name change, omitted if unchanged"`
+	Description *TextChange `json:"description,omitempty" doc:"Site description change, omitted if unchanged"`
+	Added       []DiffPage  `json:"added" doc:"Pages only in the current version"`
+	Removed     []DiffPage  `json:"removed" doc:"Pages only in the previous version"`
+	Moved       []PageMove  `json:"moved" doc:"Pages listed under a different section"`
+	Edited      []PageEdit  `json:"edited" doc:"Pages whose title or description changed"`
+}
+
+// From converts a domain.SiteDiff. Empty lists are kept as empty arrays.
+func From(d domain.SiteDiff) SiteChanges {
+	out := SiteChanges{
+		Name:        textChange(d.Name),
+		Description: textChange(d.Description),
+		Added:       diffPages(d.Added),
+		Removed:     diffPages(d.Removed),
+		Moved:       make([]PageMove, len(d.Moved)),
+		Edited:      make([]PageEdit, len(d.Edited)),
+	}
+	for i, m := range d.Moved {
+		out.Moved[i] = PageMove{URL: m.URL, Title: m.Title, From: m.From, To: m.To}
+	}
+	for i, e := range d.Edited {
+		out.Edited[i] = PageEdit{URL: e.URL, Section: e.Section, Title: textChange(e.Title), Description: textChange(e.Description)}
+	}
+	return out
+}
+
+func diffPages(pages []domain.DiffPage) []DiffPage {
+	out := make([]DiffPage, len(pages))
+	for i, p := range pages {
+		out[i] = DiffPage{URL: p.URL, Title: p.Title, Section: p.Section}
+	}
+	return out
+}
+
+func textChange(c *domain.TextChange) *TextChange {
+	if c == nil {
+		return nil
+	}
+	return &TextChange{Old: c.Old, New: c.New}
+}
@@ -0,0 +1,40 @@
+package sitediff
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+)
+
+func TestFrom(t *testing.T) {
+	tests := []struct {
+		name string
+		diff domain.SiteDiff
+		want string
+	}{
+		{"empty", domain.SiteDiff{}, `{"added":[],"removed":[],"moved":[],"edited":[]}`},
+		{"changes", domain.SiteDiff{
+			Name:    &domain.TextChange{Old: "Old", New: "New"},
+			Added:   []domain.DiffPage{{URL: "https://example.com/new", Title: "New", Section: "Docs"}},
+			Removed: []domain.DiffPage{{URL: "https://example.com/old", Title: "Old", Section: "Docs"}},
+			Moved:   []domain.PageMove{{URL: "https://example.com/api", Title: "API", From: "Docs", To: "Reference"}},
+			Edited:  []domain.PageEdit{{URL: "https://example.com/intro", Section: "Docs", Description: &domain.TextChange{New: "Start here"}}},
+		}, `{"name":{"old":"Old","new":"New"},` +
+			`"added":[{"url":"https://example.com/new","title":"New","section":"Docs"}],` +
+			`"removed":[{"url":"https://example.com/old","title":"Old","section":"Docs"}],` +
+			`"moved":[{"url":"https://example.com/api","title":"API","from":"Docs","to":"Reference"}],` +
+			`"edited":[{"url":"https://example.com/intro","section":"Docs","description":{"old":"","new":"Start here"}}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(From(tt.diff))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("From() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"github.com/adsouza/llms.txt-generator/internal/adapters/sitediff"
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+)
+
+// EventChanged is the event of change notifications.
+const EventChanged = "llms_txt.changed"
+
+// ChangePayload is the body posted when a scheduled site's llms.txt changes.
+type ChangePayload struct {
+	Event       string               `json:"event"`
+	ScheduleID  string               `json:"schedule_id"`
+	URL         string               `json:"url"`
+	GeneratedAt time.Time            `json:"generated_at"`
+	HistoryID   string               `json:"history_id,omitempty"`
+	Changes     sitediff.SiteChanges `json:"changes"`
+	Unified     string               `json:"unified"`
+}
+
+// NotifyChange implements usecases.Notifier.
+func (c *Client) NotifyChange(ctx context.Context, webhookURL string, change domain.Change) error {
+	return c.Deliver(ctx, webhookURL, EventChanged, change.ScheduleID, ChangePayload{
+		Event:       EventChanged,
+		ScheduleID:  change.ScheduleID,
+		URL:         change.URL,
+		GeneratedAt: change.GeneratedAt,
+		HistoryID:   change.HistoryID,
+		Changes:     sitediff.From(change.Diff.Changes),
+		Unified:     change.Diff.Unified,
+	})
+}
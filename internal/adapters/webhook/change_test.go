@@ -0,0 +1,71 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+)
+
+func TestClient_NotifyChange(t *testing.T) {
+	var got map[string]any
+	var contentType, userAgent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType, userAgent = r.Header.Get("Content-Type"), r.Header.Get("User-Agent")
+		if r.Header.Get(HeaderEvent) != EventChanged {
+			t.Errorf("%s = %q, want %q", HeaderEvent, r.Header.Get(HeaderEvent), EventChanged)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decoding payload: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	c := &Client{HTTP: srv.Client(), UserAgent: "llms-txt-generator/1.0", Secret: "s3cret"}
+	change := domain.Change{
+		ScheduleID:  "SCHED",
+		URL:         "https://example.com",
+		GeneratedAt: time.Date(2026, 10, 14, 3, 0, 0, 0, time.UTC),
+		HistoryID:   "GEN",
+		Diff: domain.Diff{
+			Changes: domain.SiteDiff{
+				Added: []domain.DiffPage{{URL: "https://example.com/new", Title: "New", Section: "Docs"}},
+			},
+			Unified: "+- [New](https://example.com/new)\n",
+		},
+	}
+	if err := c.NotifyChange(context.Background(), srv.URL, change); err != nil {
+		t.Fatalf("NotifyChange() error: %v", err)
+	}
+
+	if contentType != "application/json" || userAgent != "llms-txt-generator/1.0" {
+		t.Errorf("Content-Type = %q, User-Agent = %q", contentType, userAgent)
+	}
+	if got["event"] != "llms_txt.changed" || got["schedule_id"] != "SCHED" || got["history_id"] != "GEN" || got["generated_at"] != "2026-10-14T03:00:00Z" {
+		t.Errorf("payload = %v", got)
+	}
+	changes, _ := got["changes"].(map[string]any)
+	added, _ := changes["added"].([]any)
+	if len(added) != 1 || changes["removed"] == nil {
+		t.Errorf("changes = %v, want one added page and empty lists", changes)
+	}
+}
+
+func TestClient_NotifyChangeFails(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	c := &Client{HTTP: srv.Client(), Secret: "s3cret", MaxAttempts: 1}
+	err := c.NotifyChange(context.Background(), srv.URL, domain.Change{})
+	if err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("NotifyChange() error = %v, want the 500 status", err)
+	}
+}
@@ -0,0 +1,249 @@
+// Package webhook delivers notifications to URLs registered by API clients.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+	"github.com/adsouza/llms.txt-generator/internal/usecases"
+)
+
+const (
+	defaultMaxAttempts = 5
+	defaultRetryDelay  = time.Second
+	defaultLogSize     = 1000
+)
+
+// Headers sent with every notification. The delivery ID is the same for
+// every attempt, so receivers can ignore duplicates.
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Client posts JSON notifications to webhooks, retrying failed attempts with
+// exponential backoff and logging each delivery. Its HTTP client should guard
+// against requests to internal addresses, as the crawler's does, since
+// webhook URLs come from API clients.
+type Client struct {
+	HTTP      *http.Client
+	UserAgent string
+
+	// Secret signs every notification: the X-Webhook-Signature header
+	// carries "sha256=" and the hex HMAC-SHA256 of the X-Webhook-Timestamp
+	// header, a dot and the body. Without one, every URL is refused with
+	// ErrNoSecret, so that notifications never go out unsigned.
+	Secret string
+
+	// MaxAttempts bounds the attempts per notification. Defaults to 5.
+	MaxAttempts int
+
+	// RetryDelay is the wait before the second attempt, doubled before each
+	// later one. Defaults to 1s.
+	RetryDelay time.Duration
+
+	// CheckHost, if set, vets a URL's host before it is contacted, so that a
+	// blocked address is rejected up front rather than on every attempt.
+	CheckHost func(ctx context.Context, host string) error
+
+	// LogSize bounds the number of deliveries logged. Defaults to 1000.
+	LogSize int
+
+	mu  sync.Mutex
+	log []*domain.Delivery // oldest first
+}
+
+// ErrNoSecret is returned for every URL when the Client has no Secret.
+var ErrNoSecret = errors.New("webhooks.secret is not configured, so notifications cannot be signed")
+
+// Signature returns the X-Webhook-Signature value for a notification body
+// sent at timestamp, for receivers to compare against.
+func Signature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Check reports whether webhookURL may be notified: the Client must have a
+// Secret, and webhookURL must be an http or https URL whose host passes
+// CheckHost.
+func (c *Client) Check(ctx context.Context, webhookURL string) error {
+	if c.Secret == "" {
+		return ErrNoSecret
+	}
+	u, err := url.Parse(webhookURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("must be a valid http or https URL")
+	}
+	if c.CheckHost != nil {
+		return c.CheckHost(ctx, u.Hostname())
+	}
+	return nil
+}
+
+// Deliver posts payload as JSON to webhookURL, retrying network errors,
+// timeouts, 429 and 5xx responses until MaxAttempts is reached or ctx is
+// done. Subject names the job or schedule the notification is about in the
+// delivery log, and the owner in ctx the client it belongs to.
+func (c *Client) Deliver(ctx context.Context, webhookURL, event, subject string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	d := c.begin(domain.Delivery{ID: rand.Text(), Event: event, Subject: subject, Owner: usecases.Owner(ctx), URL: webhookURL, CreatedAt: time.Now()})
+
+	if err := c.Check(ctx, webhookURL); err != nil {
+		c.update(d, func(d *domain.Delivery) { d.Error, d.FinishedAt = err.Error(), time.Now() })
+		return err
+	}
+
+	delay := c.retryDelay()
+	for attempt := 1; ; attempt++ {
+		status, err := c.attempt(ctx, webhookURL, d.ID, event, body)
+		last := err == nil || attempt >= c.maxAttempts() || !retryable(status, err)
+		c.update(d, func(d *domain.Delivery) {
+			d.Attempts, d.Status, d.Error = attempt, status, ""
+			if err != nil {
+				d.Error = err.Error()
+			}
+			d.Delivered = err == nil
+			if last {
+				d.FinishedAt = time.Now()
+			}
+		})
+		if last {
+			return err
+		}
+		select {
+		case <-time.After(delay):
+			delay *= 2
+		case <-ctx.Done():
+			c.update(d, func(d *domain.Delivery) { d.FinishedAt = time.Now() })
+			return ctx.Err()
+		}
+	}
+}
+
+// attempt posts body once, returning the response status, if any.
+func (c *Client) attempt(ctx context.Context, webhookURL, id, event string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, event)
+	req.Header.Set(HeaderDelivery, id)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Signature(c.Secret, timestamp, body))
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with HTTP %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// retryable reports whether a failed attempt may succeed if repeated. A
+// certificate or handshake failure will not, and retrying it would only
+// resend the notification to a receiver that could not be verified.
+func retryable(status int, err error) bool {
+	if status == 0 {
+		return !errors.Is(err, domain.ErrBlockedAddress) && !errors.Is(err, context.Canceled) && !tlsFailure(err)
+	}
+	return status == http.StatusRequestTimeout || status == http.StatusTooManyRequests || status >= 500
+}
+
+// tlsFailure reports whether err is a TLS handshake or certificate error.
+func tlsFailure(err error) bool {
+	var (
+		verification *tls.CertificateVerificationError
+		alert        tls.AlertError
+		record       tls.RecordHeaderError
+		unknownCA    x509.UnknownAuthorityError
+		hostname     x509.HostnameError
+		invalid      x509.CertificateInvalidError
+	)
+	return errors.As(err, &verification) || errors.As(err, &alert) || errors.As(err, &record) ||
+		errors.As(err, &unknownCA) || errors.As(err, &hostname) || errors.As(err, &invalid)
+}
+
+// Deliveries returns the logged deliveries about subject, or all of them if
+// subject is empty, newest first.
+func (c *Client) Deliveries(subject string) []domain.Delivery {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var out []domain.Delivery
+	for i := len(c.log) - 1; i >= 0; i-- {
+		if subject == "" || c.log[i].Subject == subject {
+			out = append(out, *c.log[i])
+		}
+	}
+	return out
+}
+
+// begin adds a delivery to the log, dropping the oldest beyond LogSize.
+func (c *Client) begin(d domain.Delivery) *domain.Delivery {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.log = append(c.log, &d)
+	if size := c.logSize(); len(c.log) > size {
+		c.log = append([]*domain.Delivery(nil), c.log[len(c.log)-size:]...)
+	}
+	return &d
+}
+
+// update changes a logged delivery under the log's lock.
+func (c *Client) update(d *domain.Delivery, change func(*domain.Delivery)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	change(d)
+}
+
+func (c *Client) maxAttempts() int {
+	if c.MaxAttempts > 0 {
+		return c.MaxAttempts
+	}
+	return defaultMaxAttempts
+}
+
+func (c *Client) retryDelay() time.Duration {
+	if c.RetryDelay > 0 {
+		return c.RetryDelay
+	}
+	return defaultRetryDelay
+}
+
+func (c *Client) logSize() int {
+	if c.LogSize > 0 {
+		return c.LogSize
+	}
+	return defaultLogSize
+}
@@ -0,0 +1,154 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+)
+
+func TestClient_DeliverSigns(t *testing.T) {
+	var body []byte
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	c := &Client{HTTP: srv.Client(), Secret: "s3cret"}
+	if err := c.Deliver(context.Background(), srv.URL, "job.succeeded", "JOB", map[string]string{"id": "JOB"}); err != nil {
+		t.Fatalf("Deliver() error: %v", err)
+	}
+
+	if string(body) != `{"id":"JOB"}` {
+		t.Errorf("body = %s", body)
+	}
+	if header.Get(HeaderEvent) != "job.succeeded" || header.Get(HeaderDelivery) == "" {
+		t.Errorf("event = %q, delivery = %q", header.Get(HeaderEvent), header.Get(HeaderDelivery))
+	}
+	want := Signature("s3cret", header.Get(HeaderTimestamp), body)
+	if got := header.Get(HeaderSignature); got != want || !strings.HasPrefix(got, "sha256=") {
+		t.Errorf("signature = %q, want %q", got, want)
+	}
+
+	log := c.Deliveries("JOB")
+	if len(log) != 1 || !log[0].Delivered || log[0].Attempts != 1 || log[0].Status != http.StatusOK || log[0].FinishedAt.IsZero() {
+		t.Errorf("log = %+v, want one delivered attempt", log)
+	}
+}
+
+func TestClient_DeliverRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		wantAttempts int
+		wantErr      bool
+	}{
+		{"recovers after server errors", []int{503, 429, 200}, 3, false},
+		{"gives up after max attempts", []int{500, 500, 500, 500}, 3, true},
+		{"does not retry client errors", []int{404, 200}, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			var ids []string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ids = append(ids, r.Header.Get(HeaderDelivery))
+				w.WriteHeader(tt.statuses[calls.Add(1)-1])
+			}))
+			defer srv.Close()
+
+			c := &Client{HTTP: srv.Client(), Secret: "s3cret", MaxAttempts: 3, RetryDelay: time.Millisecond}
+			err := c.Deliver(context.Background(), srv.URL, "job.failed", "JOB", struct{}{})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Deliver() error = %v, want error %v", err, tt.wantErr)
+			}
+			if int(calls.Load()) != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", calls.Load(), tt.wantAttempts)
+			}
+			for _, id := range ids {
+				if id != ids[0] {
+					t.Errorf("delivery IDs %v differ between attempts", ids)
+				}
+			}
+			log := c.Deliveries("")
+			if len(log) != 1 || log[0].Attempts != tt.wantAttempts || log[0].Delivered == tt.wantErr {
+				t.Errorf("log = %+v", log)
+			}
+		})
+	}
+}
+
+func TestClient_DeliverRejectsBlockedHost(t *testing.T) {
+	c := &Client{
+		HTTP:   http.DefaultClient,
+		Secret: "s3cret",
+		CheckHost: func(_ context.Context, host string) error {
+			return fmt.Errorf("%s: %w", host, domain.ErrBlockedAddress)
+		},
+	}
+	err := c.Deliver(context.Background(), "http://169.254.169.254/latest", "job.succeeded", "JOB", struct{}{})
+	if !errors.Is(err, domain.ErrBlockedAddress) {
+		t.Fatalf("Deliver() error = %v, want ErrBlockedAddress", err)
+	}
+	log := c.Deliveries("JOB")
+	if len(log) != 1 || log[0].Attempts != 0 || log[0].Delivered || !strings.Contains(log[0].Error, "not publicly routable") {
+		t.Errorf("log = %+v, want the rejection without attempts", log)
+	}
+}
+
+func TestClient_DeliveryLogIsBounded(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer srv.Close()
+
+	c := &Client{HTTP: srv.Client(), Secret: "s3cret", LogSize: 2}
+	for i := range 3 {
+		if err := c.Deliver(context.Background(), srv.URL, "job.succeeded", fmt.Sprint(i), struct{}{}); err != nil {
+			t.Fatalf("Deliver() error: %v", err)
+		}
+	}
+	log := c.Deliveries("")
+	if len(log) != 2 || log[0].Subject != "2" || log[1].Subject != "1" {
+		t.Errorf("log = %+v, want the two newest deliveries, newest first", log)
+	}
+}
+
+func TestClient_RefusesWithoutSecret(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { calls.Add(1) }))
+	defer srv.Close()
+
+	c := &Client{HTTP: srv.Client()}
+	if err := c.Check(context.Background(), srv.URL); !errors.Is(err, ErrNoSecret) {
+		t.Errorf("Check() error = %v, want ErrNoSecret", err)
+	}
+	if err := c.Deliver(context.Background(), srv.URL, "job.succeeded", "JOB", struct{}{}); !errors.Is(err, ErrNoSecret) {
+		t.Errorf("Deliver() error = %v, want ErrNoSecret", err)
+	}
+	if calls.Load() != 0 {
+		t.Errorf("%d unsigned notifications sent", calls.Load())
+	}
+}
+
+func TestClient_DoesNotRetryCertificateErrors(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer srv.Close()
+
+	// The default client does not trust the test server's certificate.
+	c := &Client{HTTP: &http.Client{}, Secret: "s3cret", MaxAttempts: 3, RetryDelay: time.Millisecond}
+	if err := c.Deliver(context.Background(), srv.URL, "job.succeeded", "JOB", struct{}{}); err == nil {
+		t.Fatal("Deliver() succeeded with an untrusted certificate")
+	}
+	if log := c.Deliveries("JOB"); len(log) != 1 || log[0].Attempts != 1 || log[0].FinishedAt.IsZero() {
+		t.Errorf("log = %+v, want one failed attempt", log)
+	}
+}
@@ -1,10 +1,35 @@
 package domain
 
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+)
+
 // Page represents a single web page discovered during crawling.
 type Page struct {
 	URL         string
 	Title       string
 	Description string
+	Content     string            // full text for llms-full.txt, for text and Markdown documents
+	Lang        string            // language declared by <html lang>, if any
+	Alternates  map[string]string // hreflang code → URL of each translation
+
+	// Validators and hash of the response the page was read from, used to
+	// tell whether it has changed since a previous crawl.
+	ETag         string
+	LastModified string
+	ContentHash  string // hex SHA-256 of the response body
+}
+
+// Snapshot records the pages of a crawl, so that the next crawl of the site
+// can revalidate them instead of downloading and parsing them again.
+type Snapshot struct {
+	SiteURL   string
+	CreatedAt time.Time
+	Pages     []Page // pages fetched successfully; failed pages are fetched again
 }
 
 // Section groups related pages under a named heading.
@@ -21,13 +46,230 @@ type Site struct {
 	Optional    []Page // secondary links for the llms.txt "Optional" section
 }
 
+// PageError records a page that could not be fetched during crawling.
+type PageError struct {
+	URL    string
+	Status int // HTTP status code, or 0 if no response was received
+	Reason string
+}
+
+func (e *PageError) Error() string {
+	if e.Status != 0 {
+		return fmt.Sprintf("HTTP %d for %s: %s", e.Status, e.URL, e.Reason)
+	}
+	return fmt.Sprintf("%s: %s", e.URL, e.Reason)
+}
+
+// Options tunes a single generation.
+type Options struct {
+	// MaxErrorRatio enables strict mode when positive: generation fails if the
+	// fraction of pages that could not be fetched exceeds it.
+	MaxErrorRatio float64
+
+	// Language keeps only pages in this locale (a BCP 47 tag such as "en" or
+	// "pt-BR"). When empty, multilingual sites use the homepage's locale.
+	Language string
+
+	// PerLocale additionally produces one llms.txt for each locale found.
+	PerLocale bool
+
+	// Credentials authenticate the crawler to sites behind a login.
+	Credentials Credentials
+
+	// ForceRefresh crawls the site even if a cached result is available.
+	ForceRefresh bool
+
+	// Previous is the snapshot of an earlier crawl of the site. Its pages are
+	// revalidated with conditional requests and reused when unchanged.
+	Previous *Snapshot
+}
+
+// Credentials authenticate the crawler to a site. They are only sent to the
+// site's own host, and their values are redacted when formatted or logged.
+type Credentials struct {
+	Headers  map[string]string // extra request headers, such as Authorization
+	Cookies  map[string]string // cookie name → value
+	Username string            // HTTP basic auth, used when Username or Password is set
+	Password string
+}
+
+// IsZero reports whether no credentials are set.
+func (c Credentials) IsZero() bool {
+	return len(c.Headers) == 0 && len(c.Cookies) == 0 && c.Username == "" && c.Password == ""
+}
+
+// String describes which credentials are set without revealing their values.
+func (c Credentials) String() string {
+	var parts []string
+	if len(c.Headers) > 0 {
+		parts = append(parts, "headers: "+strings.Join(sortedKeys(c.Headers), ", "))
+	}
+	if len(c.Cookies) > 0 {
+		parts = append(parts, "cookies: "+strings.Join(sortedKeys(c.Cookies), ", "))
+	}
+	if c.Username != "" || c.Password != "" {
+		parts = append(parts, "basic auth: "+c.Username+":[REDACTED]")
+	}
+	return "{" + strings.Join(parts, "; ") + "}"
+}
+
+// GoString redacts credentials printed with %#v.
+func (c Credentials) GoString() string { return "domain.Credentials" + c.String() }
+
+// LogValue redacts credentials passed to log/slog.
+func (c Credentials) LogValue() slog.Value { return slog.StringValue(c.String()) }
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Result is the outcome of a successful generation.
+type Result struct {
+	LlmsTxt     string
+	LlmsFullTxt string            // empty unless the generator renders llms-full.txt
+	Locales     map[string]string // locale → llms.txt, when Options.PerLocale is set
+	Pages       int               // pages fetched successfully
+	Failures    []PageError       // pages that could not be fetched
+	GeneratedAt time.Time         // when the site was crawled, earlier than now for cached results
+	Snapshot    Snapshot          // the crawl, for regenerating the site incrementally
+	HistoryID   string            // ID of the generation's record in the history, if kept
+}
+
+// Generation is the record of one generation kept in the history, whether it
+// succeeded or not.
+type Generation struct {
+	ID            string
+	URL           string
+	Owner         string  // client that made it, if the server identifies clients
+	Options       Options // without credentials or a previous snapshot
+	Authenticated bool    // credentials were given; neither they nor the output are recorded
+	StartedAt     time.Time
+	Duration      time.Duration
+	Cached        bool        // answered from the result cache
+	Pages         int         // pages fetched successfully
+	Failures      []PageError // pages that could not be fetched
+	Error         string      // why the generation failed, empty on success
+	LlmsTxt       string
+	LlmsFullTxt   string
+	Locales       map[string]string
+}
+
+// Schedule registers a site for periodic regeneration.
+type Schedule struct {
+	ID         string
+	URL        string
+	Cron       string  // cron expression, such as "0 3 * * *"
+	Timezone   string  // IANA time zone the expression is evaluated in, UTC if empty
+	Options    Options // without credentials, which are never stored
+	WebhookURL string  // notified when the output changes, if set
+	Owner      string  // client that registered the schedule, empty without access control
+	CreatedAt  time.Time
+	NextRunAt  time.Time
+
+	LastRunAt     time.Time
+	LastHistoryID string // ID of the last successful generation in the history, if kept
+	LastLlmsTxt   string // output of the last successful run, compared with the next
+	LastError     string // why the last run failed, empty if it succeeded
+	NotifyError   string // why the last change notification failed, empty if it was delivered
+}
+
+// Change reports that a scheduled regeneration produced a different llms.txt.
+type Change struct {
+	ScheduleID  string
+	URL         string
+	GeneratedAt time.Time
+	HistoryID   string
+	Diff        Diff
+}
+
+// Delivery records the delivery of a notification to a webhook or callback URL.
+type Delivery struct {
+	ID         string // identifies the notification; the same for every attempt
+	Event      string // such as "job.succeeded" or "llms_txt.changed"
+	Subject    string // ID of the job or schedule the notification is about
+	Owner      string // client whose job or schedule it is, empty without access control
+	URL        string
+	CreatedAt  time.Time
+	FinishedAt time.Time // zero while attempts remain
+	Attempts   int
+	Status     int    // HTTP status of the last attempt, 0 if no response was received
+	Error      string // why the last attempt failed, empty once delivered
+	Delivered  bool
+}
+
 // ProgressEvent represents a streaming event during generation.
 type ProgressEvent struct {
-	Type       string   // "discovered", "progress", "done", "error"
-	URLs       []string // populated for "discovered"
-	CurrentURL string   // populated for "progress"
-	Done       int      // pages fetched so far, for "progress"
-	Total      int      // total pages to fetch, for "progress"
-	Result     string   // populated for "done"
-	Error      string   // populated for "error"
+	ID          int               // sequence number within a job's event stream, starting at 1
+	Type        string            // "queued", "discovered", "progress", "retry", "page_error", "done", "error"
+	Position    int               // place in the queue for a crawl slot, from 1, for "queued"
+	URLs        []string          // populated for "discovered"
+	CurrentURL  string            // populated for "progress", "retry" and "page_error"
+	Done        int               // pages processed so far, for "progress" and "page_error"
+	Total       int               // total pages to fetch, for "progress" and "page_error"
+	Attempt     int               // number of the attempt that failed, for "retry"
+	Status      int               // HTTP status code, for "retry" and "page_error" (0 if no response)
+	Result      string            // populated for "done"
+	FullResult  string            // llms-full.txt content, for "done" when rendered
+	Locales     map[string]string // populated for "done" when per-locale output was requested
+	Failures    []PageError       // populated for "done" and "error"
+	GeneratedAt time.Time         // when the result was generated, for "done"
+	HistoryID   string            // ID of the generation in the history, for "done" when kept
+	Error       string            // populated for "error", and the reason for "retry" and "page_error"
+}
+
+// SiteDiff describes what changed between two versions of a site's llms.txt.
+// Pages are matched by URL.
+type SiteDiff struct {
+	Name        *TextChange // nil when unchanged
+	Description *TextChange // nil when unchanged
+	Added       []DiffPage  // pages only in the new version
+	Removed     []DiffPage  // pages only in the old version
+	Moved       []PageMove  // pages listed under a different section
+	Edited      []PageEdit  // pages whose title or description changed
+}
+
+// IsEmpty reports whether the two versions list the same pages the same way.
+func (d SiteDiff) IsEmpty() bool {
+	return d.Name == nil && d.Description == nil &&
+		len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Moved) == 0 && len(d.Edited) == 0
+}
+
+// TextChange is a changed piece of text.
+type TextChange struct {
+	Old string
+	New string
+}
+
+// DiffPage is a page added or removed, with the section it is listed under.
+type DiffPage struct {
+	URL     string
+	Title   string
+	Section string
+}
+
+// PageMove is a page listed under a different section.
+type PageMove struct {
+	URL   string
+	Title string
+	From  string
+	To    string
+}
+
+// PageEdit is a page whose title or description changed.
+type PageEdit struct {
+	URL         string
+	Section     string      // section in the new version
+	Title       *TextChange // nil when unchanged
+	Description *TextChange // nil when unchanged
+}
+
+// Diff is a SiteDiff along with a unified diff of the two llms.txt texts.
+type Diff struct {
+	Changes SiteDiff
+	Unified string
 }
@@ -0,0 +1,7 @@
+package domain
+
+import "errors"
+
+// ErrBlockedAddress is returned when a URL resolves to an address the
+// service refuses to contact, such as a private or loopback network.
+var ErrBlockedAddress = errors.New("address is not publicly routable")
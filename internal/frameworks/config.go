@@ -0,0 +1,482 @@
+package frameworks
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/adsouza/llms.txt-generator/internal/adapters/crawler"
+)
+
+// Config is the server's configuration. It is loaded from defaults, then a
+// JSON file, then environment variables, then command-line flags, each
+// overriding the previous ones.
+type Config struct {
+	Listen           string        `json:"listen"`
+	MaxConcurrent    int           `json:"max_concurrent"`
+	BatchConcurrency int           `json:"batch_concurrency,omitempty"` // 0 for half of MaxConcurrent
+	MaxQueued        int           `json:"max_queued"`
+	QueueTimeout     Duration      `json:"queue_timeout"`
+	JobTTL           Duration      `json:"job_ttl"`
+	MaxJobs          int           `json:"max_jobs"`
+	SectionsFile     string        `json:"sections_file,omitempty"`
+	Cache            CacheConfig   `json:"cache"`
+	Storage          StorageConfig `json:"storage"`
+	Webhooks         WebhookConfig `json:"webhooks"`
+	Auth             AuthConfig    `json:"auth"`
+	Crawler          CrawlerConfig `json:"crawler"`
+}
+
+// AuthConfig configures API keys and the quotas of each client. Clients with
+// a key are limited per key, others per IP address.
+type AuthConfig struct {
+	Keys       APIKeys      `json:"keys,omitempty"`
+	RequireKey bool         `json:"require_key,omitempty"`
+	KeyLimits  LimitsConfig `json:"key_limits"` // for keys without their own limits
+	Anonymous  LimitsConfig `json:"anonymous"`
+	ProxyHops  int          `json:"proxy_hops,omitempty"`
+}
+
+// Enabled reports whether requests need to be checked at all.
+func (a AuthConfig) Enabled() bool {
+	return len(a.Keys) > 0 || a.Anonymous != (LimitsConfig{})
+}
+
+// LimitsConfig bounds what one client may use. Zero is unlimited.
+type LimitsConfig struct {
+	RequestsPerHour int `json:"requests_per_hour"`
+	PagesPerDay     int `json:"pages_per_day"`
+	ConcurrentJobs  int `json:"concurrent_jobs"`
+}
+
+// APIKeyConfig is an API key and the name of the client it identifies.
+type APIKeyConfig struct {
+	Name   string        `json:"name"`
+	Key    string        `json:"key"`
+	Limits *LimitsConfig `json:"limits,omitempty"` // defaults to AuthConfig.KeyLimits
+}
+
+// APIKeys are set by a flag as comma-separated name=key pairs.
+type APIKeys []APIKeyConfig
+
+func (k *APIKeys) String() string {
+	if k == nil {
+		return ""
+	}
+	names := make([]string, len(*k))
+	for i, key := range *k {
+		names[i] = key.Name + "=[REDACTED]"
+	}
+	return strings.Join(names, ",")
+}
+
+func (k *APIKeys) Set(value string) error {
+	var keys APIKeys
+	for _, pair := range strings.Split(value, ",") {
+		name, key, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return errors.New("want comma-separated name=key pairs")
+		}
+		keys = append(keys, APIKeyConfig{Name: name, Key: key})
+	}
+	*k = keys
+	return nil
+}
+
+// WebhookConfig configures the delivery of callbacks and webhooks.
+type WebhookConfig struct {
+	Secret      string   `json:"secret,omitempty"`
+	MaxAttempts int      `json:"max_attempts"`
+	RetryDelay  Duration `json:"retry_delay"`
+	Timeout     Duration `json:"timeout"`
+}
+
+// StorageConfig configures the files kept across restarts, such as the
+// history of generations.
+type StorageConfig struct {
+	Dir          string `json:"dir,omitempty"`
+	HistoryLimit int    `json:"history_limit"`
+}
+
+// CacheConfig configures the cache of results and fetched pages.
+type CacheConfig struct {
+	TTL      Duration `json:"ttl"`
+	Dir      string   `json:"dir,omitempty"`
+	MaxBytes int64    `json:"max_bytes"`
+}
+
+// CrawlerConfig configures how sites are crawled.
+type CrawlerConfig struct {
+	UserAgent         string   `json:"user_agent"`
+	ContactURL        string   `json:"contact_url,omitempty"`
+	MaxPages          int      `json:"max_pages"`
+	MaxDepth          int      `json:"max_depth"`
+	MaxAttempts       int      `json:"max_attempts"`
+	RetryDelay        Duration `json:"retry_delay"`
+	RequestTimeout    Duration `json:"request_timeout"`
+	DescriptionLength int      `json:"description_length"`
+	PageBytes         int64    `json:"page_bytes"`
+	DocBytes          int64    `json:"doc_bytes"`
+	SitemapBytes      int64    `json:"sitemap_bytes"`
+	RobotsBytes       int64    `json:"robots_bytes"`
+	Redirects         int      `json:"redirects"`
+	AllowNetworks     string   `json:"allow_networks,omitempty"`
+	Proxy             string   `json:"proxy,omitempty"`
+	CAFile            string   `json:"ca_file,omitempty"`
+	InsecureTLS       bool     `json:"insecure_tls,omitempty"`
+	PrerenderURL      string   `json:"prerender_url,omitempty"`
+	PrerenderToken    string   `json:"prerender_token,omitempty"`
+	// PrerenderBlocksPrivate confirms that the prerender service refuses to
+	// load private addresses, which the crawler's Guard cannot enforce for it.
+	PrerenderBlocksPrivate bool `json:"prerender_blocks_private,omitempty"`
+}
+
+// Invocation holds the command-line options that are not configuration.
+type Invocation struct {
+	ConfigFile  string
+	PrintConfig bool
+}
+
+// Duration is a time.Duration written as a string such as "10s".
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// DefaultConfig returns the configuration used when nothing is overridden.
+func DefaultConfig() Config {
+	return Config{
+		Listen:        ":8080",
+		MaxConcurrent: 5,
+		MaxQueued:     100,
+		QueueTimeout:  Duration(5 * time.Minute),
+		JobTTL:        Duration(time.Hour),
+		MaxJobs:       1000,
+		Cache: CacheConfig{
+			TTL:      Duration(time.Hour),
+			MaxBytes: 256 << 20,
+		},
+		Storage: StorageConfig{
+			HistoryLimit: 1000,
+		},
+		Webhooks: WebhookConfig{
+			MaxAttempts: 5,
+			RetryDelay:  Duration(time.Second),
+			Timeout:     Duration(10 * time.Second),
+		},
+		Crawler: CrawlerConfig{
+			UserAgent:         "llms-txt-generator/1.0",
+			MaxPages:          100,
+			MaxDepth:          3,
+			MaxAttempts:       3,
+			RetryDelay:        Duration(500 * time.Millisecond),
+			RequestTimeout:    Duration(10 * time.Second),
+			DescriptionLength: 200,
+			PageBytes:         5 << 20,
+			DocBytes:          20 << 20,
+			SitemapBytes:      50 << 20,
+			RobotsBytes:       500 << 10,
+			Redirects:         5,
+		},
+	}
+}
+
+// envVars maps each flag to the environment variable that also sets it.
+var envVars = []struct{ flag, env string }{
+	{"listen", "LISTEN_ADDR"},
+	{"max-concurrent", "MAX_CONCURRENT"},
+	{"batch-concurrency", "BATCH_CONCURRENCY"},
+	{"max-queued", "MAX_QUEUED"},
+	{"queue-timeout", "QUEUE_TIMEOUT"},
+	{"job-ttl", "JOB_TTL"},
+	{"max-jobs", "MAX_JOBS"},
+	{"sections-file", "SECTIONS_FILE"},
+	{"cache-ttl", "CACHE_TTL"},
+	{"cache-dir", "CACHE_DIR"},
+	{"cache-bytes", "CACHE_BYTES"},
+	{"storage-dir", "STORAGE_DIR"},
+	{"history-limit", "HISTORY_LIMIT"},
+	{"webhook-secret", "WEBHOOK_SECRET"},
+	{"webhook-attempts", "WEBHOOK_MAX_ATTEMPTS"},
+	{"webhook-retry-delay", "WEBHOOK_RETRY_DELAY"},
+	{"webhook-timeout", "WEBHOOK_TIMEOUT"},
+	{"api-keys", "API_KEYS"},
+	{"require-api-key", "REQUIRE_API_KEY"},
+	{"proxy-hops", "PROXY_HOPS"},
+	{"requests-per-hour", "RATE_REQUESTS_PER_HOUR"},
+	{"pages-per-day", "RATE_PAGES_PER_DAY"},
+	{"concurrent-jobs", "RATE_CONCURRENT_JOBS"},
+	{"key-requests-per-hour", "KEY_REQUESTS_PER_HOUR"},
+	{"key-pages-per-day", "KEY_PAGES_PER_DAY"},
+	{"key-concurrent-jobs", "KEY_CONCURRENT_JOBS"},
+	{"user-agent", "CRAWLER_USER_AGENT"},
+	{"contact-url", "CRAWLER_CONTACT_URL"},
+	{"max-pages", "CRAWLER_MAX_PAGES"},
+	{"max-depth", "CRAWLER_MAX_DEPTH"},
+	{"max-attempts", "CRAWLER_MAX_ATTEMPTS"},
+	{"retry-delay", "CRAWLER_RETRY_DELAY"},
+	{"request-timeout", "CRAWLER_REQUEST_TIMEOUT"},
+	{"description-length", "CRAWLER_DESCRIPTION_LENGTH"},
+	{"page-bytes", "CRAWLER_PAGE_BYTES"},
+	{"doc-bytes", "CRAWLER_DOC_BYTES"},
+	{"sitemap-bytes", "CRAWLER_SITEMAP_BYTES"},
+	{"robots-bytes", "CRAWLER_ROBOTS_BYTES"},
+	{"redirects", "CRAWLER_REDIRECTS"},
+	{"allow-networks", "CRAWLER_ALLOW_NETWORKS"},
+	{"proxy", "CRAWLER_PROXY"},
+	{"ca-file", "CRAWLER_CA_FILE"},
+	{"insecure-tls", "CRAWLER_INSECURE_TLS"},
+	{"prerender-url", "PRERENDER_URL"},
+	{"prerender-token", "PRERENDER_TOKEN"},
+	{"prerender-blocks-private", "PRERENDER_BLOCKS_PRIVATE"},
+}
+
+// newFlagSet binds the flags to cfg and inv, using their current values as defaults.
+func newFlagSet(cfg *Config, inv *Invocation) *flag.FlagSet {
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	fs.StringVar(&inv.ConfigFile, "config", inv.ConfigFile, "JSON config file (env CONFIG_FILE)")
+	fs.BoolVar(&inv.PrintConfig, "print-config", inv.PrintConfig, "print the effective configuration and exit")
+
+	fs.StringVar(&cfg.Listen, "listen", cfg.Listen, "listen address (env LISTEN_ADDR, or PORT)")
+	fs.IntVar(&cfg.MaxConcurrent, "max-concurrent", cfg.MaxConcurrent, "maximum concurrent crawls")
+	fs.IntVar(&cfg.BatchConcurrency, "batch-concurrency", cfg.BatchConcurrency, "crawl slots batches may use together (default half of max-concurrent)")
+	fs.IntVar(&cfg.MaxQueued, "max-queued", cfg.MaxQueued, "requests that may wait for a crawl slot; more are refused with 503")
+	fs.TextVar(&cfg.QueueTimeout, "queue-timeout", cfg.QueueTimeout, "how long a request may wait for a crawl slot")
+	fs.TextVar(&cfg.JobTTL, "job-ttl", cfg.JobTTL, "how long finished background jobs stay available")
+	fs.IntVar(&cfg.MaxJobs, "max-jobs", cfg.MaxJobs, "background jobs kept; beyond it, those that finished first are dropped")
+	fs.StringVar(&cfg.SectionsFile, "sections-file", cfg.SectionsFile, `JSON file mapping URL path segments to section names, e.g. {"kb": "Support"}`)
+
+	fs.TextVar(&cfg.Cache.TTL, "cache-ttl", cfg.Cache.TTL, "how long generated results are reused; 0 disables caching")
+	fs.StringVar(&cfg.Cache.Dir, "cache-dir", cfg.Cache.Dir, "directory that keeps the cache across restarts (default in memory)")
+	fs.Int64Var(&cfg.Cache.MaxBytes, "cache-bytes", cfg.Cache.MaxBytes, "maximum size of the cache, in memory or on disk")
+
+	fs.StringVar(&cfg.Storage.Dir, "storage-dir", cfg.Storage.Dir, "directory for the history of generations (default none, which disables it)")
+	fs.IntVar(&cfg.Storage.HistoryLimit, "history-limit", cfg.Storage.HistoryLimit, "generations kept in the history; 0 keeps all")
+
+	w := &cfg.Webhooks
+	fs.StringVar(&w.Secret, "webhook-secret", w.Secret, "key that signs callbacks and webhooks with HMAC-SHA256 (required for either)")
+	fs.IntVar(&w.MaxAttempts, "webhook-attempts", w.MaxAttempts, "attempts per callback or webhook notification")
+	fs.TextVar(&w.RetryDelay, "webhook-retry-delay", w.RetryDelay, "initial backoff between notification attempts")
+	fs.TextVar(&w.Timeout, "webhook-timeout", w.Timeout, "timeout per notification attempt")
+
+	a := &cfg.Auth
+	fs.Var(&a.Keys, "api-keys", "comma-separated name=key API keys (default none)")
+	fs.BoolVar(&a.RequireKey, "require-api-key", a.RequireKey, "reject API requests without a key instead of limiting them per IP address")
+	fs.IntVar(&a.ProxyHops, "proxy-hops", a.ProxyHops, "reverse proxies in front of the server appending to X-Forwarded-For")
+	fs.IntVar(&a.Anonymous.RequestsPerHour, "requests-per-hour", a.Anonymous.RequestsPerHour, "API requests per hour per IP address without a key; 0 is unlimited")
+	fs.IntVar(&a.Anonymous.PagesPerDay, "pages-per-day", a.Anonymous.PagesPerDay, "pages crawled per day per IP address without a key; 0 is unlimited")
+	fs.IntVar(&a.Anonymous.ConcurrentJobs, "concurrent-jobs", a.Anonymous.ConcurrentJobs, "generations at once per IP address without a key; 0 is unlimited")
+	fs.IntVar(&a.KeyLimits.RequestsPerHour, "key-requests-per-hour", a.KeyLimits.RequestsPerHour, "API requests per hour per API key; 0 is unlimited")
+	fs.IntVar(&a.KeyLimits.PagesPerDay, "key-pages-per-day", a.KeyLimits.PagesPerDay, "pages crawled per day per API key; 0 is unlimited")
+	fs.IntVar(&a.KeyLimits.ConcurrentJobs, "key-concurrent-jobs", a.KeyLimits.ConcurrentJobs, "generations at once per API key; 0 is unlimited")
+
+	c := &cfg.Crawler
+	fs.StringVar(&c.UserAgent, "user-agent", c.UserAgent, "crawler User-Agent; its product token selects the robots.txt group")
+	fs.StringVar(&c.ContactURL, "contact-url", c.ContactURL, "contact URL appended to the User-Agent")
+	fs.IntVar(&c.MaxPages, "max-pages", c.MaxPages, "maximum pages per site")
+	fs.IntVar(&c.MaxDepth, "max-depth", c.MaxDepth, "maximum link depth when a site has no sitemap")
+	fs.IntVar(&c.MaxAttempts, "max-attempts", c.MaxAttempts, "attempts per request for transient failures")
+	fs.TextVar(&c.RetryDelay, "retry-delay", c.RetryDelay, "initial backoff between attempts")
+	fs.TextVar(&c.RequestTimeout, "request-timeout", c.RequestTimeout, "timeout per request")
+	fs.IntVar(&c.DescriptionLength, "description-length", c.DescriptionLength, "maximum length of descriptions taken from page text")
+	fs.Int64Var(&c.PageBytes, "page-bytes", c.PageBytes, "maximum HTML, text or Markdown page size")
+	fs.Int64Var(&c.DocBytes, "doc-bytes", c.DocBytes, "maximum PDF size")
+	fs.Int64Var(&c.SitemapBytes, "sitemap-bytes", c.SitemapBytes, "maximum sitemap size")
+	fs.Int64Var(&c.RobotsBytes, "robots-bytes", c.RobotsBytes, "maximum robots.txt size")
+	fs.IntVar(&c.Redirects, "redirects", c.Redirects, "redirect hops followed per request")
+	fs.StringVar(&c.AllowNetworks, "allow-networks", c.AllowNetworks, "comma-separated networks exempt from the SSRF guard")
+	fs.StringVar(&c.Proxy, "proxy", c.Proxy, "HTTP, HTTPS or SOCKS5 proxy URL")
+	fs.StringVar(&c.CAFile, "ca-file", c.CAFile, "PEM bundle of additional trusted root certificates")
+	fs.BoolVar(&c.InsecureTLS, "insecure-tls", c.InsecureTLS, "skip TLS certificate verification of crawled sites (staging only)")
+	fs.StringVar(&c.PrerenderURL, "prerender-url", c.PrerenderURL, "Prerender or Rendertron endpoint for JavaScript-heavy pages")
+	fs.StringVar(&c.PrerenderToken, "prerender-token", c.PrerenderToken, "token sent to the prerender service")
+	fs.BoolVar(&c.PrerenderBlocksPrivate, "prerender-blocks-private", c.PrerenderBlocksPrivate, "confirm that the prerender service blocks private networks, as prerender-url requires")
+	return fs
+}
+
+// LoadConfig builds the configuration from args (without the program name)
+// and the environment, and validates it.
+func LoadConfig(args []string, getenv func(string) string) (Config, Invocation, error) {
+	// A first pass over the flags finds the config file. They are parsed
+	// again below so that they take precedence over the file and environment.
+	var inv Invocation
+	scratch := DefaultConfig()
+	if err := newFlagSet(&scratch, &inv).Parse(args); err != nil {
+		return Config{}, inv, err
+	}
+	if inv.ConfigFile == "" {
+		inv.ConfigFile = getenv("CONFIG_FILE")
+	}
+
+	cfg := DefaultConfig()
+	if inv.ConfigFile != "" {
+		if err := readConfigFile(inv.ConfigFile, &cfg); err != nil {
+			return Config{}, inv, err
+		}
+	}
+	if port := getenv("PORT"); port != "" {
+		cfg.Listen = ":" + port
+	}
+
+	fs := newFlagSet(&cfg, &inv)
+	fs.SetOutput(io.Discard)
+	for _, v := range envVars {
+		if value := getenv(v.env); value != "" {
+			if err := fs.Set(v.flag, value); err != nil {
+				return Config{}, inv, fmt.Errorf("invalid %s: %w", v.env, err)
+			}
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return Config{}, inv, err
+	}
+	return cfg, inv, cfg.Validate()
+}
+
+func readConfigFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(cfg); err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Validate reports every invalid setting.
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	_, _, err := net.SplitHostPort(c.Listen)
+	check(err == nil, "listen: invalid address %q", c.Listen)
+	check(c.MaxConcurrent >= 1, "max_concurrent: must be at least 1")
+	check(c.BatchConcurrency >= 0 && c.BatchConcurrency <= c.MaxConcurrent, "batch_concurrency: must be between 0 and max_concurrent")
+	check(c.MaxQueued >= 1, "max_queued: must be at least 1")
+	check(c.QueueTimeout > 0, "queue_timeout: must be positive")
+	check(c.JobTTL > 0, "job_ttl: must be positive")
+	check(c.MaxJobs >= 1, "max_jobs: must be at least 1")
+	check(c.Cache.TTL >= 0, "cache.ttl: must not be negative")
+	check(c.Cache.MaxBytes > 0, "cache.max_bytes: must be positive")
+	check(c.Storage.HistoryLimit >= 0, "storage.history_limit: must not be negative")
+	check(c.Webhooks.MaxAttempts >= 1, "webhooks.max_attempts: must be at least 1")
+	check(c.Webhooks.RetryDelay > 0, "webhooks.retry_delay: must be positive")
+	check(c.Webhooks.Timeout > 0, "webhooks.timeout: must be positive")
+
+	a := c.Auth
+	seen := make(map[string]bool)
+	for i, k := range a.Keys {
+		check(k.Name != "" && k.Key != "", "auth.keys[%d]: name and key must not be empty", i)
+		check(!seen[k.Key], "auth.keys[%d]: duplicate key", i)
+		seen[k.Key] = true
+		if k.Limits != nil {
+			check(k.Limits.valid(), "auth.keys[%d].limits: must not be negative", i)
+		}
+	}
+	check(!a.RequireKey || len(a.Keys) > 0, "auth.require_key: no keys are configured")
+	check(a.KeyLimits.valid(), "auth.key_limits: must not be negative")
+	check(a.Anonymous.valid(), "auth.anonymous: must not be negative")
+	check(a.ProxyHops >= 0, "auth.proxy_hops: must not be negative")
+
+	cr := c.Crawler
+	check(strings.TrimSpace(cr.UserAgent) != "", "crawler.user_agent: must not be empty")
+	check(cr.ContactURL == "" || isHTTPURL(cr.ContactURL), "crawler.contact_url: must be an http or https URL")
+	check(cr.MaxPages >= 1, "crawler.max_pages: must be at least 1")
+	check(cr.MaxDepth >= 1, "crawler.max_depth: must be at least 1")
+	check(cr.MaxAttempts >= 1, "crawler.max_attempts: must be at least 1")
+	check(cr.RetryDelay > 0, "crawler.retry_delay: must be positive")
+	check(cr.RequestTimeout > 0, "crawler.request_timeout: must be positive")
+	check(cr.DescriptionLength >= 1, "crawler.description_length: must be at least 1")
+	check(cr.PageBytes > 0, "crawler.page_bytes: must be positive")
+	check(cr.DocBytes > 0, "crawler.doc_bytes: must be positive")
+	check(cr.SitemapBytes > 0, "crawler.sitemap_bytes: must be positive")
+	check(cr.RobotsBytes > 0, "crawler.robots_bytes: must be positive")
+	check(cr.Redirects >= 1, "crawler.redirects: must be at least 1")
+	if _, err := crawler.ParseNetworks(cr.AllowNetworks); err != nil {
+		errs = append(errs, fmt.Errorf("crawler.allow_networks: %w", err))
+	}
+	if cr.Proxy != "" {
+		u, err := url.Parse(cr.Proxy)
+		check(err == nil && u.Host != "", "crawler.proxy: invalid URL")
+	}
+	check(cr.PrerenderURL == "" || isHTTPURL(cr.PrerenderURL), "crawler.prerender_url: must be an http or https URL")
+	check(cr.PrerenderURL == "" || cr.PrerenderBlocksPrivate,
+		"crawler.prerender_blocks_private: the prerender service loads pages and their scripts itself; block private networks in it and set this to confirm")
+	return errors.Join(errs...)
+}
+
+func (l LimitsConfig) valid() bool {
+	return l.RequestsPerHour >= 0 && l.PagesPerDay >= 0 && l.ConcurrentJobs >= 0
+}
+
+func isHTTPURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// Print writes the configuration as JSON with secrets redacted.
+func (c Config) Print(w io.Writer) error {
+	if c.Crawler.PrerenderToken != "" {
+		c.Crawler.PrerenderToken = "[REDACTED]"
+	}
+	if c.Webhooks.Secret != "" {
+		c.Webhooks.Secret = "[REDACTED]"
+	}
+	keys := make(APIKeys, len(c.Auth.Keys))
+	for i, k := range c.Auth.Keys {
+		k.Key = "[REDACTED]"
+		keys[i] = k
+	}
+	c.Auth.Keys = keys
+	if u, err := url.Parse(c.Crawler.Proxy); err == nil && c.Crawler.Proxy != "" {
+		c.Crawler.Proxy = u.Redacted()
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(c)
+}
+
+// LoadSectionNames reads a JSON object mapping URL path segments to section
+// names. Segments are matched case-insensitively.
+func LoadSectionNames(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading sections file: %w", err)
+	}
+	var raw map[string]string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("sections file %s: %w", path, err)
+	}
+	names := make(map[string]string, len(raw))
+	for segment, name := range raw {
+		if strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("sections file %s: empty section name for %q", path, segment)
+		}
+		names[strings.ToLower(segment)] = name
+	}
+	return names, nil
+}
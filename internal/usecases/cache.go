@@ -0,0 +1,81 @@
+package usecases
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+)
+
+// Cache stores serialized values by key. Implementations may evict entries at
+// any time, so a miss only costs the work of recomputing the value.
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	Put(key string, value []byte) error
+}
+
+// cachedResult returns the result stored under key if it is younger than the
+// cache TTL.
+func (s *Service) cachedResult(key string, now time.Time) (domain.Result, bool) {
+	data, ok := s.Cache.Get(key)
+	if !ok {
+		return domain.Result{}, false
+	}
+	var result domain.Result
+	if err := json.Unmarshal(data, &result); err != nil || now.Sub(result.GeneratedAt) > s.CacheTTL {
+		return domain.Result{}, false
+	}
+	return result, true
+}
+
+// storeResult caches a result without its snapshot, which is kept
+// separately. A result that cannot be stored is simply generated again next
+// time.
+func (s *Service) storeResult(key string, result domain.Result) {
+	result.Snapshot = domain.Snapshot{}
+	if data, err := json.Marshal(result); err == nil {
+		_ = s.Cache.Put(key, data)
+	}
+}
+
+// resultKey returns the cache key for generating siteURL with opts, or false
+// if the result must not be cached because it was crawled with credentials or
+// must be revalidated against a snapshot the caller supplied.
+func resultKey(siteURL string, opts domain.Options) (string, bool) {
+	if !opts.Credentials.IsZero() || opts.Previous != nil {
+		return "", false
+	}
+	u, err := url.Parse(siteURL)
+	if err != nil {
+		return "", false
+	}
+	key, _ := json.Marshal(struct {
+		URL           string
+		Language      string
+		PerLocale     bool
+		MaxErrorRatio float64
+	}{normalizeURL(u), strings.ToLower(opts.Language), opts.PerLocale, opts.MaxErrorRatio})
+	sum := sha256.Sum256(key)
+	return "result:" + hex.EncodeToString(sum[:]), true
+}
+
+// normalizeURL returns u in a canonical form, so that URLs differing only in
+// letter case of the scheme and host, a default port, an empty path or a
+// fragment share a cache entry.
+func normalizeURL(u *url.URL) string {
+	n := *u
+	n.Scheme = strings.ToLower(n.Scheme)
+	n.Host = strings.ToLower(n.Host)
+	if port := n.Port(); (n.Scheme == "http" && port == "80") || (n.Scheme == "https" && port == "443") {
+		n.Host = n.Hostname()
+	}
+	if n.Path == "" {
+		n.Path = "/"
+	}
+	n.Fragment, n.RawFragment = "", ""
+	return n.String()
+}
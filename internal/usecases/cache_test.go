@@ -0,0 +1,130 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+)
+
+// mapCache is an in-memory Cache without eviction.
+type mapCache map[string][]byte
+
+func (m mapCache) Get(key string) ([]byte, bool) {
+	v, ok := m[key]
+	return v, ok
+}
+
+func (m mapCache) Put(key string, value []byte) error {
+	m[key] = value
+	return nil
+}
+
+// countingCrawler counts the crawls of a fakeCrawler.
+type countingCrawler struct {
+	fakeCrawler
+	crawls int
+}
+
+func (c *countingCrawler) Discover(ctx context.Context, siteURL string) ([]string, error) {
+	c.crawls++
+	return c.fakeCrawler.Discover(ctx, siteURL)
+}
+
+func newCachedService(ttl time.Duration) (*Service, *countingCrawler, mapCache) {
+	crawler := &countingCrawler{fakeCrawler: fakeCrawler{pages: []domain.Page{
+		{URL: "https://example.com/", Title: "Example"},
+		{URL: "https://example.com/docs/intro", Title: "Intro"},
+	}}}
+	cache := mapCache{}
+	return &Service{Crawler: crawler, Formatter: &fakeFormatter{}, Cache: cache, CacheTTL: ttl}, crawler, cache
+}
+
+func TestGenerate_CachesResults(t *testing.T) {
+	svc, crawler, _ := newCachedService(time.Hour)
+
+	first, err := svc.Generate(context.Background(), "https://example.com", domain.Options{})
+	if err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	second, err := svc.Generate(context.Background(), "HTTPS://Example.com:443/#top", domain.Options{})
+	if err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	if crawler.crawls != 1 {
+		t.Errorf("crawls = %d, want 1", crawler.crawls)
+	}
+	if second.LlmsTxt != first.LlmsTxt || !second.GeneratedAt.Equal(first.GeneratedAt) {
+		t.Errorf("cached result = %+v, want %+v", second, first)
+	}
+
+	// Other options, or a forced refresh, crawl again.
+	_, _ = svc.Generate(context.Background(), "https://example.com", domain.Options{Language: "fr"})
+	_, _ = svc.Generate(context.Background(), "https://example.com", domain.Options{ForceRefresh: true})
+	if crawler.crawls != 3 {
+		t.Errorf("crawls = %d, want 3", crawler.crawls)
+	}
+	if third, _ := svc.Generate(context.Background(), "https://example.com", domain.Options{}); !third.GeneratedAt.After(first.GeneratedAt) {
+		t.Error("forced refresh did not replace the cached result")
+	}
+}
+
+func TestGenerate_CacheExpires(t *testing.T) {
+	svc, crawler, _ := newCachedService(time.Nanosecond)
+
+	for range 2 {
+		if _, err := svc.Generate(context.Background(), "https://example.com", domain.Options{}); err != nil {
+			t.Fatalf("Generate() error: %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if crawler.crawls != 2 {
+		t.Errorf("crawls = %d, want 2", crawler.crawls)
+	}
+}
+
+func TestGenerate_DoesNotCache(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   domain.Options
+		broken bool
+	}{
+		{"credentials", domain.Options{Credentials: domain.Credentials{Cookies: map[string]string{"session": "abc"}}}, false},
+		{"strict mode failure", domain.Options{MaxErrorRatio: 0.1}, true},
+		{"previous snapshot", domain.Options{Previous: &domain.Snapshot{SiteURL: "https://example.com"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, crawler, cache := newCachedService(time.Hour)
+			if tt.broken {
+				crawler.failing = map[string]error{"https://example.com/docs/intro": errors.New("boom")}
+			}
+			for range 2 {
+				_, _ = svc.Generate(context.Background(), "https://example.com", tt.opts)
+			}
+			if crawler.crawls != 2 || len(cache) != 0 {
+				t.Errorf("crawls = %d, cache entries = %d; want 2 crawls and nothing cached", crawler.crawls, len(cache))
+			}
+		})
+	}
+}
+
+func TestGenerateStream_CachedResult(t *testing.T) {
+	svc, _, _ := newCachedService(time.Hour)
+	_, _ = svc.Generate(context.Background(), "https://example.com", domain.Options{})
+
+	events := make(chan domain.ProgressEvent, 10)
+	go svc.GenerateStream(context.Background(), "https://example.com", domain.Options{}, events)
+	var types []string
+	for ev := range events {
+		types = append(types, ev.Type)
+		if ev.Type == "done" && ev.GeneratedAt.IsZero() {
+			t.Error("done event has no generation time")
+		}
+	}
+	if len(types) != 1 || types[0] != "done" {
+		t.Errorf("events = %v, want only done", types)
+	}
+}
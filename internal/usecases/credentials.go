@@ -0,0 +1,30 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+)
+
+type credentialsKey struct{}
+
+type siteCredentials struct {
+	creds   domain.Credentials
+	siteURL string
+}
+
+// WithCredentials returns a copy of ctx carrying the credentials for the site
+// at siteURL. Adapters must only send them to that site's host.
+func WithCredentials(ctx context.Context, siteURL string, creds domain.Credentials) context.Context {
+	if creds.IsZero() {
+		return ctx
+	}
+	return context.WithValue(ctx, credentialsKey{}, siteCredentials{creds: creds, siteURL: siteURL})
+}
+
+// SiteCredentials returns the credentials carried by ctx and the URL of the
+// site they belong to.
+func SiteCredentials(ctx context.Context) (creds domain.Credentials, siteURL string, ok bool) {
+	sc, ok := ctx.Value(credentialsKey{}).(siteCredentials)
+	return sc.creds, sc.siteURL, ok
+}
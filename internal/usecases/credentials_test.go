@@ -0,0 +1,64 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+)
+
+// credentialsCrawler records the credentials each call to the crawler carries.
+type credentialsCrawler struct {
+	fakeCrawler
+	siteURLs []string
+}
+
+func (c *credentialsCrawler) FetchPage(ctx context.Context, pageURL string) (domain.Page, error) {
+	if _, siteURL, ok := SiteCredentials(ctx); ok {
+		c.siteURLs = append(c.siteURLs, siteURL)
+	}
+	return c.fakeCrawler.FetchPage(ctx, pageURL)
+}
+
+func TestGenerate_PassesCredentialsToCrawler(t *testing.T) {
+	crawler := &credentialsCrawler{fakeCrawler: fakeCrawler{pages: []domain.Page{
+		{URL: "https://example.com/", Title: "Example"},
+		{URL: "https://example.com/docs", Title: "Docs"},
+	}}}
+	svc := &Service{Crawler: crawler, Formatter: &fakeFormatter{}}
+
+	opts := domain.Options{Credentials: domain.Credentials{Username: "ci", Password: "hunter2"}}
+	if _, err := svc.Generate(context.Background(), "https://example.com", opts); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	if len(crawler.siteURLs) != 2 || crawler.siteURLs[0] != "https://example.com" {
+		t.Errorf("credentials carried for %v, want both pages of https://example.com", crawler.siteURLs)
+	}
+}
+
+func TestWithCredentials_Empty(t *testing.T) {
+	ctx := WithCredentials(context.Background(), "https://example.com", domain.Credentials{})
+	if _, _, ok := SiteCredentials(ctx); ok {
+		t.Error("SiteCredentials() ok = true for empty credentials")
+	}
+}
+
+func TestCredentials_Redacted(t *testing.T) {
+	opts := domain.Options{Credentials: domain.Credentials{
+		Headers:  map[string]string{"Authorization": "Bearer s3cret-token"},
+		Cookies:  map[string]string{"session": "s3cret-cookie"},
+		Username: "ci",
+		Password: "s3cret-password",
+	}}
+	for _, format := range []string{"%v", "%+v", "%#v", "%s"} {
+		out := fmt.Sprintf(format, opts)
+		if strings.Contains(out, "s3cret") {
+			t.Errorf("Sprintf(%q) = %s, leaks a credential", format, out)
+		}
+		if !strings.Contains(out, "Authorization") || !strings.Contains(out, "session") {
+			t.Errorf("Sprintf(%q) = %s, want header and cookie names", format, out)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package usecases
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCron_Next(t *testing.T) {
+	from := time.Date(2026, 10, 14, 10, 17, 30, 0, time.UTC) // a Wednesday
+	tests := []struct {
+		expr, timezone string
+		want           time.Time
+	}{
+		{"* * * * *", "", time.Date(2026, 10, 14, 10, 18, 0, 0, time.UTC)},
+		{"*/15 * * * *", "", time.Date(2026, 10, 14, 10, 30, 0, 0, time.UTC)},
+		{"0 3 * * *", "", time.Date(2026, 10, 15, 3, 0, 0, 0, time.UTC)},
+		{"@daily", "", time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", "", time.Date(2026, 10, 14, 11, 0, 0, 0, time.UTC)},
+		{"30 9 * * 1-5", "", time.Date(2026, 10, 15, 9, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", "", time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", "", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 13 * 5", "", time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)}, // Friday or the 13th
+		{"0 12 29 2 *", "", time.Date(2028, 2, 29, 12, 0, 0, 0, time.UTC)},
+		{"0 3 * * *", "Asia/Tokyo", time.Date(2026, 10, 14, 18, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", "", time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr+" "+tt.timezone, func(t *testing.T) {
+			c, err := ParseCron(tt.expr, tt.timezone)
+			if err != nil {
+				t.Fatalf("ParseCron() error: %v", err)
+			}
+			if got := c.Next(from); !got.Equal(tt.want) {
+				t.Errorf("Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCron_Invalid(t *testing.T) {
+	tests := []struct{ expr, timezone string }{
+		{"* * * *", ""},
+		{"60 * * * *", ""},
+		{"* 24 * * *", ""},
+		{"* * 0 * *", ""},
+		{"5-1 * * * *", ""},
+		{"*/0 * * * *", ""},
+		{"a * * * *", ""},
+		{"@sometimes", ""},
+		{"* * * * *", "Mars/Olympus"},
+	}
+	for _, tt := range tests {
+		if _, err := ParseCron(tt.expr, tt.timezone); err == nil {
+			t.Errorf("ParseCron(%q, %q) succeeded, want an error", tt.expr, tt.timezone)
+		}
+	}
+}
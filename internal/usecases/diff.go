@@ -0,0 +1,126 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+)
+
+// ErrInvalidLlmsTxt is returned when llms.txt content cannot be parsed.
+var ErrInvalidLlmsTxt = errors.New("invalid llms.txt")
+
+// optionalSection names the section of a Site's Optional pages in diffs.
+const optionalSection = "Optional"
+
+// Differ compares two versions of a site's llms.txt.
+type Differ interface {
+	Diff(oldText, newText string) (domain.Diff, error)
+}
+
+// Parser reads llms.txt content back into a Site.
+type Parser interface {
+	Parse(text string) (domain.Site, error)
+}
+
+// TextDiffer renders a line-by-line diff of two texts.
+type TextDiffer interface {
+	Diff(oldText, newText string) string
+}
+
+// DiffService implements Differ by parsing both versions and comparing them.
+type DiffService struct {
+	Parser     Parser
+	TextDiffer TextDiffer
+}
+
+// Diff implements Differ.
+func (s *DiffService) Diff(oldText, newText string) (domain.Diff, error) {
+	oldSite, err := s.Parser.Parse(oldText)
+	if err != nil {
+		return domain.Diff{}, fmt.Errorf("%w: previous version: %v", ErrInvalidLlmsTxt, err)
+	}
+	newSite, err := s.Parser.Parse(newText)
+	if err != nil {
+		return domain.Diff{}, fmt.Errorf("%w: current version: %v", ErrInvalidLlmsTxt, err)
+	}
+	return domain.Diff{
+		Changes: DiffSites(oldSite, newSite),
+		Unified: s.TextDiffer.Diff(oldText, newText),
+	}, nil
+}
+
+// listedPage is a page with the section it is listed under.
+type listedPage struct {
+	page    domain.Page
+	section string
+}
+
+// listing returns the pages of a site in order, with their sections. A URL
+// listed twice keeps its first entry.
+func listing(site domain.Site) ([]listedPage, map[string]listedPage) {
+	var pages []listedPage
+	for _, sec := range site.Sections {
+		for _, p := range sec.Pages {
+			pages = append(pages, listedPage{p, sec.Name})
+		}
+	}
+	for _, p := range site.Optional {
+		pages = append(pages, listedPage{p, optionalSection})
+	}
+	byURL := make(map[string]listedPage, len(pages))
+	for _, lp := range pages {
+		if _, ok := byURL[lp.page.URL]; !ok {
+			byURL[lp.page.URL] = lp
+		}
+	}
+	return pages, byURL
+}
+
+// DiffSites compares two versions of a site. Pages are matched by URL, and
+// changes are listed in the order of the version they appear in.
+func DiffSites(oldSite, newSite domain.Site) domain.SiteDiff {
+	var d domain.SiteDiff
+	d.Name = textChange(oldSite.Name, newSite.Name)
+	d.Description = textChange(oldSite.Description, newSite.Description)
+
+	oldPages, oldByURL := listing(oldSite)
+	newPages, newByURL := listing(newSite)
+
+	seen := make(map[string]bool, len(newPages))
+	for _, np := range newPages {
+		if seen[np.page.URL] {
+			continue
+		}
+		seen[np.page.URL] = true
+		op, ok := oldByURL[np.page.URL]
+		if !ok {
+			d.Added = append(d.Added, domain.DiffPage{URL: np.page.URL, Title: np.page.Title, Section: np.section})
+			continue
+		}
+		if op.section != np.section {
+			d.Moved = append(d.Moved, domain.PageMove{URL: np.page.URL, Title: np.page.Title, From: op.section, To: np.section})
+		}
+		title := textChange(op.page.Title, np.page.Title)
+		desc := textChange(op.page.Description, np.page.Description)
+		if title != nil || desc != nil {
+			d.Edited = append(d.Edited, domain.PageEdit{URL: np.page.URL, Section: np.section, Title: title, Description: desc})
+		}
+	}
+
+	removed := make(map[string]bool)
+	for _, op := range oldPages {
+		if _, ok := newByURL[op.page.URL]; !ok && !removed[op.page.URL] {
+			removed[op.page.URL] = true
+			d.Removed = append(d.Removed, domain.DiffPage{URL: op.page.URL, Title: op.page.Title, Section: op.section})
+		}
+	}
+	return d
+}
+
+func textChange(oldText, newText string) *domain.TextChange {
+	if oldText == newText {
+		return nil
+	}
+	return &domain.TextChange{Old: oldText, New: newText}
+}
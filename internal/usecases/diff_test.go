@@ -0,0 +1,111 @@
+package usecases
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+)
+
+func TestDiffSites(t *testing.T) {
+	oldSite := domain.Site{
+		Name:        "Example",
+		Description: "Old description",
+		Sections: []domain.Section{
+			{Name: "Docs", Pages: []domain.Page{
+				{URL: "https://example.com/intro", Title: "Intro", Description: "Start here"},
+				{URL: "https://example.com/api", Title: "API"},
+				{URL: "https://example.com/old", Title: "Old page"},
+			}},
+		},
+		Optional: []domain.Page{{URL: "https://example.com/about", Title: "About"}},
+	}
+	newSite := domain.Site{
+		Name:        "Example",
+		Description: "New description",
+		Sections: []domain.Section{
+			{Name: "Docs", Pages: []domain.Page{
+				{URL: "https://example.com/intro", Title: "Introduction", Description: "Start here"},
+				{URL: "https://example.com/new", Title: "New page"},
+			}},
+			{Name: "Reference", Pages: []domain.Page{
+				{URL: "https://example.com/api", Title: "API", Description: "Every endpoint"},
+			}},
+		},
+		Optional: []domain.Page{{URL: "https://example.com/about", Title: "About"}},
+	}
+
+	want := domain.SiteDiff{
+		Description: &domain.TextChange{Old: "Old description", New: "New description"},
+		Added:       []domain.DiffPage{{URL: "https://example.com/new", Title: "New page", Section: "Docs"}},
+		Removed:     []domain.DiffPage{{URL: "https://example.com/old", Title: "Old page", Section: "Docs"}},
+		Moved:       []domain.PageMove{{URL: "https://example.com/api", Title: "API", From: "Docs", To: "Reference"}},
+		Edited: []domain.PageEdit{
+			{URL: "https://example.com/intro", Section: "Docs", Title: &domain.TextChange{Old: "Intro", New: "Introduction"}},
+			{URL: "https://example.com/api", Section: "Reference", Description: &domain.TextChange{Old: "", New: "Every endpoint"}},
+		},
+	}
+	if got := DiffSites(oldSite, newSite); !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffSites() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDiffSites_Unchanged(t *testing.T) {
+	site := domain.Site{Name: "Example", Sections: []domain.Section{{Name: "Docs", Pages: []domain.Page{{URL: "https://example.com/a", Title: "A"}}}}}
+	if d := DiffSites(site, site); !d.IsEmpty() {
+		t.Errorf("DiffSites(site, site) = %+v, want no changes", d)
+	}
+}
+
+func TestDiffSites_OptionalSection(t *testing.T) {
+	page := domain.Page{URL: "https://example.com/about", Title: "About"}
+	oldSite := domain.Site{Name: "Example", Sections: []domain.Section{{Name: "Docs", Pages: []domain.Page{page}}}}
+	newSite := domain.Site{Name: "Example", Optional: []domain.Page{page}}
+
+	d := DiffSites(oldSite, newSite)
+	want := []domain.PageMove{{URL: page.URL, Title: "About", From: "Docs", To: "Optional"}}
+	if !reflect.DeepEqual(d.Moved, want) {
+		t.Errorf("Moved = %+v, want %+v", d.Moved, want)
+	}
+}
+
+// stubParser parses a text as the site of the same name.
+type stubParser map[string]domain.Site
+
+func (p stubParser) Parse(text string) (domain.Site, error) {
+	site, ok := p[text]
+	if !ok {
+		return domain.Site{}, errors.New("unknown text")
+	}
+	return site, nil
+}
+
+type stubTextDiffer struct{}
+
+func (stubTextDiffer) Diff(oldText, newText string) string { return oldText + " -> " + newText }
+
+func TestDiffService(t *testing.T) {
+	svc := &DiffService{
+		Parser: stubParser{
+			"old": {Name: "Old"},
+			"new": {Name: "New"},
+		},
+		TextDiffer: stubTextDiffer{},
+	}
+
+	diff, err := svc.Diff("old", "new")
+	if err != nil {
+		t.Fatalf("Diff() error: %v", err)
+	}
+	if diff.Unified != "old -> new" {
+		t.Errorf("Unified = %q, want %q", diff.Unified, "old -> new")
+	}
+	if want := (&domain.TextChange{Old: "Old", New: "New"}); !reflect.DeepEqual(diff.Changes.Name, want) {
+		t.Errorf("Changes.Name = %+v, want %+v", diff.Changes.Name, want)
+	}
+
+	if _, err := svc.Diff("old", "garbage"); !errors.Is(err, ErrInvalidLlmsTxt) {
+		t.Errorf("Diff() error = %v, want ErrInvalidLlmsTxt", err)
+	}
+}
@@ -2,21 +2,28 @@ package usecases
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/url"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/adsouza/llms.txt-generator/internal/domain"
 )
 
+// ErrTooManyFailures is returned in strict mode when the fraction of pages
+// that could not be fetched exceeds Options.MaxErrorRatio.
+var ErrTooManyFailures = errors.New("too many pages could not be fetched")
+
 // Generator generates llms.txt content for a website.
 type Generator interface {
-	Generate(ctx context.Context, siteURL string) (string, error)
+	Generate(ctx context.Context, siteURL string, opts domain.Options) (domain.Result, error)
 }
 
 // Crawler discovers pages on a website.
 type Crawler interface {
-	Crawl(ctx context.Context, siteURL string) ([]domain.Page, error)
+	Crawl(ctx context.Context, siteURL string) ([]domain.Page, []domain.PageError, error)
 	Discover(ctx context.Context, siteURL string) ([]string, error)
 	FetchPage(ctx context.Context, pageURL string) (domain.Page, error)
 }
@@ -30,65 +37,210 @@ type Formatter interface {
 type Service struct {
 	Crawler   Crawler
 	Formatter Formatter
+
+	// FullFormatter, if set, also renders llms-full.txt from the text content
+	// of the pages that provide it.
+	FullFormatter Formatter
+
+	// SectionNames maps lowercase URL path segments to section names, such as
+	// "kb" → "Support". Entries take precedence over the built-in names.
+	SectionNames map[string]string
+
+	// Cache, if set, keeps results for CacheTTL so that repeated requests for
+	// the same site and options are answered without crawling. Results
+	// crawled with credentials are never cached.
+	Cache    Cache
+	CacheTTL time.Duration
+
+	// Snapshots, if set, keeps the pages of each site's last crawl, so that
+	// the next crawl revalidates them instead of fetching them from scratch.
+	// Unlike Cache, it should be a store whose entries are not evicted.
+	// Crawls with credentials neither start from nor leave a snapshot.
+	Snapshots Cache
+
+	// History, if set, records every generation, including those that fail
+	// or are answered from the cache.
+	History History
 }
 
-// Generate crawls the given site URL and returns formatted llms.txt content.
-func (s *Service) Generate(ctx context.Context, siteURL string) (string, error) {
-	pages, err := s.Crawler.Crawl(ctx, siteURL)
-	if err != nil {
-		return "", err
-	}
-	site := groupPages(siteURL, pages)
-	return s.Formatter.Format(site), nil
+// Generate crawls the given site URL and returns formatted llms.txt content
+// along with any pages that could not be fetched.
+func (s *Service) Generate(ctx context.Context, siteURL string, opts domain.Options) (domain.Result, error) {
+	return s.generate(ctx, siteURL, opts, func(domain.ProgressEvent) {})
 }
 
 // GenerateStream discovers pages, fetches metadata, and sends progress events to the channel.
 // The channel is closed when the function returns.
-func (s *Service) GenerateStream(ctx context.Context, siteURL string, events chan<- domain.ProgressEvent) {
+func (s *Service) GenerateStream(ctx context.Context, siteURL string, opts domain.Options, events chan<- domain.ProgressEvent) {
 	defer close(events)
 
-	urls, err := s.Crawler.Discover(ctx, siteURL)
+	result, err := s.generate(ctx, siteURL, opts, func(ev domain.ProgressEvent) { events <- ev })
 	if err != nil {
-		events <- domain.ProgressEvent{Type: "error", Error: err.Error()}
+		events <- domain.ProgressEvent{Type: "error", Error: err.Error(), Failures: result.Failures}
 		return
 	}
+	events <- domain.ProgressEvent{
+		Type:        "done",
+		Result:      result.LlmsTxt,
+		FullResult:  result.LlmsFullTxt,
+		Locales:     result.Locales,
+		Failures:    result.Failures,
+		GeneratedAt: result.GeneratedAt,
+		HistoryID:   result.HistoryID,
+	}
+}
+
+// generate produces a result and records it in the history.
+func (s *Service) generate(ctx context.Context, siteURL string, opts domain.Options, emit func(domain.ProgressEvent)) (domain.Result, error) {
+	started := time.Now()
+	result, err := s.produce(ctx, siteURL, opts, emit)
+	if s.History != nil {
+		result.HistoryID = s.record(ctx, siteURL, opts, started, result, err)
+	}
+	return result, err
+}
+
+// produce answers from the cache when it can, and crawls the site otherwise.
+// Unless opts.ForceRefresh is set, the crawl starts from the previous snapshot
+// of the site, which it then replaces.
+func (s *Service) produce(ctx context.Context, siteURL string, opts domain.Options, emit func(domain.ProgressEvent)) (domain.Result, error) {
+	key, cacheable := resultKey(siteURL, opts)
+	cacheable = cacheable && s.Cache != nil && s.CacheTTL > 0
+	if cacheable && !opts.ForceRefresh {
+		if result, ok := s.cachedResult(key, time.Now()); ok {
+			// The cached result has no snapshot of its own; hand back the
+			// stored one so that a caller keeping it does not lose it.
+			if s.Snapshots != nil {
+				if snapshot := s.loadSnapshot(siteURL); snapshot != nil {
+					result.Snapshot = *snapshot
+				}
+			}
+			return result, nil
+		}
+	}
+
+	keepSnapshot := s.Snapshots != nil && opts.Credentials.IsZero()
+	previous := opts.Previous
+	if previous == nil && keepSnapshot && !opts.ForceRefresh {
+		previous = s.loadSnapshot(siteURL)
+	}
+	result, err := s.crawl(WithPrevious(ctx, previous), siteURL, opts, emit)
+	if err != nil {
+		return result, err
+	}
+	result.GeneratedAt = time.Now()
+	result.Snapshot.CreatedAt = result.GeneratedAt
+	if keepSnapshot {
+		s.saveSnapshot(result.Snapshot)
+	}
+	if cacheable {
+		s.storeResult(key, result)
+	}
+	return result, nil
+}
 
-	events <- domain.ProgressEvent{Type: "discovered", URLs: urls, Total: len(urls)}
+// crawl discovers and fetches the site's pages and formats them.
+func (s *Service) crawl(ctx context.Context, siteURL string, opts domain.Options, emit func(domain.ProgressEvent)) (domain.Result, error) {
+	ctx = WithProgress(ctx, emit)
+	ctx = WithCredentials(ctx, siteURL, opts.Credentials)
 
+	urls, err := s.Crawler.Discover(ctx, siteURL)
+	if err != nil {
+		return domain.Result{}, err
+	}
+
+	emit(domain.ProgressEvent{Type: "discovered", URLs: urls, Total: len(urls)})
+
+	var result domain.Result
 	var pages []domain.Page
 	for i, u := range urls {
 		page, err := s.Crawler.FetchPage(ctx, u)
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
 		if err != nil {
+			pe := pageError(u, err)
+			result.Failures = append(result.Failures, pe)
+			emit(domain.ProgressEvent{
+				Type:       "page_error",
+				CurrentURL: u,
+				Done:       i + 1,
+				Total:      len(urls),
+				Status:     pe.Status,
+				Error:      pe.Reason,
+			})
 			continue
 		}
 		pages = append(pages, page)
-		events <- domain.ProgressEvent{
+		emit(domain.ProgressEvent{
 			Type:       "progress",
 			CurrentURL: u,
 			Done:       i + 1,
 			Total:      len(urls),
+		})
+	}
+	result.Pages = len(pages)
+	result.Snapshot = domain.Snapshot{SiteURL: siteURL, Pages: pages}
+
+	if opts.MaxErrorRatio > 0 && len(urls) > 0 {
+		if ratio := float64(len(result.Failures)) / float64(len(urls)); ratio > opts.MaxErrorRatio {
+			return result, fmt.Errorf("%w: %d of %d pages failed (max ratio %.2f)",
+				ErrTooManyFailures, len(result.Failures), len(urls), opts.MaxErrorRatio)
 		}
 	}
 
-	site := groupPages(siteURL, pages)
-	result := s.Formatter.Format(site)
-	events <- domain.ProgressEvent{Type: "done", Result: result}
+	locs := newLocales(pages)
+	target := opts.Language
+	if target == "" {
+		target = locs.primary(pages)
+	}
+	site := groupPages(siteURL, locs.filter(pages, target), s.SectionNames)
+	result.LlmsTxt = s.Formatter.Format(site)
+	if s.FullFormatter != nil {
+		result.LlmsFullTxt = s.FullFormatter.Format(site)
+	}
+
+	if opts.PerLocale {
+		result.Locales = make(map[string]string)
+		for _, tag := range locs.distinct(pages) {
+			result.Locales[tag] = s.Formatter.Format(groupPages(siteURL, locs.filter(pages, tag), s.SectionNames))
+		}
+	}
+	return result, nil
 }
 
-func groupPages(siteURL string, pages []domain.Page) domain.Site {
+// pageError converts a FetchPage error into a PageError for reporting,
+// describing transport failures by their cause rather than the request.
+func pageError(pageURL string, err error) domain.PageError {
+	var pe *domain.PageError
+	if errors.As(err, &pe) {
+		return *pe
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	return domain.PageError{URL: pageURL, Reason: err.Error()}
+}
+
+// groupPages builds a Site from pages, naming sections after their first path
+// segment through names, then sectionNames.
+func groupPages(siteURL string, pages []domain.Page, names map[string]string) domain.Site {
 	var site domain.Site
 
 	if u, err := url.Parse(siteURL); err == nil {
 		site.Name = u.Hostname()
 	}
 
+	locs := newLocales(pages)
 	buckets := make(map[string][]domain.Page)
 	for _, p := range pages {
 		u, err := url.Parse(p.URL)
 		if err != nil {
 			continue
 		}
-		path := strings.Trim(u.Path, "/")
+		// Locale prefixes such as /fr/ or /pt-br/ are not sections.
+		_, path := locs.split(p, strings.Trim(u.Path, "/"))
 
 		if path == "" {
 			site.Name = p.Title
@@ -104,7 +256,9 @@ func groupPages(siteURL string, pages []domain.Page) domain.Site {
 			continue
 		}
 
-		if name, ok := sectionNames[first]; ok {
+		if name, ok := names[first]; ok {
+			buckets[name] = append(buckets[name], p)
+		} else if name, ok := sectionNames[first]; ok {
 			buckets[name] = append(buckets[name], p)
 		} else {
 			name = strings.ToUpper(first[:1]) + first[1:]
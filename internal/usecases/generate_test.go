@@ -9,13 +9,14 @@ import (
 )
 
 type fakeCrawler struct {
-	pages []domain.Page
-	urls  []string
-	err   error
+	pages   []domain.Page
+	urls    []string
+	err     error
+	failing map[string]error
 }
 
-func (f *fakeCrawler) Crawl(_ context.Context, _ string) ([]domain.Page, error) {
-	return f.pages, f.err
+func (f *fakeCrawler) Crawl(_ context.Context, _ string) ([]domain.Page, []domain.PageError, error) {
+	return f.pages, nil, f.err
 }
 
 func (f *fakeCrawler) Discover(_ context.Context, _ string) ([]string, error) {
@@ -33,6 +34,9 @@ func (f *fakeCrawler) Discover(_ context.Context, _ string) ([]string, error) {
 }
 
 func (f *fakeCrawler) FetchPage(_ context.Context, pageURL string) (domain.Page, error) {
+	if err, ok := f.failing[pageURL]; ok {
+		return domain.Page{}, err
+	}
 	for _, p := range f.pages {
 		if p.URL == pageURL {
 			return p, nil
@@ -58,12 +62,12 @@ func TestGenerate_HappyPath(t *testing.T) {
 	formatter := &fakeFormatter{}
 	svc := &Service{Crawler: crawler, Formatter: formatter}
 
-	result, err := svc.Generate(context.Background(), "https://example.com")
+	result, err := svc.Generate(context.Background(), "https://example.com", domain.Options{})
 	if err != nil {
 		t.Fatalf("Generate() error: %v", err)
 	}
-	if result != "formatted" {
-		t.Errorf("Generate() = %q, want %q", result, "formatted")
+	if result.LlmsTxt != "formatted" {
+		t.Errorf("Generate() = %q, want %q", result.LlmsTxt, "formatted")
 	}
 	if formatter.lastSite.Name != "Example" {
 		t.Errorf("site name = %q, want %q", formatter.lastSite.Name, "Example")
@@ -78,7 +82,7 @@ func TestGenerate_CrawlerError(t *testing.T) {
 	formatter := &fakeFormatter{}
 	svc := &Service{Crawler: crawler, Formatter: formatter}
 
-	_, err := svc.Generate(context.Background(), "https://example.com")
+	_, err := svc.Generate(context.Background(), "https://example.com", domain.Options{})
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -89,7 +93,7 @@ func TestGroupPages_HomepageExtraction(t *testing.T) {
 		{URL: "https://example.com/", Title: "My Site", Description: "Welcome to my site"},
 		{URL: "https://example.com/docs/api", Title: "API Docs", Description: "API reference"},
 	}
-	site := groupPages("https://example.com", pages)
+	site := groupPages("https://example.com", pages, nil)
 	if site.Name != "My Site" {
 		t.Errorf("name = %q, want %q", site.Name, "My Site")
 	}
@@ -102,7 +106,7 @@ func TestGroupPages_NoHomepage(t *testing.T) {
 	pages := []domain.Page{
 		{URL: "https://example.com/docs/api", Title: "API Docs"},
 	}
-	site := groupPages("https://example.com", pages)
+	site := groupPages("https://example.com", pages, nil)
 	if site.Name != "example.com" {
 		t.Errorf("name = %q, want domain fallback %q", site.Name, "example.com")
 	}
@@ -115,7 +119,7 @@ func TestGroupPages_SectionMapping(t *testing.T) {
 		{URL: "https://example.com/blog/post1", Title: "Post 1"},
 		{URL: "https://example.com/tutorials/basics", Title: "Basics"},
 	}
-	site := groupPages("https://example.com", pages)
+	site := groupPages("https://example.com", pages, nil)
 
 	sectionMap := map[string]bool{}
 	for _, s := range site.Sections {
@@ -136,7 +140,7 @@ func TestGroupPages_UnknownPathCapitalized(t *testing.T) {
 	pages := []domain.Page{
 		{URL: "https://example.com/widgets/foo", Title: "Foo Widget"},
 	}
-	site := groupPages("https://example.com", pages)
+	site := groupPages("https://example.com", pages, nil)
 	if len(site.Sections) != 1 {
 		t.Fatalf("got %d sections, want 1", len(site.Sections))
 	}
@@ -150,7 +154,7 @@ func TestGroupPages_RootLevelPages(t *testing.T) {
 		{URL: "https://example.com/about", Title: "About"},
 		{URL: "https://example.com/pricing", Title: "Pricing"},
 	}
-	site := groupPages("https://example.com", pages)
+	site := groupPages("https://example.com", pages, nil)
 	if len(site.Sections) != 1 {
 		t.Fatalf("got %d sections, want 1", len(site.Sections))
 	}
@@ -171,7 +175,7 @@ func TestGroupPages_OptionalOverflow(t *testing.T) {
 		{URL: "https://example.com/support/a", Title: "Support A"},
 		{URL: "https://example.com/widgets/a", Title: "Widget A"},
 	}
-	site := groupPages("https://example.com", pages)
+	site := groupPages("https://example.com", pages, nil)
 
 	if len(site.Sections) > 5 {
 		t.Errorf("got %d sections, want at most 5", len(site.Sections))
@@ -187,7 +191,7 @@ func TestGroupPages_SortedSections(t *testing.T) {
 		{URL: "https://example.com/docs/a", Title: "A Doc"},
 		{URL: "https://example.com/blog/a", Title: "A Post"},
 	}
-	site := groupPages("https://example.com", pages)
+	site := groupPages("https://example.com", pages, nil)
 
 	if len(site.Sections) < 2 {
 		t.Fatalf("got %d sections, want at least 2", len(site.Sections))
@@ -214,7 +218,7 @@ func TestGenerateStream_EventOrder(t *testing.T) {
 	svc := &Service{Crawler: crawler, Formatter: formatter}
 
 	events := make(chan domain.ProgressEvent, 10)
-	svc.GenerateStream(context.Background(), "https://example.com", events)
+	svc.GenerateStream(context.Background(), "https://example.com", domain.Options{}, events)
 
 	var collected []domain.ProgressEvent
 	for ev := range events {
@@ -245,7 +249,7 @@ func TestGenerateStream_DiscoverError(t *testing.T) {
 	svc := &Service{Crawler: crawler, Formatter: formatter}
 
 	events := make(chan domain.ProgressEvent, 10)
-	svc.GenerateStream(context.Background(), "https://example.com", events)
+	svc.GenerateStream(context.Background(), "https://example.com", domain.Options{}, events)
 
 	var collected []domain.ProgressEvent
 	for ev := range events {
@@ -259,3 +263,100 @@ func TestGenerateStream_DiscoverError(t *testing.T) {
 		t.Errorf("event type = %q, want %q", collected[0].Type, "error")
 	}
 }
+
+func TestGenerate_ReportsPageFailures(t *testing.T) {
+	crawler := &fakeCrawler{
+		pages: []domain.Page{{URL: "https://example.com/", Title: "Home"}},
+		urls:  []string{"https://example.com/", "https://example.com/gone", "https://example.com/slow"},
+		failing: map[string]error{
+			"https://example.com/gone": &domain.PageError{URL: "https://example.com/gone", Status: 404, Reason: "Not Found"},
+			"https://example.com/slow": errors.New("timeout"),
+		},
+	}
+	svc := &Service{Crawler: crawler, Formatter: &fakeFormatter{}}
+
+	result, err := svc.Generate(context.Background(), "https://example.com", domain.Options{})
+	if err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	if result.Pages != 1 {
+		t.Errorf("pages = %d, want 1", result.Pages)
+	}
+	if len(result.Failures) != 2 {
+		t.Fatalf("failures = %d, want 2", len(result.Failures))
+	}
+	if result.Failures[0].Status != 404 {
+		t.Errorf("status = %d, want 404", result.Failures[0].Status)
+	}
+	if result.Failures[1].URL != "https://example.com/slow" || result.Failures[1].Reason != "timeout" {
+		t.Errorf("failure = %+v, want timeout for /slow", result.Failures[1])
+	}
+}
+
+func TestGenerate_StrictMode(t *testing.T) {
+	crawler := &fakeCrawler{
+		urls:    []string{"https://example.com/", "https://example.com/gone"},
+		failing: map[string]error{"https://example.com/gone": errors.New("boom")},
+	}
+	svc := &Service{Crawler: crawler, Formatter: &fakeFormatter{}}
+
+	if _, err := svc.Generate(context.Background(), "https://example.com", domain.Options{MaxErrorRatio: 0.5}); err != nil {
+		t.Errorf("ratio 0.5 at threshold: unexpected error %v", err)
+	}
+	_, err := svc.Generate(context.Background(), "https://example.com", domain.Options{MaxErrorRatio: 0.4})
+	if !errors.Is(err, ErrTooManyFailures) {
+		t.Errorf("error = %v, want ErrTooManyFailures", err)
+	}
+}
+
+func TestGenerateStream_PageErrorEvent(t *testing.T) {
+	crawler := &fakeCrawler{
+		urls:    []string{"https://example.com/", "https://example.com/gone"},
+		failing: map[string]error{"https://example.com/gone": &domain.PageError{URL: "https://example.com/gone", Status: 503, Reason: "Service Unavailable"}},
+	}
+	svc := &Service{Crawler: crawler, Formatter: &fakeFormatter{}}
+
+	events := make(chan domain.ProgressEvent, 10)
+	svc.GenerateStream(context.Background(), "https://example.com", domain.Options{}, events)
+
+	var pageErrors []domain.ProgressEvent
+	var last domain.ProgressEvent
+	for ev := range events {
+		if ev.Type == "page_error" {
+			pageErrors = append(pageErrors, ev)
+		}
+		last = ev
+	}
+	if len(pageErrors) != 1 {
+		t.Fatalf("got %d page_error events, want 1", len(pageErrors))
+	}
+	if pageErrors[0].CurrentURL != "https://example.com/gone" || pageErrors[0].Status != 503 {
+		t.Errorf("page_error = %+v", pageErrors[0])
+	}
+	if last.Type != "done" || len(last.Failures) != 1 {
+		t.Errorf("done event = %+v, want 1 failure", last)
+	}
+}
+
+func TestGroupPages_CustomSectionNames(t *testing.T) {
+	pages := []domain.Page{
+		{URL: "https://example.com/kb/reset-password", Title: "Reset your password"},
+		{URL: "https://example.com/docs/intro", Title: "Intro"},
+		{URL: "https://example.com/blog/launch", Title: "Launch"},
+	}
+	names := map[string]string{"kb": "Support", "docs": "Manual"}
+	site := groupPages("https://example.com", pages, names)
+
+	got := make(map[string]bool)
+	for _, sec := range site.Sections {
+		got[sec.Name] = true
+	}
+	for _, want := range []string{"Support", "Manual", "Blog"} {
+		if !got[want] {
+			t.Errorf("missing section %q in %v", want, got)
+		}
+	}
+	if got["Documentation"] {
+		t.Error("custom name did not override the built-in Documentation section")
+	}
+}
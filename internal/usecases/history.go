@@ -0,0 +1,100 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+)
+
+// ErrGenerationNotFound is returned when the history has no generation with
+// the requested ID.
+var ErrGenerationNotFound = errors.New("generation not found")
+
+// History keeps a record of past generations.
+type History interface {
+	// Add records a generation and returns the ID it was given.
+	Add(gen domain.Generation) (string, error)
+	// List returns the generations matching query, newest first, without
+	// their output.
+	List(query HistoryQuery) ([]domain.Generation, error)
+	// Get returns a generation with its output, or ErrGenerationNotFound.
+	Get(id string) (domain.Generation, error)
+}
+
+// HistoryQuery selects generations from the history.
+type HistoryQuery struct {
+	Host  string // only generations of this host, compared case-insensitively
+	Owner string // only generations made by this client, if set
+	Limit int    // at most this many generations; 0 for all
+}
+
+// record adds a generation to the history, for the client ctx acts on behalf
+// of, and returns its ID. The output of a generation made with credentials is
+// left out, like it is from the cache, so that only its outcome is kept. A
+// generation that cannot be recorded only leaves a gap in the history.
+func (s *Service) record(ctx context.Context, siteURL string, opts domain.Options, started time.Time, result domain.Result, err error) string {
+	gen := domain.Generation{
+		URL:           siteURL,
+		Owner:         Owner(ctx),
+		Options:       opts,
+		Authenticated: !opts.Credentials.IsZero(),
+		StartedAt:     started,
+		Duration:      time.Since(started),
+		Cached:        err == nil && result.GeneratedAt.Before(started),
+		Pages:         result.Pages,
+		Failures:      result.Failures,
+		LlmsTxt:       result.LlmsTxt,
+		LlmsFullTxt:   result.LlmsFullTxt,
+		Locales:       result.Locales,
+	}
+	gen.Options.Credentials = domain.Credentials{}
+	gen.Options.Previous = nil
+	if gen.Authenticated {
+		gen.LlmsTxt, gen.LlmsFullTxt, gen.Locales = "", "", nil
+	}
+	if err != nil {
+		gen.Error = err.Error()
+	}
+	id, err := s.History.Add(gen)
+	if err != nil {
+		return ""
+	}
+	return id
+}
+
+// Published returns the generation of host whose output is published at its
+// stable URLs: the newest successful one made without credentials or a
+// language, so that it is what any visitor would see. Generations answered
+// from the cache are passed over for the one that produced their result, so
+// that its start time says when the site was last crawled.
+func Published(h History, host string) (domain.Generation, error) {
+	gens, err := h.List(HistoryQuery{Host: host})
+	if err != nil {
+		return domain.Generation{}, err
+	}
+	var cached *domain.Generation
+	for i, gen := range gens {
+		if gen.Error != "" || gen.Authenticated || gen.Options.Language != "" {
+			continue
+		}
+		if !gen.Cached {
+			return h.Get(gen.ID)
+		}
+		if cached == nil {
+			cached = &gens[i]
+		}
+	}
+	if cached != nil {
+		// The crawl behind the cached result has been pruned.
+		return h.Get(cached.ID)
+	}
+	return domain.Generation{}, ErrGenerationNotFound
+}
+
+// Visible reports whether a generation may be shown to owner: its own, or
+// any when the server does not identify its clients.
+func Visible(gen domain.Generation, owner string) bool {
+	return owner == "" || gen.Owner == owner
+}
@@ -0,0 +1,138 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+)
+
+// memHistory is a History that keeps generations in a slice.
+type memHistory struct {
+	gens []domain.Generation
+}
+
+func (h *memHistory) Add(gen domain.Generation) (string, error) {
+	gen.ID = string(rune('a' + len(h.gens)))
+	h.gens = append(h.gens, gen)
+	return gen.ID, nil
+}
+
+func (h *memHistory) List(HistoryQuery) ([]domain.Generation, error) { return h.gens, nil }
+
+func (h *memHistory) Get(id string) (domain.Generation, error) {
+	for _, gen := range h.gens {
+		if gen.ID == id {
+			return gen, nil
+		}
+	}
+	return domain.Generation{}, ErrGenerationNotFound
+}
+
+func TestGenerate_RecordsHistory(t *testing.T) {
+	crawler := &fakeCrawler{
+		pages:   []domain.Page{{URL: "https://example.com/", Title: "Example"}},
+		urls:    []string{"https://example.com/", "https://example.com/gone"},
+		failing: map[string]error{"https://example.com/gone": &domain.PageError{URL: "https://example.com/gone", Status: 404, Reason: "not found"}},
+	}
+	history := &memHistory{}
+	svc := &Service{Crawler: crawler, Formatter: &fakeFormatter{}, History: history}
+
+	opts := domain.Options{
+		Language:    "en",
+		Credentials: domain.Credentials{Headers: map[string]string{"Authorization": "Bearer secret"}},
+	}
+	result, err := svc.Generate(WithOwner(context.Background(), "key:partner"), "https://example.com", opts)
+	if err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	if result.HistoryID != "a" {
+		t.Errorf("HistoryID = %q, want %q", result.HistoryID, "a")
+	}
+	if len(history.gens) != 1 {
+		t.Fatalf("recorded %d generations, want 1", len(history.gens))
+	}
+
+	gen := history.gens[0]
+	if gen.URL != "https://example.com" || gen.Options.Language != "en" || gen.Owner != "key:partner" {
+		t.Errorf("recorded %+v, want the URL, options and owner", gen)
+	}
+	if gen.LlmsTxt != "" || gen.LlmsFullTxt != "" || gen.Locales != nil {
+		t.Errorf("recorded the output of a crawl with credentials: %+v", gen)
+	}
+	if gen.Pages != 1 || len(gen.Failures) != 1 || gen.Failures[0].Status != 404 {
+		t.Errorf("recorded pages = %d, failures = %+v; want 1 page and the 404", gen.Pages, gen.Failures)
+	}
+	if !gen.Authenticated || !gen.Options.Credentials.IsZero() {
+		t.Errorf("credentials recorded as %v (authenticated %v); want only the fact they were given", gen.Options.Credentials, gen.Authenticated)
+	}
+	if gen.StartedAt.IsZero() || gen.Duration < 0 || gen.Cached {
+		t.Errorf("started at %v, took %v, cached %v", gen.StartedAt, gen.Duration, gen.Cached)
+	}
+}
+
+func TestGenerate_RecordsFailuresAndCacheHits(t *testing.T) {
+	history := &memHistory{}
+	svc, crawler, _ := newCachedService(time.Hour)
+	svc.History = history
+
+	if _, err := svc.Generate(context.Background(), "https://example.com", domain.Options{}); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	if _, err := svc.Generate(context.Background(), "https://example.com", domain.Options{}); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	crawler.err = errors.New("connection refused")
+	if _, err := svc.Generate(context.Background(), "https://other.example", domain.Options{}); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if len(history.gens) != 3 {
+		t.Fatalf("recorded %d generations, want 3", len(history.gens))
+	}
+	if history.gens[0].LlmsTxt == "" {
+		t.Error("output of a crawl without credentials not recorded")
+	}
+	if history.gens[0].Cached || !history.gens[1].Cached {
+		t.Errorf("cached = %v, %v; want only the second generation cached", history.gens[0].Cached, history.gens[1].Cached)
+	}
+	if history.gens[2].Error != "connection refused" {
+		t.Errorf("error = %q, want %q", history.gens[2].Error, "connection refused")
+	}
+}
+
+func TestPublished(t *testing.T) {
+	crawl := domain.Generation{ID: "crawl", LlmsTxt: "# Crawled\n"}
+	tests := []struct {
+		name   string
+		gens   []domain.Generation // newest first
+		wantID string
+	}{
+		{"newest success", []domain.Generation{crawl, {ID: "older"}}, "crawl"},
+		{"skips failures", []domain.Generation{{ID: "failed", Error: "timeout"}, crawl}, "crawl"},
+		{"skips credentials", []domain.Generation{{ID: "private", Authenticated: true}, crawl}, "crawl"},
+		{"skips languages", []domain.Generation{{ID: "fr", Options: domain.Options{Language: "fr"}}, crawl}, "crawl"},
+		{"prefers the crawl behind a cache hit", []domain.Generation{{ID: "hit", Cached: true}, crawl}, "crawl"},
+		{"falls back to a cache hit", []domain.Generation{{ID: "hit", Cached: true}, {ID: "failed", Error: "timeout"}}, "hit"},
+		{"nothing published", []domain.Generation{{ID: "failed", Error: "timeout"}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, err := Published(&memHistory{gens: tt.gens}, "example.com")
+			if tt.wantID == "" {
+				if !errors.Is(err, ErrGenerationNotFound) {
+					t.Errorf("Published() error = %v, want ErrGenerationNotFound", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Published() error: %v", err)
+			}
+			if gen.ID != tt.wantID {
+				t.Errorf("Published() = %q, want %q", gen.ID, tt.wantID)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package usecases
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+)
+
+// minLocalePrefixPages is how many pages must start with a locale segment for
+// it to be taken as a locale prefix on pages that do not declare that locale.
+const minLocalePrefixPages = 2
+
+// locales describes the languages a site is published in, as declared by its
+// pages' lang attributes and hreflang alternates.
+type locales struct {
+	known    map[string]bool   // normalized locales and their base languages
+	byURL    map[string]string // page URL → locale declared for it by an hreflang link
+	prefixes map[string]int    // known locale → pages whose path starts with it
+}
+
+func newLocales(pages []domain.Page) locales {
+	l := locales{known: make(map[string]bool), byURL: make(map[string]string), prefixes: make(map[string]int)}
+	add := func(tag string) {
+		tag = normalizeLocale(tag)
+		if tag == "" || tag == "x-default" {
+			return
+		}
+		l.known[tag] = true
+		l.known[baseLanguage(tag)] = true
+	}
+	for _, p := range pages {
+		add(p.Lang)
+		for hreflang, u := range p.Alternates {
+			add(hreflang)
+			if tag := normalizeLocale(hreflang); tag != "x-default" {
+				l.byURL[u] = tag
+			}
+		}
+	}
+	for _, p := range pages {
+		if u, err := url.Parse(p.URL); err == nil {
+			first, _, _ := strings.Cut(strings.Trim(u.Path, "/"), "/")
+			if tag := normalizeLocale(first); tag != "" && l.known[tag] {
+				l.prefixes[tag]++
+			}
+		}
+	}
+	return l
+}
+
+// split removes a leading locale segment such as "fr" or "pt-br" from p's
+// trimmed URL path, returning the locale and the remaining path. A segment
+// that merely looks like a known locale, such as "id" or "it", is only taken
+// as one if p declares that locale, or several pages start with it.
+func (l locales) split(p domain.Page, path string) (locale, rest string) {
+	first, rest, _ := strings.Cut(path, "/")
+	tag := normalizeLocale(first)
+	if tag == "" || !l.known[tag] {
+		return "", path
+	}
+	if l.prefixes[tag] >= minLocalePrefixPages || l.declared(p, tag) {
+		return tag, rest
+	}
+	return "", path
+}
+
+// declared reports whether p's lang attribute, or an hreflang link pointing
+// at it, names locale or one of its regional variants.
+func (l locales) declared(p domain.Page, locale string) bool {
+	if tag := normalizeLocale(p.Lang); tag != "" && matchesLocale(tag, locale) {
+		return true
+	}
+	tag, ok := l.byURL[p.URL]
+	return ok && matchesLocale(tag, locale)
+}
+
+// of returns a page's locale from its lang attribute, the hreflang links
+// pointing at it, or its path prefix, in that order.
+func (l locales) of(p domain.Page) string {
+	if tag := normalizeLocale(p.Lang); tag != "" {
+		return tag
+	}
+	if tag, ok := l.byURL[p.URL]; ok {
+		return tag
+	}
+	if u, err := url.Parse(p.URL); err == nil {
+		tag, _ := l.split(p, strings.Trim(u.Path, "/"))
+		return tag
+	}
+	return ""
+}
+
+// distinct returns the sorted locales of the given pages.
+func (l locales) distinct(pages []domain.Page) []string {
+	seen := make(map[string]bool)
+	var tags []string
+	for _, p := range pages {
+		if tag := l.of(p); tag != "" && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// primary picks the locale to publish for a multilingual site when none was
+// requested: the homepage's, or else the most common one. It returns "" for
+// sites published in a single locale.
+func (l locales) primary(pages []domain.Page) string {
+	if len(l.distinct(pages)) < 2 {
+		return ""
+	}
+	counts := make(map[string]int)
+	for _, p := range pages {
+		tag := l.of(p)
+		if u, err := url.Parse(p.URL); err == nil && strings.Trim(u.Path, "/") == "" && tag != "" {
+			return tag
+		}
+		counts[tag]++
+	}
+	best := ""
+	for tag, n := range counts {
+		if tag != "" && (best == "" || n > counts[best] || n == counts[best] && tag < best) {
+			best = tag
+		}
+	}
+	return best
+}
+
+// filter keeps the pages in the target locale, along with pages whose locale
+// is unknown since they are usually shared between all translations.
+func (l locales) filter(pages []domain.Page, target string) []domain.Page {
+	target = normalizeLocale(target)
+	if target == "" {
+		return pages
+	}
+	var kept []domain.Page
+	for _, p := range pages {
+		if tag := l.of(p); tag == "" || matchesLocale(tag, target) {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+// matchesLocale reports whether a page in locale satisfies a request for
+// target. A bare language such as "en" matches all of its regional variants.
+func matchesLocale(locale, target string) bool {
+	return locale == target || !strings.Contains(target, "-") && baseLanguage(locale) == target
+}
+
+// normalizeLocale lowercases a BCP 47 language tag and uses hyphens as
+// separators. It returns "" for strings that do not look like a tag.
+func normalizeLocale(tag string) string {
+	tag = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+	if tag == "x-default" {
+		return tag
+	}
+	parts := strings.Split(tag, "-")
+	if len(parts[0]) < 2 || len(parts[0]) > 3 || !isLetters(parts[0]) {
+		return ""
+	}
+	for _, part := range parts[1:] {
+		if len(part) < 2 || len(part) > 8 {
+			return ""
+		}
+	}
+	return tag
+}
+
+func baseLanguage(tag string) string {
+	base, _, _ := strings.Cut(tag, "-")
+	return base
+}
+
+func isLetters(s string) bool {
+	for _, r := range s {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,173 @@
+package usecases
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+)
+
+func multilingualPages() []domain.Page {
+	alternates := func(path string) map[string]string {
+		return map[string]string{
+			"en":        "https://example.com" + path,
+			"fr":        "https://example.com/fr" + path,
+			"de":        "https://example.com/de" + path,
+			"x-default": "https://example.com" + path,
+		}
+	}
+	return []domain.Page{
+		{URL: "https://example.com/", Title: "Home", Lang: "en", Alternates: alternates("/")},
+		{URL: "https://example.com/docs/intro", Title: "Intro", Lang: "en", Alternates: alternates("/docs/intro")},
+		{URL: "https://example.com/fr/", Title: "Accueil", Lang: "fr", Alternates: alternates("/")},
+		{URL: "https://example.com/fr/docs/intro", Title: "Introduction", Lang: "fr", Alternates: alternates("/docs/intro")},
+		{URL: "https://example.com/de/", Title: "Startseite", Alternates: alternates("/")},
+		{URL: "https://example.com/de/docs/intro", Title: "Einführung", Alternates: alternates("/docs/intro")},
+	}
+}
+
+func TestGroupPages_StripsLocalePrefixes(t *testing.T) {
+	site := groupPages("https://example.com", multilingualPages(), nil)
+
+	for _, sec := range site.Sections {
+		if sec.Name == "Fr" || sec.Name == "De" {
+			t.Errorf("locale prefix became section %q", sec.Name)
+		}
+	}
+	if len(site.Sections) != 1 || site.Sections[0].Name != "Documentation" {
+		t.Errorf("sections = %+v, want only Documentation", site.Sections)
+	}
+}
+
+func TestGenerate_DefaultsToHomepageLocale(t *testing.T) {
+	crawler := &fakeCrawler{pages: multilingualPages()}
+	formatter := &fakeFormatter{}
+	svc := &Service{Crawler: crawler, Formatter: formatter}
+
+	if _, err := svc.Generate(context.Background(), "https://example.com", domain.Options{}); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	if formatter.lastSite.Name != "Home" {
+		t.Errorf("site name = %q, want %q", formatter.lastSite.Name, "Home")
+	}
+	if n := len(formatter.lastSite.Sections[0].Pages); n != 1 {
+		t.Errorf("documentation pages = %d, want 1 (no translations)", n)
+	}
+}
+
+func TestGenerate_TargetLanguage(t *testing.T) {
+	crawler := &fakeCrawler{pages: multilingualPages()}
+	formatter := &fakeFormatter{}
+	svc := &Service{Crawler: crawler, Formatter: formatter}
+
+	// German pages have no lang attribute and are identified by hreflang.
+	if _, err := svc.Generate(context.Background(), "https://example.com", domain.Options{Language: "DE"}); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	if formatter.lastSite.Name != "Startseite" {
+		t.Errorf("site name = %q, want %q", formatter.lastSite.Name, "Startseite")
+	}
+	pages := formatter.lastSite.Sections[0].Pages
+	if len(pages) != 1 || pages[0].Title != "Einführung" {
+		t.Errorf("pages = %+v, want only Einführung", pages)
+	}
+}
+
+func TestGenerate_PerLocale(t *testing.T) {
+	crawler := &fakeCrawler{pages: multilingualPages()}
+	svc := &Service{Crawler: crawler, Formatter: titleFormatter{}}
+
+	result, err := svc.Generate(context.Background(), "https://example.com", domain.Options{PerLocale: true})
+	if err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	want := map[string]string{"de": "Startseite", "en": "Home", "fr": "Accueil"}
+	if len(result.Locales) != len(want) {
+		t.Fatalf("locales = %v, want %v", result.Locales, want)
+	}
+	for tag, name := range want {
+		if !strings.HasPrefix(result.Locales[tag], name) {
+			t.Errorf("locale %s = %q, want site %q", tag, result.Locales[tag], name)
+		}
+	}
+}
+
+func TestGroupPages_KeepsNonLocaleSegments(t *testing.T) {
+	pages := []domain.Page{
+		{URL: "https://example.com/id/123", Title: "Item", Lang: "en"},
+	}
+	site := groupPages("https://example.com", pages, nil)
+	if len(site.Sections) != 1 || site.Sections[0].Name != "Id" {
+		t.Errorf("sections = %+v, want Id", site.Sections)
+	}
+}
+
+func TestGroupPages_KeepsSegmentsOnlyShapedLikeLocales(t *testing.T) {
+	// An English site with an Italian translation under /it-it/ and an IT
+	// helpdesk under /it/.
+	alternates := map[string]string{"en": "https://example.com/", "it-it": "https://example.com/it-it/"}
+	pages := []domain.Page{
+		{URL: "https://example.com/", Title: "Home", Lang: "en", Alternates: alternates},
+		{URL: "https://example.com/it-it/", Title: "Home (IT)", Lang: "it-IT", Alternates: alternates},
+		{URL: "https://example.com/it/helpdesk", Title: "Helpdesk", Lang: "en"},
+		{URL: "https://example.com/no/exceptions", Title: "No Exceptions", Lang: "en"},
+	}
+	site := groupPages("https://example.com", pages, nil)
+	var names []string
+	for _, sec := range site.Sections {
+		names = append(names, sec.Name)
+	}
+	if strings.Join(names, ",") != "It,No" {
+		t.Errorf("sections = %v, want It and No", names)
+	}
+}
+
+func TestLocales_Split(t *testing.T) {
+	l := newLocales(multilingualPages())
+	tests := []struct {
+		page       domain.Page
+		wantLocale string
+		wantRest   string
+	}{
+		// Used as a prefix on several pages.
+		{domain.Page{URL: "https://example.com/fr/blog/news"}, "fr", "blog/news"},
+		// Declared by the page alone.
+		{domain.Page{URL: "https://example.com/en/start", Lang: "en-GB"}, "en", "start"},
+		// Known, but neither a common prefix nor declared by the page.
+		{domain.Page{URL: "https://example.com/en/start", Lang: "fr"}, "", "en/start"},
+		{domain.Page{URL: "https://example.com/docs/intro"}, "", "docs/intro"},
+	}
+	for _, tt := range tests {
+		u := strings.TrimPrefix(tt.page.URL, "https://example.com/")
+		locale, rest := l.split(tt.page, u)
+		if locale != tt.wantLocale || rest != tt.wantRest {
+			t.Errorf("split(%s) = %q, %q; want %q, %q", tt.page.URL, locale, rest, tt.wantLocale, tt.wantRest)
+		}
+	}
+}
+
+func TestMatchesLocale(t *testing.T) {
+	tests := []struct {
+		locale, target string
+		want           bool
+	}{
+		{"en", "en", true},
+		{"en-us", "en", true},
+		{"en", "en-us", false},
+		{"en-gb", "en-us", false},
+		{"pt-br", "pt-br", true},
+	}
+	for _, tt := range tests {
+		if got := matchesLocale(tt.locale, tt.target); got != tt.want {
+			t.Errorf("matchesLocale(%q, %q) = %v, want %v", tt.locale, tt.target, got, tt.want)
+		}
+	}
+}
+
+// titleFormatter renders just the site name, to tell per-locale outputs apart.
+type titleFormatter struct{}
+
+func (titleFormatter) Format(site domain.Site) string {
+	return site.Name
+}
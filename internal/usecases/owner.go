@@ -0,0 +1,21 @@
+package usecases
+
+import "context"
+
+type ownerKey struct{}
+
+// WithOwner returns a copy of ctx acting on behalf of owner, an opaque ID of
+// the client that generations made with it are recorded for.
+func WithOwner(ctx context.Context, owner string) context.Context {
+	if owner == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, ownerKey{}, owner)
+}
+
+// Owner returns the client ctx acts on behalf of, or "" if it does not carry
+// one, as when the server does not identify its clients.
+func Owner(ctx context.Context) string {
+	owner, _ := ctx.Value(ownerKey{}).(string)
+	return owner
+}
@@ -0,0 +1,22 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+)
+
+type progressKey struct{}
+
+// WithProgress returns a copy of ctx carrying report, which adapters use to
+// surface intermediate events, such as retries, while a generation runs.
+func WithProgress(ctx context.Context, report func(domain.ProgressEvent)) context.Context {
+	return context.WithValue(ctx, progressKey{}, report)
+}
+
+// ReportProgress delivers ev to the reporter carried by ctx, if any.
+func ReportProgress(ctx context.Context, ev domain.ProgressEvent) {
+	if report, ok := ctx.Value(progressKey{}).(func(domain.ProgressEvent)); ok {
+		report(ev)
+	}
+}
@@ -0,0 +1,217 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrQuotaExceeded is returned when a client has used up one of its quotas.
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// Quota names, as reported by QuotaError.
+const (
+	QuotaRequests = "requests_per_hour"
+	QuotaPages    = "pages_per_day"
+	QuotaJobs     = "concurrent_jobs"
+)
+
+const (
+	requestWindow = time.Hour
+	pageWindow    = 24 * time.Hour
+	sweepInterval = time.Minute
+)
+
+// Limits bound what one client may use. Zero fields are unlimited.
+type Limits struct {
+	RequestsPerHour int
+	PagesPerDay     int
+	ConcurrentJobs  int
+}
+
+// QuotaError reports the quota a client has used up.
+type QuotaError struct {
+	Quota string
+	Limit int
+	// RetryAfter is how long until the quota's window starts over, or zero
+	// for concurrent jobs and reserved pages, which free up whenever a job
+	// finishes.
+	RetryAfter time.Duration
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%s quota of %d used up", e.Quota, e.Limit)
+}
+
+func (e *QuotaError) Unwrap() error { return ErrQuotaExceeded }
+
+// Usage is a client's standing against its hourly request quota.
+type Usage struct {
+	Limit     int
+	Remaining int
+	Reset     time.Duration // until the window starts over
+}
+
+// window counts uses in a fixed window that starts with the first use after
+// the previous one ended.
+type window struct {
+	start time.Time
+	count int
+}
+
+// roll starts a new window if the current one, of the given length, is over.
+func (w *window) roll(now time.Time, length time.Duration) {
+	if now.Sub(w.start) >= length {
+		w.start, w.count = now, 0
+	}
+}
+
+func (w *window) reset(now time.Time, length time.Duration) time.Duration {
+	return w.start.Add(length).Sub(now)
+}
+
+type usage struct {
+	requests window
+	pages    window
+	reserved int // pages reserved by running jobs, not yet charged to pages
+	jobs     int
+}
+
+// Quotas counts what each client, identified by an opaque string, uses
+// against its limits: requests per hour, pages crawled per day and jobs at
+// once. Clients that have not used anything for a day are forgotten.
+type Quotas struct {
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+
+	mu      sync.Mutex
+	clients map[string]*usage
+	swept   time.Time
+	freed   chan struct{} // closed and cleared whenever a job is released
+}
+
+// Freed returns a channel that is closed the next time a job is released,
+// which may let a client held back by its concurrent jobs or reserved pages
+// start another.
+func (q *Quotas) Freed() <-chan struct{} {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.freed == nil {
+		q.freed = make(chan struct{})
+	}
+	return q.freed
+}
+
+// Request counts a request by client, or returns a QuotaError if its hourly
+// quota is used up.
+func (q *Quotas) Request(client string, limits Limits) (Usage, error) {
+	if limits.RequestsPerHour <= 0 {
+		return Usage{}, nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := q.now()
+	u := q.usage(client, now)
+	u.requests.roll(now, requestWindow)
+	reset := u.requests.reset(now, requestWindow)
+	if u.requests.count >= limits.RequestsPerHour {
+		return Usage{Limit: limits.RequestsPerHour, Reset: reset},
+			&QuotaError{Quota: QuotaRequests, Limit: limits.RequestsPerHour, RetryAfter: reset}
+	}
+	u.requests.count++
+	return Usage{Limit: limits.RequestsPerHour, Remaining: limits.RequestsPerHour - u.requests.count, Reset: reset}, nil
+}
+
+// StartJob counts a job started by client, reserving the most pages it may
+// crawl against the client's daily quota until it is released. It returns a
+// QuotaError if the client already runs as many jobs as it may, or if the
+// pages it has crawled and reserved for the day reach its quota. A job may
+// take the page count over the limit by less than its reservation; the next
+// one is refused.
+func (q *Quotas) StartJob(client string, limits Limits, pages int) (*Reservation, error) {
+	if limits.PagesPerDay <= 0 && limits.ConcurrentJobs <= 0 {
+		return nil, nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := q.now()
+	u := q.usage(client, now)
+	u.pages.roll(now, pageWindow)
+	if limits.PagesPerDay > 0 && u.pages.count+u.reserved >= limits.PagesPerDay {
+		var retryAfter time.Duration
+		if u.pages.count >= limits.PagesPerDay {
+			// Otherwise pages free up whenever a job finishes.
+			retryAfter = u.pages.reset(now, pageWindow)
+		}
+		return nil, &QuotaError{Quota: QuotaPages, Limit: limits.PagesPerDay, RetryAfter: retryAfter}
+	}
+	if limits.ConcurrentJobs > 0 && u.jobs >= limits.ConcurrentJobs {
+		return nil, &QuotaError{Quota: QuotaJobs, Limit: limits.ConcurrentJobs}
+	}
+	u.jobs++
+	u.reserved += pages
+	return &Reservation{quotas: q, client: client, pages: pages}, nil
+}
+
+// Reservation is a job counted against a client's quotas until it is
+// released. A nil Reservation, for a client without job limits, can be
+// released too.
+type Reservation struct {
+	quotas *Quotas
+	client string
+	pages  int // reserved when the job started
+	once   sync.Once
+}
+
+// Release ends the job, charging the pages it crawled to the client's daily
+// quota in place of those it reserved. Only the first call has an effect.
+func (r *Reservation) Release(pages int) {
+	if r == nil {
+		return
+	}
+	r.once.Do(func() {
+		q := r.quotas
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		now := q.now()
+		u := q.usage(r.client, now)
+		u.jobs--
+		u.reserved -= r.pages
+		u.pages.roll(now, pageWindow)
+		u.pages.count += pages
+		if q.freed != nil {
+			close(q.freed)
+			q.freed = nil
+		}
+	})
+}
+
+// usage returns the client's usage, forgetting idle clients now and then.
+// The caller holds q.mu.
+func (q *Quotas) usage(client string, now time.Time) *usage {
+	if now.Sub(q.swept) >= sweepInterval {
+		for id, u := range q.clients {
+			if u.jobs == 0 && now.Sub(u.requests.start) >= requestWindow && now.Sub(u.pages.start) >= pageWindow {
+				delete(q.clients, id)
+			}
+		}
+		q.swept = now
+	}
+	if q.clients == nil {
+		q.clients = make(map[string]*usage)
+	}
+	u, ok := q.clients[client]
+	if !ok {
+		u = &usage{}
+		q.clients[client] = u
+	}
+	return u
+}
+
+func (q *Quotas) now() time.Time {
+	if q.Now != nil {
+		return q.Now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,129 @@
+package usecases
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeClock is a settable time source.
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func TestQuotas_Requests(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)}
+	q := &Quotas{Now: clock.Now}
+	limits := Limits{RequestsPerHour: 2}
+
+	for want := 1; want >= 0; want-- {
+		usage, err := q.Request("alice", limits)
+		if err != nil || usage.Remaining != want || usage.Limit != 2 || usage.Reset != time.Hour {
+			t.Fatalf("Request() = %+v, %v; want %d remaining", usage, err, want)
+		}
+	}
+	clock.now = clock.now.Add(20 * time.Minute)
+	_, err := q.Request("alice", limits)
+	var quotaErr *QuotaError
+	if !errors.As(err, &quotaErr) || !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("Request() error = %v, want a QuotaError", err)
+	}
+	if quotaErr.Quota != QuotaRequests || quotaErr.RetryAfter != 40*time.Minute {
+		t.Errorf("QuotaError = %+v, want requests_per_hour retrying in 40m", quotaErr)
+	}
+	if _, err := q.Request("bob", limits); err != nil {
+		t.Errorf("Request() by another client error: %v", err)
+	}
+
+	clock.now = clock.now.Add(40 * time.Minute)
+	if usage, err := q.Request("alice", limits); err != nil || usage.Remaining != 1 {
+		t.Errorf("Request() after the window = %+v, %v; want a fresh window", usage, err)
+	}
+	if usage, err := q.Request("alice", Limits{}); err != nil || usage != (Usage{}) {
+		t.Errorf("Request() without a limit = %+v, %v; want nothing counted", usage, err)
+	}
+}
+
+func TestQuotas_Jobs(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)}
+	q := &Quotas{Now: clock.Now}
+	limits := Limits{PagesPerDay: 100, ConcurrentJobs: 1}
+
+	first, err := q.StartJob("alice", limits, 50)
+	if err != nil {
+		t.Fatalf("StartJob() error: %v", err)
+	}
+	var quotaErr *QuotaError
+	if _, err := q.StartJob("alice", limits, 50); !errors.As(err, &quotaErr) || quotaErr.Quota != QuotaJobs {
+		t.Fatalf("second StartJob() error = %v, want the concurrent_jobs quota", err)
+	}
+
+	first.Release(120)
+	first.Release(120) // no effect
+	clock.now = clock.now.Add(time.Hour)
+	if _, err := q.StartJob("alice", limits, 50); !errors.As(err, &quotaErr) || quotaErr.Quota != QuotaPages || quotaErr.RetryAfter != 23*time.Hour {
+		t.Fatalf("StartJob() over the page quota error = %v, want pages_per_day retrying in 23h", err)
+	}
+
+	clock.now = clock.now.Add(23 * time.Hour)
+	next, err := q.StartJob("alice", limits, 50)
+	if err != nil {
+		t.Fatalf("StartJob() the next day error: %v", err)
+	}
+	next.Release(0)
+
+	unlimited, err := q.StartJob("bob", Limits{RequestsPerHour: 5}, 50)
+	if err != nil || unlimited != nil {
+		t.Errorf("StartJob() without job limits = %v, %v; want nothing counted", unlimited, err)
+	}
+	unlimited.Release(10)
+}
+
+func TestQuotas_ReservesPages(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)}
+	q := &Quotas{Now: clock.Now}
+	limits := Limits{PagesPerDay: 250}
+
+	var running []*Reservation
+	for range 3 {
+		res, err := q.StartJob("alice", limits, 100)
+		if err != nil {
+			t.Fatalf("StartJob() error: %v", err)
+		}
+		running = append(running, res)
+	}
+	var quotaErr *QuotaError
+	if _, err := q.StartJob("alice", limits, 100); !errors.As(err, &quotaErr) || quotaErr.Quota != QuotaPages || quotaErr.RetryAfter != 0 {
+		t.Fatalf("StartJob() with every page reserved error = %v, want pages_per_day with no retry delay", err)
+	}
+
+	// Unused pages are refunded when a job finishes.
+	running[0].Release(10)
+	if _, err := q.StartJob("alice", limits, 100); err != nil {
+		t.Errorf("StartJob() after a refund error: %v", err)
+	}
+}
+
+func TestQuotas_Freed(t *testing.T) {
+	q := &Quotas{}
+	limits := Limits{ConcurrentJobs: 1}
+	res, err := q.StartJob("alice", limits, 0)
+	if err != nil {
+		t.Fatalf("StartJob() error: %v", err)
+	}
+	freed := q.Freed()
+	select {
+	case <-freed:
+		t.Fatal("Freed() closed before a job was released")
+	default:
+	}
+	res.Release(0)
+	select {
+	case <-freed:
+	default:
+		t.Fatal("Freed() not closed after a job was released")
+	}
+	if _, err := q.StartJob("alice", limits, 0); err != nil {
+		t.Errorf("StartJob() after the release error: %v", err)
+	}
+}
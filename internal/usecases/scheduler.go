@@ -0,0 +1,222 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+)
+
+// ErrScheduleNotFound is returned when no schedule has the requested ID.
+var ErrScheduleNotFound = errors.New("schedule not found")
+
+// ErrInvalidSchedule is returned when a schedule cannot be registered.
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// ScheduleStore keeps schedules across restarts.
+type ScheduleStore interface {
+	List() ([]domain.Schedule, error)
+	// Get returns the schedule with the given ID, or ErrScheduleNotFound.
+	Get(id string) (domain.Schedule, error)
+	// Put creates or replaces a schedule.
+	Put(schedule domain.Schedule) error
+	// Delete removes a schedule, or returns ErrScheduleNotFound.
+	Delete(id string) error
+}
+
+// Notifier tells a webhook that a scheduled site's llms.txt changed.
+type Notifier interface {
+	NotifyChange(ctx context.Context, webhookURL string, change domain.Change) error
+}
+
+// Slots limits how many crawls run at once.
+type Slots interface {
+	// AcquireSlot waits for a crawl slot, returning the function that frees
+	// it.
+	AcquireSlot(ctx context.Context) (release func(), err error)
+}
+
+// Scheduler regenerates registered sites on their cron schedules, one at a
+// time, and notifies their webhooks when the output changes.
+type Scheduler struct {
+	Generator Generator
+	Differ    Differ
+	Store     ScheduleStore
+
+	// Slots, if set, is where each run waits for a crawl slot, so that
+	// scheduled runs share the server's concurrency limit with requests.
+	Slots Slots
+
+	// Notifier, if set, delivers change notifications to schedules that have
+	// a webhook.
+	Notifier Notifier
+
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+
+	mu sync.Mutex // serializes updates to stored schedules
+}
+
+// Add validates and registers a schedule, returning it with its ID and first
+// run time.
+func (s *Scheduler) Add(schedule domain.Schedule) (domain.Schedule, error) {
+	cron, err := ParseCron(schedule.Cron, schedule.Timezone)
+	if err != nil {
+		return domain.Schedule{}, fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+	now := s.now()
+	next := cron.Next(now)
+	if next.IsZero() {
+		return domain.Schedule{}, fmt.Errorf("%w: cron expression %q never matches", ErrInvalidSchedule, schedule.Cron)
+	}
+	if u, err := url.Parse(schedule.URL); err != nil || u.Host == "" {
+		return domain.Schedule{}, fmt.Errorf("%w: invalid URL %q", ErrInvalidSchedule, schedule.URL)
+	}
+
+	schedule.ID = rand.Text()
+	schedule.Options.Credentials = domain.Credentials{}
+	schedule.Options.Previous = nil
+	schedule.CreatedAt = now
+	schedule.NextRunAt = next
+	schedule.LastRunAt, schedule.LastHistoryID, schedule.LastLlmsTxt = time.Time{}, "", ""
+	schedule.LastError, schedule.NotifyError = "", ""
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.Store.Put(schedule); err != nil {
+		return domain.Schedule{}, err
+	}
+	return schedule, nil
+}
+
+// List returns every registered schedule.
+func (s *Scheduler) List() ([]domain.Schedule, error) { return s.Store.List() }
+
+// Get returns the schedule with the given ID.
+func (s *Scheduler) Get(id string) (domain.Schedule, error) { return s.Store.Get(id) }
+
+// Remove unregisters a schedule. A run already in progress finishes, but its
+// outcome is not stored.
+func (s *Scheduler) Remove(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Store.Delete(id)
+}
+
+// Run checks for due schedules every interval until ctx is cancelled. Runs
+// missed while the server was down are made up once, on the first check.
+func (s *Scheduler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.RunDue(ctx)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// RunDue regenerates every site whose next run time has passed, in turn.
+func (s *Scheduler) RunDue(ctx context.Context) {
+	schedules, err := s.Store.List()
+	if err != nil {
+		return
+	}
+	for _, schedule := range schedules {
+		if ctx.Err() != nil {
+			return
+		}
+		if !schedule.NextRunAt.After(s.now()) {
+			s.run(ctx, schedule)
+		}
+	}
+}
+
+// run regenerates one site, notifies its webhook if the output changed, and
+// stores the outcome along with the next run time. The site is always
+// crawled afresh, since a cached result would hide any change, and the run,
+// with its history entry and notification, belongs to the schedule's owner.
+func (s *Scheduler) run(ctx context.Context, schedule domain.Schedule) {
+	ctx = WithOwner(ctx, schedule.Owner)
+	started := s.now()
+	result, err := s.generate(ctx, schedule)
+	if ctx.Err() != nil {
+		// Shutting down: the run is retried on the next start.
+		return
+	}
+
+	schedule.LastRunAt = started
+	schedule.LastError = ""
+	if err != nil {
+		schedule.LastError = err.Error()
+	} else {
+		previous := schedule.LastLlmsTxt
+		schedule.LastLlmsTxt = result.LlmsTxt
+		schedule.LastHistoryID = result.HistoryID
+		if previous != "" && previous != result.LlmsTxt {
+			schedule.NotifyError = s.notify(ctx, schedule, previous, result)
+		}
+	}
+	if cron, err := ParseCron(schedule.Cron, schedule.Timezone); err == nil {
+		schedule.NextRunAt = cron.Next(s.now())
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.Store.Get(schedule.ID); err != nil {
+		// Removed while running.
+		return
+	}
+	_ = s.Store.Put(schedule)
+}
+
+// generate crawls the schedule's site once a crawl slot is free.
+func (s *Scheduler) generate(ctx context.Context, schedule domain.Schedule) (domain.Result, error) {
+	if s.Slots != nil {
+		release, err := s.Slots.AcquireSlot(ctx)
+		if err != nil {
+			return domain.Result{}, err
+		}
+		defer release()
+	}
+	opts := schedule.Options
+	opts.ForceRefresh = true
+	return s.Generator.Generate(ctx, schedule.URL, opts)
+}
+
+// notify sends the change to the schedule's webhook, returning why it could
+// not be delivered, if it was not.
+func (s *Scheduler) notify(ctx context.Context, schedule domain.Schedule, previous string, result domain.Result) string {
+	if s.Notifier == nil || schedule.WebhookURL == "" {
+		return ""
+	}
+	diff, err := s.Differ.Diff(previous, result.LlmsTxt)
+	if err != nil {
+		return "computing diff: " + err.Error()
+	}
+	change := domain.Change{
+		ScheduleID:  schedule.ID,
+		URL:         schedule.URL,
+		GeneratedAt: result.GeneratedAt,
+		HistoryID:   result.HistoryID,
+		Diff:        diff,
+	}
+	if err := s.Notifier.NotifyChange(ctx, schedule.WebhookURL, change); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+func (s *Scheduler) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
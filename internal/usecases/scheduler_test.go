@@ -0,0 +1,255 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+)
+
+// memSchedules is a ScheduleStore in a map.
+type memSchedules map[string]domain.Schedule
+
+func (m memSchedules) List() ([]domain.Schedule, error) {
+	out := make([]domain.Schedule, 0, len(m))
+	for _, s := range m {
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+func (m memSchedules) Get(id string) (domain.Schedule, error) {
+	s, ok := m[id]
+	if !ok {
+		return domain.Schedule{}, ErrScheduleNotFound
+	}
+	return s, nil
+}
+
+func (m memSchedules) Put(s domain.Schedule) error {
+	m[s.ID] = s
+	return nil
+}
+
+func (m memSchedules) Delete(id string) error {
+	if _, ok := m[id]; !ok {
+		return ErrScheduleNotFound
+	}
+	delete(m, id)
+	return nil
+}
+
+// sequenceGenerator returns its outputs in turn, recording the options and
+// owner of the last call.
+type sequenceGenerator struct {
+	outputs []string
+	calls   int
+	opts    domain.Options
+	owner   string
+}
+
+func (g *sequenceGenerator) Generate(ctx context.Context, _ string, opts domain.Options) (domain.Result, error) {
+	out := g.outputs[min(g.calls, len(g.outputs)-1)]
+	g.calls++
+	g.opts, g.owner = opts, Owner(ctx)
+	if out == "" {
+		return domain.Result{}, errors.New("site unreachable")
+	}
+	return domain.Result{LlmsTxt: out, HistoryID: out}, nil
+}
+
+type recordingNotifier struct {
+	changes []domain.Change
+	err     error
+}
+
+func (n *recordingNotifier) NotifyChange(_ context.Context, _ string, change domain.Change) error {
+	n.changes = append(n.changes, change)
+	return n.err
+}
+
+type lineDiffer struct{}
+
+func (lineDiffer) Diff(oldText, newText string) (domain.Diff, error) {
+	return domain.Diff{Unified: "-" + oldText + "+" + newText}, nil
+}
+
+func newTestScheduler(outputs ...string) (*Scheduler, *sequenceGenerator, *recordingNotifier, *time.Time) {
+	now := time.Date(2026, 10, 14, 2, 59, 0, 0, time.UTC)
+	gen := &sequenceGenerator{outputs: outputs}
+	notifier := &recordingNotifier{}
+	s := &Scheduler{
+		Generator: gen,
+		Differ:    lineDiffer{},
+		Store:     memSchedules{},
+		Notifier:  notifier,
+		Now:       func() time.Time { return now },
+	}
+	return s, gen, notifier, &now
+}
+
+func TestScheduler_Add(t *testing.T) {
+	s, _, _, _ := newTestScheduler("a")
+
+	schedule, err := s.Add(domain.Schedule{
+		URL:     "https://example.com",
+		Cron:    "0 3 * * *",
+		Options: domain.Options{Language: "en", Credentials: domain.Credentials{Username: "bot", Password: "secret"}},
+	})
+	if err != nil {
+		t.Fatalf("Add() error: %v", err)
+	}
+	if schedule.ID == "" || !schedule.NextRunAt.Equal(time.Date(2026, 10, 14, 3, 0, 0, 0, time.UTC)) {
+		t.Errorf("Add() = %+v, want an ID and the next 3am", schedule)
+	}
+	if !schedule.Options.Credentials.IsZero() {
+		t.Error("credentials were stored")
+	}
+	if stored, err := s.Get(schedule.ID); err != nil || stored.URL != "https://example.com" {
+		t.Errorf("Get() = %+v, %v", stored, err)
+	}
+
+	for _, invalid := range []domain.Schedule{
+		{URL: "https://example.com", Cron: "every night"},
+		{URL: "https://example.com", Cron: "0 0 30 2 *"},
+		{URL: "https://example.com", Cron: "@daily", Timezone: "Nowhere/Special"},
+	} {
+		if _, err := s.Add(invalid); !errors.Is(err, ErrInvalidSchedule) {
+			t.Errorf("Add(%q, %q) error = %v, want ErrInvalidSchedule", invalid.Cron, invalid.Timezone, err)
+		}
+	}
+}
+
+func TestScheduler_RunDue(t *testing.T) {
+	s, gen, notifier, now := newTestScheduler("# v1\n", "# v1\n", "# v2\n", "")
+	schedule, err := s.Add(domain.Schedule{URL: "https://example.com", Cron: "0 3 * * *", WebhookURL: "https://hooks.example/llms"})
+	if err != nil {
+		t.Fatalf("Add() error: %v", err)
+	}
+
+	// Not due yet.
+	s.RunDue(context.Background())
+	if gen.calls != 0 {
+		t.Fatalf("generated %d times before the schedule was due", gen.calls)
+	}
+
+	runNight := func() domain.Schedule {
+		*now = now.Add(24 * time.Hour)
+		s.RunDue(context.Background())
+		got, err := s.Get(schedule.ID)
+		if err != nil {
+			t.Fatalf("Get() error: %v", err)
+		}
+		return got
+	}
+
+	first := runNight()
+	if gen.calls != 1 || first.LastLlmsTxt != "# v1\n" || first.LastHistoryID != "# v1\n" || len(notifier.changes) != 0 {
+		t.Fatalf("after the first run: %+v, %d notifications; want the baseline stored without notifying", first, len(notifier.changes))
+	}
+	if want := time.Date(2026, 10, 15, 3, 0, 0, 0, time.UTC); !first.NextRunAt.Equal(want) {
+		t.Errorf("NextRunAt = %v, want %v", first.NextRunAt, want)
+	}
+
+	runNight() // unchanged
+	if len(notifier.changes) != 0 {
+		t.Fatalf("notified %d times for an unchanged output", len(notifier.changes))
+	}
+
+	runNight() // changed
+	if len(notifier.changes) != 1 {
+		t.Fatalf("notified %d times, want 1", len(notifier.changes))
+	}
+	change := notifier.changes[0]
+	if change.ScheduleID != schedule.ID || change.Diff.Unified != "-# v1\n+# v2\n" || change.HistoryID != "# v2\n" {
+		t.Errorf("change = %+v", change)
+	}
+
+	failed := runNight() // fails
+	if failed.LastError != "site unreachable" || failed.LastLlmsTxt != "# v2\n" {
+		t.Errorf("after a failed run: LastError = %q, LastLlmsTxt = %q; want the error and the last good output", failed.LastError, failed.LastLlmsTxt)
+	}
+}
+
+func TestScheduler_RecordsNotifyError(t *testing.T) {
+	s, _, notifier, now := newTestScheduler("# v1\n", "# v2\n")
+	notifier.err = errors.New("webhook responded with HTTP 500")
+	schedule, err := s.Add(domain.Schedule{URL: "https://example.com", Cron: "@hourly", WebhookURL: "https://hooks.example/llms"})
+	if err != nil {
+		t.Fatalf("Add() error: %v", err)
+	}
+	for range 2 {
+		*now = now.Add(time.Hour)
+		s.RunDue(context.Background())
+	}
+	got, _ := s.Get(schedule.ID)
+	if got.NotifyError != "webhook responded with HTTP 500" {
+		t.Errorf("NotifyError = %q", got.NotifyError)
+	}
+}
+
+func TestScheduler_RemovedWhileRunning(t *testing.T) {
+	s, _, _, now := newTestScheduler("# v1\n")
+	schedule, err := s.Add(domain.Schedule{URL: "https://example.com", Cron: "@hourly"})
+	if err != nil {
+		t.Fatalf("Add() error: %v", err)
+	}
+	s.Generator = generatorFunc(func() {
+		if err := s.Remove(schedule.ID); err != nil {
+			t.Errorf("Remove() error: %v", err)
+		}
+	})
+	*now = now.Add(time.Hour)
+	s.RunDue(context.Background())
+	if _, err := s.Get(schedule.ID); !errors.Is(err, ErrScheduleNotFound) {
+		t.Errorf("Get() error = %v, want the removed schedule to stay removed", err)
+	}
+}
+
+// countingSlots counts the slots acquired and released.
+type countingSlots struct {
+	acquired, released int
+	err                error
+}
+
+func (c *countingSlots) AcquireSlot(context.Context) (func(), error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	c.acquired++
+	return func() { c.released++ }, nil
+}
+
+func TestScheduler_RunsAfreshInASlot(t *testing.T) {
+	s, gen, _, now := newTestScheduler("# v1\n")
+	slots := &countingSlots{}
+	s.Slots = slots
+	if _, err := s.Add(domain.Schedule{URL: "https://example.com", Cron: "@hourly", Owner: "key:partner"}); err != nil {
+		t.Fatalf("Add() error: %v", err)
+	}
+	*now = now.Add(time.Hour)
+	s.RunDue(context.Background())
+	if !gen.opts.ForceRefresh || gen.owner != "key:partner" {
+		t.Errorf("generated with %+v for %q, want a forced refresh for the schedule's owner", gen.opts, gen.owner)
+	}
+	if slots.acquired != 1 || slots.released != 1 {
+		t.Errorf("acquired %d slots and released %d, want 1 each", slots.acquired, slots.released)
+	}
+
+	slots.err = errors.New("timed out waiting for a crawl slot")
+	*now = now.Add(time.Hour)
+	s.RunDue(context.Background())
+	if list, _ := s.List(); gen.calls != 1 || list[0].LastError != slots.err.Error() {
+		t.Errorf("after a slot error: %d calls, LastError = %q", gen.calls, list[0].LastError)
+	}
+}
+
+// generatorFunc calls a function before returning an empty result.
+type generatorFunc func()
+
+func (f generatorFunc) Generate(context.Context, string, domain.Options) (domain.Result, error) {
+	f()
+	return domain.Result{LlmsTxt: "# v1\n"}, nil
+}
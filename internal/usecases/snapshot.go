@@ -0,0 +1,74 @@
+package usecases
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+)
+
+type previousKey struct{}
+
+// WithPrevious returns a copy of ctx carrying the pages of a previous crawl,
+// which the crawler revalidates instead of fetching them from scratch.
+func WithPrevious(ctx context.Context, snapshot *domain.Snapshot) context.Context {
+	if snapshot == nil || len(snapshot.Pages) == 0 {
+		return ctx
+	}
+	pages := make(map[string]domain.Page, len(snapshot.Pages))
+	for _, p := range snapshot.Pages {
+		pages[p.URL] = p
+	}
+	return context.WithValue(ctx, previousKey{}, pages)
+}
+
+// PreviousPage returns the page at pageURL as it was in the previous crawl
+// carried by ctx.
+func PreviousPage(ctx context.Context, pageURL string) (domain.Page, bool) {
+	pages, _ := ctx.Value(previousKey{}).(map[string]domain.Page)
+	p, ok := pages[pageURL]
+	return p, ok
+}
+
+// loadSnapshot returns the last snapshot saved for siteURL, if any.
+func (s *Service) loadSnapshot(siteURL string) *domain.Snapshot {
+	key, ok := snapshotKey(siteURL)
+	if !ok {
+		return nil
+	}
+	data, ok := s.Snapshots.Get(key)
+	if !ok {
+		return nil
+	}
+	var snapshot domain.Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil
+	}
+	return &snapshot
+}
+
+// saveSnapshot keeps a snapshot for the next crawl of its site. A snapshot
+// that cannot be stored only means the next crawl starts from scratch.
+func (s *Service) saveSnapshot(snapshot domain.Snapshot) {
+	key, ok := snapshotKey(snapshot.SiteURL)
+	if !ok {
+		return
+	}
+	if data, err := json.Marshal(snapshot); err == nil {
+		_ = s.Snapshots.Put(key, data)
+	}
+}
+
+// snapshotKey returns the store key for the snapshot of siteURL. Snapshots
+// hold every fetched page, whatever the options, so only the URL is keyed.
+func snapshotKey(siteURL string) (string, bool) {
+	u, err := url.Parse(siteURL)
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256([]byte(normalizeURL(u)))
+	return "snapshot:" + hex.EncodeToString(sum[:]), true
+}
@@ -0,0 +1,142 @@
+package usecases
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/adsouza/llms.txt-generator/internal/domain"
+)
+
+// snapshotCrawler records which pages were fetched with a previous version.
+type snapshotCrawler struct {
+	fakeCrawler
+	revalidated []string
+}
+
+func (c *snapshotCrawler) FetchPage(ctx context.Context, pageURL string) (domain.Page, error) {
+	if prev, ok := PreviousPage(ctx, pageURL); ok {
+		c.revalidated = append(c.revalidated, pageURL)
+		return prev, nil
+	}
+	page, err := c.fakeCrawler.FetchPage(ctx, pageURL)
+	page.ContentHash = "hash of " + pageURL
+	return page, err
+}
+
+func TestGenerate_StartsFromPreviousSnapshot(t *testing.T) {
+	crawler := &snapshotCrawler{fakeCrawler: fakeCrawler{pages: []domain.Page{
+		{URL: "https://example.com/", Title: "Example"},
+		{URL: "https://example.com/docs/intro", Title: "Intro"},
+	}}}
+	// Without a result cache, every generation crawls, but each starts from
+	// the snapshot saved by the one before.
+	svc := &Service{Crawler: crawler, Formatter: &fakeFormatter{}, Snapshots: mapCache{}}
+
+	first, err := svc.Generate(context.Background(), "https://example.com", domain.Options{})
+	if err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	if len(crawler.revalidated) != 0 {
+		t.Errorf("first crawl revalidated %v", crawler.revalidated)
+	}
+	if len(first.Snapshot.Pages) != 2 || first.Snapshot.Pages[1].ContentHash == "" || !first.Snapshot.CreatedAt.Equal(first.GeneratedAt) {
+		t.Errorf("snapshot = %+v", first.Snapshot)
+	}
+
+	// A new page appears in the sitemap; only it is fetched from scratch.
+	crawler.pages = append(crawler.pages, domain.Page{URL: "https://example.com/docs/new", Title: "New"})
+	second, err := svc.Generate(context.Background(), "https://example.com/", domain.Options{})
+	if err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	if len(crawler.revalidated) != 2 || len(second.Snapshot.Pages) != 3 {
+		t.Errorf("revalidated %v, snapshot has %d pages; want 2 revalidated of 3", crawler.revalidated, len(second.Snapshot.Pages))
+	}
+
+	// A forced refresh ignores the snapshot.
+	crawler.revalidated = nil
+	if _, err := svc.Generate(context.Background(), "https://example.com", domain.Options{ForceRefresh: true}); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	if len(crawler.revalidated) != 0 {
+		t.Errorf("forced refresh revalidated %v", crawler.revalidated)
+	}
+}
+
+func TestGenerate_CredentialsSkipSnapshot(t *testing.T) {
+	crawler := &snapshotCrawler{fakeCrawler: fakeCrawler{pages: []domain.Page{
+		{URL: "https://example.com/", Title: "Example"},
+	}}}
+	snapshots := mapCache{}
+	svc := &Service{Crawler: crawler, Formatter: &fakeFormatter{}, Snapshots: snapshots}
+	opts := domain.Options{Credentials: domain.Credentials{Headers: map[string]string{"Authorization": "Bearer secret"}}}
+
+	if _, err := svc.Generate(context.Background(), "https://example.com", opts); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	if len(snapshots) != 0 {
+		t.Errorf("credentialed crawl saved %d snapshots", len(snapshots))
+	}
+
+	if _, err := svc.Generate(context.Background(), "https://example.com", domain.Options{}); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	if _, err := svc.Generate(context.Background(), "https://example.com", opts); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	if len(crawler.revalidated) != 0 {
+		t.Errorf("credentialed crawl revalidated %v", crawler.revalidated)
+	}
+}
+
+func TestGenerate_ExplicitPreviousSnapshot(t *testing.T) {
+	crawler := &snapshotCrawler{fakeCrawler: fakeCrawler{pages: []domain.Page{
+		{URL: "https://example.com/", Title: "Example"},
+		{URL: "https://example.com/docs/intro", Title: "Intro"},
+	}}}
+	svc := &Service{Crawler: crawler, Formatter: &fakeFormatter{}}
+
+	previous := &domain.Snapshot{SiteURL: "https://example.com", Pages: []domain.Page{{URL: "https://example.com/docs/intro", Title: "Intro"}}}
+	result, err := svc.Generate(context.Background(), "https://example.com", domain.Options{Previous: previous})
+	if err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	if len(crawler.revalidated) != 1 || crawler.revalidated[0] != "https://example.com/docs/intro" {
+		t.Errorf("revalidated %v, want the intro page", crawler.revalidated)
+	}
+	if result.Snapshot.SiteURL != "https://example.com" || len(result.Snapshot.Pages) != 2 {
+		t.Errorf("snapshot = %+v", result.Snapshot)
+	}
+}
+
+func TestGenerate_CacheHitKeepsSnapshot(t *testing.T) {
+	crawler := &snapshotCrawler{fakeCrawler: fakeCrawler{pages: []domain.Page{
+		{URL: "https://example.com/", Title: "Example"},
+		{URL: "https://example.com/docs/intro", Title: "Intro"},
+	}}}
+	svc := &Service{Crawler: crawler, Formatter: &fakeFormatter{}, Cache: mapCache{}, CacheTTL: time.Hour, Snapshots: mapCache{}}
+
+	if _, err := svc.Generate(context.Background(), "https://example.com", domain.Options{}); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	hit, err := svc.Generate(context.Background(), "https://example.com", domain.Options{})
+	if err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	if len(hit.Snapshot.Pages) != 2 {
+		t.Fatalf("cached result snapshot has %d pages, want 2", len(hit.Snapshot.Pages))
+	}
+
+	// Handing the snapshot back bypasses the cache and revalidates its pages.
+	result, err := svc.Generate(context.Background(), "https://example.com", domain.Options{Previous: &hit.Snapshot})
+	if err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	if len(crawler.revalidated) != 2 {
+		t.Errorf("revalidated %v, want both pages", crawler.revalidated)
+	}
+	if result.GeneratedAt.Equal(hit.GeneratedAt) {
+		t.Error("conditional run returned the cached result")
+	}
+}